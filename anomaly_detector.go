@@ -0,0 +1,183 @@
+package simplelogr
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultDetectorWindow is the period over which a RateDetector counts entries
+	DefaultDetectorWindow = time.Minute
+)
+
+// Detector observes a stream of entries to detect anomalies, see AttachDetector
+type Detector interface {
+	Observe(e Entry)
+}
+
+// AttachDetector feeds every Entry published by the BroadcastSink to the Detector, the returned function detaches it
+func AttachDetector(b *BroadcastSink, d Detector) (detach func()) {
+	return b.Subscribe(d.Observe)
+}
+
+// Anomaly describes an unusual rate of entries spotted by a RateDetector
+type Anomaly struct {
+	// Severity is the severity name of the entries
+	Severity string
+	// Fingerprint identifies the kind of entry (its severity, logger name and message) when the anomaly is specific to
+	// one kind of entry, and is empty when the anomaly concerns all entries of the Severity
+	Fingerprint string
+	// Count is the number of matching entries seen within the Window
+	Count int
+	// Window is the period the entries were counted over
+	Window time.Duration
+	// Entry is the entry that caused the threshold to be exceeded
+	Entry Entry
+}
+
+// RateDetectorOptions configures the behaviour of a RateDetector
+type RateDetectorOptions struct {
+	// Window is the sliding period over which entries are counted
+	Window time.Duration
+	// SeverityThresholds maps severity names to the number of entries within the Window at which an Anomaly is reported
+	SeverityThresholds map[string]int
+	// FingerprintThreshold is the number of entries of the same kind (severity, logger name and message) within the
+	// Window at which an Anomaly is reported, zero disables detection by fingerprint
+	FingerprintThreshold int
+	// Cooldown is the minimum time between reporting anomalies for the same severity or fingerprint, defaults to the
+	// Window
+	Cooldown time.Duration
+	// SeverityEncoder identifies the severity name based on the verbosity level and the presence of any errors
+	SeverityEncoder func(level int, err error) string
+	// OnAnomaly is called whenever an anomaly is detected, e.g. to boost verbosity using VerbosityController.Boost or
+	// to log to an alerting sink. It is called synchronously, so should return quickly.
+	OnAnomaly func(a Anomaly)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (r *RateDetectorOptions) AssertDefaults() {
+	if r.Window <= 0 {
+		r.Window = DefaultDetectorWindow
+	}
+
+	if r.Cooldown <= 0 {
+		r.Cooldown = r.Window
+	}
+
+	if r.SeverityEncoder == nil {
+		r.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if r.OnAnomaly == nil {
+		r.OnAnomaly = func(a Anomaly) {}
+	}
+}
+
+// RateDetector is a Detector which reports an Anomaly when the rate of entries of a given severity, or of a given
+// kind, spikes above a threshold - providing simple in-process log-based alerting
+type RateDetector struct {
+	options   RateDetectorOptions
+	lock      sync.Mutex
+	seen      map[string][]time.Time
+	lastFired map[string]time.Time
+	// lastPruned is when keys that are no longer being seen were last forgotten, see prune
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+// NewRateDetector creates a new RateDetector with the provided options
+func NewRateDetector(opts RateDetectorOptions) *RateDetector {
+	return &RateDetector{
+		options:   opts,
+		seen:      map[string][]time.Time{},
+		lastFired: map[string]time.Time{},
+		now:       time.Now,
+	}
+}
+
+// Observe implements Detector, counting the Entry and reporting any resulting anomalies
+func (r *RateDetector) Observe(e Entry) {
+	severity := e.severity(r.options.SeverityEncoder)
+
+	var anomalies []Anomaly
+
+	r.lock.Lock()
+	now := r.now()
+
+	if threshold, ok := r.options.SeverityThresholds[severity]; ok {
+		if count, fire := r.count("severity:"+severity, threshold, now); fire {
+			anomalies = append(anomalies, Anomaly{
+				Severity: severity,
+				Count:    count,
+				Window:   r.options.Window,
+				Entry:    e,
+			})
+		}
+	}
+
+	if r.options.FingerprintThreshold > 0 {
+		fingerprint := severity + "|" + strings.Join(e.Names, DefaultNameSeparator) + "|" + e.Message
+		if count, fire := r.count("fingerprint:"+fingerprint, r.options.FingerprintThreshold, now); fire {
+			anomalies = append(anomalies, Anomaly{
+				Severity:    severity,
+				Fingerprint: fingerprint,
+				Count:       count,
+				Window:      r.options.Window,
+				Entry:       e,
+			})
+		}
+	}
+
+	// pruning scans every key, so it is done at most once per window rather than for every entry
+	if now.Sub(r.lastPruned) >= r.options.Window {
+		r.prune(now)
+		r.lastPruned = now
+	}
+	r.lock.Unlock()
+
+	for _, a := range anomalies {
+		r.options.OnAnomaly(a)
+	}
+}
+
+// count records an occurrence of the key, reporting the count within the window and whether an anomaly should fire
+func (r *RateDetector) count(key string, threshold int, now time.Time) (int, bool) {
+	cutoff := now.Add(-r.options.Window)
+
+	times := r.seen[key]
+	for len(times) > 0 && !times[0].After(cutoff) {
+		times = times[1:]
+	}
+	times = append(times, now)
+	r.seen[key] = times
+
+	if len(times) < threshold {
+		return len(times), false
+	}
+
+	if last, ok := r.lastFired[key]; ok && now.Sub(last) < r.options.Cooldown {
+		return len(times), false
+	}
+	r.lastFired[key] = now
+
+	return len(times), true
+}
+
+// prune forgets keys that have not been seen within the window, so that memory use is bounded by the variety of
+// recent entries. As it runs once per window, keys are forgotten within two windows of last being seen
+func (r *RateDetector) prune(now time.Time) {
+	cutoff := now.Add(-r.options.Window)
+	for key, times := range r.seen {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(r.seen, key)
+		}
+	}
+	for key, last := range r.lastFired {
+		if now.Sub(last) >= r.options.Cooldown {
+			delete(r.lastFired, key)
+		}
+	}
+}
+
+var _ Detector = (*RateDetector)(nil)
@@ -0,0 +1,47 @@
+package simplelogr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateDetectorPrunesOncePerWindow(t *testing.T) {
+	var anomalies []Anomaly
+	opts := RateDetectorOptions{
+		Window:               time.Minute,
+		FingerprintThreshold: 2,
+		OnAnomaly: func(a Anomaly) {
+			anomalies = append(anomalies, a)
+		},
+	}
+	opts.AssertDefaults()
+	detector := NewRateDetector(opts)
+
+	now := time.Date(2021, time.October, 2, 12, 0, 0, 0, time.UTC)
+	detector.now = func() time.Time {
+		return now
+	}
+
+	// the first entry prunes, so the keys of later entries within the window are kept until the next prune
+	for i := 0; i < 100; i++ {
+		detector.Observe(Entry{Message: fmt.Sprintf("message %d", i)})
+		now = now.Add(time.Second / 2)
+	}
+	if len(detector.seen) != 100 {
+		t.Errorf("expected 100 keys to be tracked before pruning, got %d", len(detector.seen))
+	}
+
+	// once the window has passed the keys that have not been seen since are forgotten
+	now = now.Add(time.Minute)
+	detector.Observe(Entry{Message: "message 0"})
+	if len(detector.seen) != 1 {
+		t.Errorf("expected only the key seen after pruning to be tracked, got %d", len(detector.seen))
+	}
+
+	// entries within the window are still counted between prunes
+	detector.Observe(Entry{Message: "message 0"})
+	if len(anomalies) != 1 || anomalies[0].Count != 2 {
+		t.Errorf("expected an anomaly counting 2 entries, got %v", anomalies)
+	}
+}
@@ -0,0 +1,277 @@
+package simplelogr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultAsyncQueueSize is the number of entries an AsyncSink queues before dropping new ones
+	DefaultAsyncQueueSize = 1024
+)
+
+// AsyncSink queues log Entry objects and emits them to another LogSink in the background, so that slow destinations
+// (e.g. files on network storage) do not block logging. Entries are emitted in the order they were queued, once Start
+// has been called or when Flush or Close are called. If the queue is full new entries are dropped, as are entries that
+// have been queued for longer than the Expiry policy allows.
+//
+// With a PriorityQueueSize, entries with one of the PrioritySeverities (by default errors) are queued separately with
+// capacity reserved for them, and are emitted ahead of other entries, so that the most important information is
+// still delivered first when the sink is overloaded.
+type AsyncSink struct {
+	options AsyncSinkOptions
+
+	lock          sync.Mutex
+	queue         []queuedEntry
+	priorityQueue []queuedEntry
+	dropped       uint64
+	wake          chan struct{}
+
+	// drainLock ensures entries are emitted in the order they were queued
+	drainLock sync.Mutex
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// queuedEntry is an Entry waiting to be emitted, along with the memory reserved for it
+type queuedEntry struct {
+	entry Entry
+	size  int64
+}
+
+// NewAsyncSink creates a new AsyncSink with the provided options
+func NewAsyncSink(opts AsyncSinkOptions) *AsyncSink {
+	return &AsyncSink{
+		options: opts,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Log implements LogSink, queueing the Entry to be emitted in the background
+func (a *AsyncSink) Log(e Entry) error {
+	size := a.options.Memory.size(e)
+
+	priority := a.options.PriorityQueueSize > 0 && a.isPriority(e)
+
+	a.lock.Lock()
+	queue := &a.queue
+	if priority {
+		queue = &a.priorityQueue
+	}
+	// priority entries beyond the capacity reserved for them share the capacity of the queue
+	shared := len(a.queue)
+	if overflow := len(a.priorityQueue) - a.options.PriorityQueueSize; overflow > 0 {
+		shared += overflow
+	}
+	if (!priority || len(a.priorityQueue) >= a.options.PriorityQueueSize) && shared >= a.options.QueueSize {
+		if !priority || len(a.queue) == 0 {
+			a.dropped++
+			a.lock.Unlock()
+			return fmt.Errorf("async sink queue is full")
+		}
+		// make room by discarding the oldest entry queued without priority
+		evicted, _ := a.evictLocked()
+		a.options.Memory.release(evicted)
+	}
+	if !a.options.Memory.reserve(size, a.evictLocked) {
+		a.dropped++
+		a.lock.Unlock()
+		return fmt.Errorf("async sink memory budget exceeded")
+	}
+	*queue = append(*queue, queuedEntry{entry: e, size: size})
+	a.lock.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Flush emits all queued entries to the underlying sink, priority entries first, returning any errors it reports
+func (a *AsyncSink) Flush() error {
+	a.drainLock.Lock()
+	defer a.drainLock.Unlock()
+
+	a.lock.Lock()
+	queue := append(a.priorityQueue, a.queue...)
+	a.priorityQueue = nil
+	a.queue = nil
+	a.lock.Unlock()
+
+	now := time.Now()
+	var expired expiredEntries
+	deliver := queue[:0]
+	for _, queued := range queue {
+		a.options.Memory.release(queued.size)
+		if a.options.Expiry.expired(queued.entry.Timestamp, now) {
+			expired.add(queued.entry.Timestamp)
+			continue
+		}
+		deliver = append(deliver, queued)
+	}
+
+	var errs multiError
+	if expired.count > 0 {
+		a.lock.Lock()
+		a.dropped += expired.count
+		a.lock.Unlock()
+
+		if summary, ok := a.options.Expiry.summary(expired, now); ok {
+			if err := a.options.Sink.Log(summary); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, queued := range deliver {
+		if err := a.options.Sink.Log(queued.entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// isPriority determines whether an entry has one of the PrioritySeverities
+func (a *AsyncSink) isPriority(e Entry) bool {
+	severity := e.severity(a.options.SeverityEncoder)
+	for _, s := range a.options.PrioritySeverities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// evictLocked discards the oldest queued entry to make room for a new one, preferring to keep priority entries, see
+// MemoryPolicyShrink
+func (a *AsyncSink) evictLocked() (int64, bool) {
+	queue := &a.queue
+	if len(a.queue) == 0 {
+		queue = &a.priorityQueue
+	}
+	if len(*queue) == 0 {
+		return 0, false
+	}
+
+	oldest := (*queue)[0]
+	(*queue)[0] = queuedEntry{}
+	*queue = (*queue)[1:]
+	a.dropped++
+
+	return oldest.size, true
+}
+
+// Dropped implements DropCounter, reporting how many entries were discarded because the queue was full, the memory
+// budget was exceeded, or they expired
+func (a *AsyncSink) Dropped() uint64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.dropped
+}
+
+// Start begins emitting queued entries in the background, until the context is cancelled or Close is called
+func (a *AsyncSink) Start(ctx context.Context) {
+	a.lifecycleLock.Lock()
+	defer a.lifecycleLock.Unlock()
+
+	if a.done != nil {
+		return
+	}
+
+	ctx, a.cancel = context.WithCancel(ctx)
+	a.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		for {
+			if err := a.Flush(); err != nil {
+				a.options.ErrorHandler(err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.wake:
+			}
+		}
+	}(a.done)
+}
+
+// Close stops any background emitting started by Start, and emits any remaining queued entries
+func (a *AsyncSink) Close() error {
+	a.lifecycleLock.Lock()
+	if a.done != nil {
+		a.cancel()
+		<-a.done
+		a.done = nil
+	}
+	a.lifecycleLock.Unlock()
+
+	return a.Flush()
+}
+
+// Unwrap implements WrapperSink, returning the sink queued entries are emitted to
+func (a *AsyncSink) Unwrap() []LogSink {
+	return []LogSink{a.options.Sink}
+}
+
+var _ LogSink = (*AsyncSink)(nil)
+var _ DropCounter = (*AsyncSink)(nil)
+var _ CloserSink = (*AsyncSink)(nil)
+var _ WrapperSink = (*AsyncSink)(nil)
+
+// AsyncSinkOptions configures the behaviour of an AsyncSink
+type AsyncSinkOptions struct {
+	// Sink is where queued entries are emitted to
+	Sink LogSink
+	// QueueSize is the number of entries queued before new entries are dropped
+	QueueSize int
+	// PriorityQueueSize is the number of entries with one of the PrioritySeverities that can be queued in addition to
+	// QueueSize, and that are emitted ahead of other entries. Once it is full, further priority entries use the
+	// capacity of QueueSize, taking the place of the oldest entries queued without priority if necessary. Zero disables
+	// priority queueing
+	PriorityQueueSize int
+	// PrioritySeverities are the severity names of entries that are queued with priority
+	PrioritySeverities []string
+	// SeverityEncoder identifies the severity name used to decide whether entries are queued with priority
+	SeverityEncoder func(level int, err error) string
+	// ErrorHandler is called with any errors the underlying sink reports while emitting in the background
+	ErrorHandler func(err error)
+	// Memory optionally limits the memory used by queued entries, shared with other buffering components
+	Memory MemoryLimit
+	// Expiry determines whether entries that have been queued for too long are dropped or summarized
+	Expiry ExpiryPolicy
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (a *AsyncSinkOptions) AssertDefaults() {
+	if a.QueueSize <= 0 {
+		a.QueueSize = DefaultAsyncQueueSize
+	}
+
+	if a.PrioritySeverities == nil {
+		a.PrioritySeverities = []string{DefaultErrorSeverity}
+	}
+	if a.SeverityEncoder == nil {
+		a.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if a.ErrorHandler == nil {
+		a.ErrorHandler = DefaultErrorHandler
+	}
+
+	a.Memory.assertDefaults("async")
+	a.Expiry.AssertDefaults()
+}
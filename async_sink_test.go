@@ -0,0 +1,67 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestAsyncSinkPriorityOverload(t *testing.T) {
+	recorded := &recordingSink{}
+	opts := AsyncSinkOptions{
+		Sink:              recorded,
+		QueueSize:         5,
+		PriorityQueueSize: 2,
+	}
+	opts.AssertDefaults()
+	sink := NewAsyncSink(opts)
+
+	// the queue is not drained until Flush is called, so that it overflows
+	for i := 0; i < 6; i++ {
+		err := sink.Log(Entry{Message: fmt.Sprintf("info %d", i)})
+		if full := i >= 5; full != (err != nil) {
+			t.Errorf("expected logging info %d to fail only once the queue is full, got %v", i, err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if err := sink.Log(Entry{Message: fmt.Sprintf("error %d", i), Error: errors.New("failure")}); err != nil {
+			t.Errorf("expected error %d to be queued, got %v", i, err)
+		}
+	}
+
+	// the entry that did not fit, and the two oldest entries making room for errors beyond the priority capacity
+	if dropped := sink.Dropped(); dropped != 3 {
+		t.Errorf("expected 3 dropped entries, got %d", dropped)
+	}
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	var messages []string
+	for _, e := range recorded.entries {
+		messages = append(messages, e.Message)
+	}
+	expected := []string{"error 0", "error 1", "error 2", "error 3", "info 2", "info 3", "info 4"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected entries %v, got %v", expected, messages)
+	}
+
+	// once every entry is a priority entry, priority entries beyond the total capacity are dropped
+	recorded.entries = nil
+	for i := 0; i < 8; i++ {
+		err := sink.Log(Entry{Message: fmt.Sprintf("error %d", i), Error: errors.New("failure")})
+		if full := i >= 7; full != (err != nil) {
+			t.Errorf("expected logging error %d to fail only once the queue is full, got %v", i, err)
+		}
+	}
+	if dropped := sink.Dropped(); dropped != 4 {
+		t.Errorf("expected 4 dropped entries, got %d", dropped)
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	if len(recorded.entries) != 7 {
+		t.Errorf("expected 7 entries, got %d", len(recorded.entries))
+	}
+}
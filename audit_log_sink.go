@@ -0,0 +1,215 @@
+package simplelogr
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	// DefaultAuditHashKey is the key the hash of each audit record is stored in
+	DefaultAuditHashKey = "_hash"
+
+	// ErrAuditLogTampered is returned when verifying an audit log whose records have been modified, removed, reordered
+	// or only partially written
+	ErrAuditLogTampered = errors.New("audit log has been tampered with")
+)
+
+// AuditLogSink emits log Entry objects as tamper-evident JSON records for compliance logging, e.g. of administrative
+// actions. Each record ends with a hash of the previous record's hash and the bytes of the record itself, so that the
+// records form a chain in which any modification, removal or reordering can be detected using VerifyAuditLog.
+type AuditLogSink struct {
+	options AuditLogSinkOptions
+	record  *JSONLogSink
+	lock    sync.Mutex
+	// previous is the hash of the last record written
+	previous []byte
+}
+
+// NewAuditLogSink creates a new AuditLogSink with the provided options, starting a new chain unless PreviousHash is
+// specified
+func NewAuditLogSink(opts AuditLogSinkOptions) *AuditLogSink {
+	return &AuditLogSink{
+		options:  opts,
+		record:   NewJSONLogSink(opts.Record),
+		previous: opts.PreviousHash,
+	}
+}
+
+// Log implements LogSink, encoding the given Entry as a JSON record and appending its hash before writing it to the
+// configured io.Writer. The chain only advances when the record is written successfully
+func (a *AuditLogSink) Log(e Entry) error {
+	record, err := a.record.fields(e)
+	if err != nil {
+		return err
+	}
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	buf, err := a.record.encoder.appendObject(*buffer, record)
+	*buffer = buf
+	if e.reportPlaceholders(err) != nil {
+		return err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	sum := auditHash(a.options.Key, a.previous, buf)
+	buf = appendAuditHash(buf, a.options.HashKey, sum)
+	*buffer = buf
+
+	if _, err := a.options.Output.Write(buf); err != nil {
+		return err
+	}
+	a.previous = sum
+	return nil
+}
+
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (a *AuditLogSink) Flush() error {
+	return flushWriter(a.options.Output)
+}
+
+var _ LogSink = (*AuditLogSink)(nil)
+var _ FlushSink = (*AuditLogSink)(nil)
+
+// AuditLogVerification summarises an audit log verified by VerifyAuditLog
+type AuditLogVerification struct {
+	// Records is the number of records verified
+	Records int
+	// LastHash is the hash of the last record, which should be passed as the PreviousHash of an AuditLogSink that
+	// continues the log. Removal of records from the end of the log can only be detected by comparing it against a
+	// hash recorded elsewhere
+	LastHash []byte
+}
+
+// VerifyAuditLog reads the records written by an AuditLogSink with the given options, checking that each record's
+// hash matches its contents and the record before it. It returns ErrAuditLogTampered, identifying the first record
+// found to be invalid, if any have been modified, removed, reordered or only partially written.
+func VerifyAuditLog(r io.Reader, options AuditLogSinkOptions) (AuditLogVerification, error) {
+	result := AuditLogVerification{
+		LastHash: options.PreviousHash,
+	}
+	marker := append(defaultJSONEncoder.appendString(nil, options.HashKey), ':', '"')
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && err == io.EOF {
+			return result, nil
+		} else if err != nil && err != io.EOF {
+			return result, err
+		}
+
+		record := result.Records + 1
+		if err == io.EOF {
+			return result, fmt.Errorf("record %d is incomplete: %w", record, ErrAuditLogTampered)
+		}
+
+		content, sum, ok := splitAuditRecord(bytes.TrimSuffix(line, []byte("\n")), marker)
+		if !ok {
+			return result, fmt.Errorf("record %d has no valid hash: %w", record, ErrAuditLogTampered)
+		}
+		if !hmac.Equal(sum, auditHash(options.Key, result.LastHash, content)) {
+			return result, fmt.Errorf("record %d does not match its hash: %w", record, ErrAuditLogTampered)
+		}
+
+		result.Records = record
+		result.LastHash = sum
+	}
+}
+
+// auditHash calculates the hash of a record following the record with the previous hash, using HMAC-SHA256 if a key
+// is specified, or otherwise SHA-256
+func auditHash(key []byte, previous []byte, record []byte) []byte {
+	var h hash.Hash
+	if key != nil {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(previous)
+	h.Write(record)
+	return h.Sum(nil)
+}
+
+// appendAuditHash appends the hash to the JSON object at the end of the buffer as its last field, followed by a
+// newline
+func appendAuditHash(buf []byte, hashKey string, sum []byte) []byte {
+	buf = buf[:len(buf)-1]
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = defaultJSONEncoder.appendString(buf, hashKey)
+	buf = append(buf, ':', '"')
+	buf = append(buf, hex.EncodeToString(sum)...)
+	return append(buf, '"', '}', '\n')
+}
+
+// splitAuditRecord reverses appendAuditHash, returning the JSON object as it was hashed, and the hash that was
+// appended to it. The marker is the encoded hash key followed by the opening of its value
+func splitAuditRecord(line []byte, marker []byte) ([]byte, []byte, bool) {
+	i := bytes.LastIndex(line, marker)
+	if i < 1 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, nil, false
+	}
+
+	sum, err := hex.DecodeString(string(line[i+len(marker) : len(line)-2]))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, nil, false
+	}
+
+	content := line[:i]
+	switch content[len(content)-1] {
+	case ',':
+		content = content[:len(content)-1]
+	case '{':
+	default:
+		return nil, nil, false
+	}
+	return append(content[:len(content):len(content)], '}'), sum, true
+}
+
+// AuditLogSinkOptions configures the behaviour of an AuditLogSink
+type AuditLogSinkOptions struct {
+	// Output configures where to write audit records to, typically a file opened for appending
+	Output io.Writer
+	// HashKey determines the key to store the hash of each record in, which is always the last field of the record
+	HashKey string
+	// Key, if specified, is a secret used to calculate the hashes as HMAC-SHA256 rather than SHA-256, so that the
+	// chain cannot be recalculated after tampering by anyone who does not know it
+	Key []byte
+	// PreviousHash continues an existing chain, e.g. when appending to an existing log, and should be the LastHash
+	// returned by VerifyAuditLog. If unspecified, a new chain is started
+	PreviousHash []byte
+	// Record configures the keys used for the fields of each record, which are laid out as by JSONLogSink. Its Output
+	// is not used. SortKeys may be useful to make records easier to compare, but is not needed to verify them, as the
+	// hashes cover the records exactly as they were written. Indent is not supported, as each record must be written on
+	// a single line to be verified, and is cleared by AssertDefaults
+	Record JSONLogSinkOptions
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (a *AuditLogSinkOptions) AssertDefaults() {
+	if a.Output == nil {
+		a.Output = os.Stderr
+	}
+
+	if a.HashKey == "" {
+		a.HashKey = DefaultAuditHashKey
+	}
+
+	// records are verified line by line, so they cannot be spread over several lines
+	a.Record.Indent = ""
+	a.Record.AssertDefaults()
+}
@@ -0,0 +1,58 @@
+package simplelogr
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	errBenchmark = errors.New("benchmark error")
+)
+
+func BenchmarkJSONLogSink(b *testing.B) {
+	opts := JSONLogSinkOptions{Output: ioutil.Discard}
+	opts.AssertDefaults()
+	benchmarkSink(b, NewJSONLogSink(opts))
+}
+
+func BenchmarkLogfmtLogSink(b *testing.B) {
+	opts := LogfmtLogSinkOptions{Output: ioutil.Discard}
+	opts.AssertDefaults()
+	benchmarkSink(b, NewLogfmtLogSink(opts))
+}
+
+// benchmarkSink measures the cost of logging typical entries through a Logger writing to the sink
+func benchmarkSink(b *testing.B, sink LogSink) {
+	logger := logr.New(New(Options{Sink: sink})).
+		WithName("benchmark").
+		WithValues("request_id", "8f14e45f", "attempt", 3)
+
+	b.Run("Info", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("handled request", "path", "/api/v1/items", "status", 200, "duration_ms", 12.5)
+		}
+	})
+	b.Run("Error", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Error(errBenchmark, "request failed", "path", "/api/v1/items", "retry", true)
+		}
+	})
+
+	contextual := logger.WithValues(
+		"service", "inventory",
+		"version", "1.4.2",
+		"region", "eu-west-1",
+		"labels", map[string]string{"team": "platform", "tier": "backend"},
+	)
+	b.Run("WithValues", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			contextual.Info("handled request", "path", "/api/v1/items", "status", 200)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package simplelogr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BinaryFormat determines how a BinaryLogSink encodes each entry
+type BinaryFormat int
+
+const (
+	// BinaryFormatMsgpack encodes each entry as a MessagePack map
+	BinaryFormatMsgpack BinaryFormat = iota
+	// BinaryFormatCBOR encodes each entry as a CBOR (RFC 8949) map
+	BinaryFormatCBOR
+)
+
+// String returns the name of the format
+func (f BinaryFormat) String() string {
+	switch f {
+	case BinaryFormatMsgpack:
+		return "msgpack"
+	case BinaryFormatCBOR:
+		return "CBOR"
+	}
+	return fmt.Sprintf("BinaryFormat(%d)", int(f))
+}
+
+// BinaryLogSink emits log Entry objects as MessagePack or CBOR records, for high-throughput pipelines where the cost
+// and size of JSON encoding are the bottleneck. Records have the same fields as the JSON objects written by
+// JSONLogSink, and may optionally be framed with their length so that they can be split apart again when read.
+type BinaryLogSink struct {
+	options BinaryLogSinkOptions
+	record  *JSONLogSink
+}
+
+// NewBinaryLogSink creates a new BinaryLogSink with the provided options
+func NewBinaryLogSink(opts BinaryLogSinkOptions) *BinaryLogSink {
+	return &BinaryLogSink{
+		options: opts,
+		record:  NewJSONLogSink(opts.Record),
+	}
+}
+
+// Log implements LogSink, encoding the given Entry before writing it to the configured io.Writer
+func (b BinaryLogSink) Log(e Entry) error {
+	return b.Encode(b.options.Output, e)
+}
+
+// Encode implements EntryEncoder, writing the binary encoding of the given Entry to the given io.Writer, preceded by
+// its length if LengthPrefix is set
+func (b BinaryLogSink) Encode(w io.Writer, e Entry) error {
+	record, err := b.record.fields(e)
+	if err != nil {
+		return err
+	}
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	buf := *buffer
+	if b.options.LengthPrefix {
+		buf = append(buf, 0, 0, 0, 0)
+	}
+	switch b.options.Format {
+	case BinaryFormatCBOR:
+		buf, err = appendCBOR(buf, record)
+	default:
+		buf, err = appendMsgpack(buf, record)
+	}
+	*buffer = buf
+	if e.reportPlaceholders(err) != nil {
+		return fmt.Errorf("failed to encode log entry as %s: %w", b.options.Format, err)
+	}
+
+	if b.options.LengthPrefix {
+		binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (b BinaryLogSink) Flush() error {
+	return flushWriter(b.options.Output)
+}
+
+var _ LogSink = (*BinaryLogSink)(nil)
+var _ EntryEncoder = (*BinaryLogSink)(nil)
+var _ FlushSink = (*BinaryLogSink)(nil)
+
+// BinaryLogSinkOptions configures the behaviour of a BinaryLogSink
+type BinaryLogSinkOptions struct {
+	// Output configures where to write records to
+	Output io.Writer
+	// Format determines whether records are encoded as MessagePack (the default) or CBOR
+	Format BinaryFormat
+	// LengthPrefix precedes each record with its length in bytes, as a 4 byte big-endian unsigned integer, for
+	// transports and readers that cannot otherwise tell where one record ends and the next begins
+	LengthPrefix bool
+	// Record configures the keys used for the fields of each record, which are laid out as by JSONLogSink. Its Output
+	// is not used
+	Record JSONLogSinkOptions
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (b *BinaryLogSinkOptions) AssertDefaults() {
+	if b.Output == nil {
+		b.Output = os.Stderr
+	}
+
+	b.Record.AssertDefaults()
+}
@@ -0,0 +1,208 @@
+package simplelogr
+
+import (
+	"sync"
+)
+
+// BroadcastSink publishes every Entry to in-process subscribers, so that other components (UIs, anomaly detectors,
+// test harnesses) can observe the live stream of entries without parsing encoded output. Entries are optionally also
+// passed on to another LogSink. The most recent entries can be retained, so that late subscribers are able to see
+// recent history. It is safe for concurrent use.
+type BroadcastSink struct {
+	options BroadcastSinkOptions
+	lock    sync.Mutex
+	nextID  uint64
+	// subscribers is replaced rather than modified, so that Log can use it without holding the lock
+	subscribers []broadcastSubscriber
+	history     []Entry
+	historyNext int
+}
+
+type broadcastSubscriber struct {
+	id         uint64
+	subscriber func(e Entry)
+}
+
+// BroadcastSinkOptions configures the behaviour of a BroadcastSink
+type BroadcastSinkOptions struct {
+	// Sink, if specified, is passed every Entry before it is published to subscribers
+	Sink LogSink
+	// HistorySize is the number of recent entries retained for replaying to late subscribers, see SubscribeWithReplay
+	HistorySize int
+	// ErrorHandler is called with any errors returned by sinks attached using Attach, defaults to DefaultErrorHandler
+	ErrorHandler func(err error)
+}
+
+// NewBroadcastSink creates a new BroadcastSink with the provided options
+func NewBroadcastSink(opts BroadcastSinkOptions) *BroadcastSink {
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	return &BroadcastSink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, passing the Entry on to the wrapped sink (if any) and then publishing it to all subscribers
+func (b *BroadcastSink) Log(e Entry) error {
+	var err error
+	if b.options.Sink != nil {
+		err = b.options.Sink.Log(e)
+	}
+
+	b.lock.Lock()
+	if b.options.HistorySize > 0 {
+		if len(b.history) < b.options.HistorySize {
+			b.history = append(b.history, e)
+		} else {
+			b.history[b.historyNext] = e
+		}
+		b.historyNext = (b.historyNext + 1) % b.options.HistorySize
+	}
+	subscribers := b.subscribers
+	b.lock.Unlock()
+
+	for _, s := range subscribers {
+		s.subscriber(e)
+	}
+
+	return err
+}
+
+// Subscribe registers a function to be called synchronously with every Entry logged from now on. Subscribers should
+// return quickly, as they delay the code doing the logging. The returned function cancels the subscription.
+func (b *BroadcastSink) Subscribe(subscriber func(e Entry)) (unsubscribe func()) {
+	return b.SubscribeWithReplay(subscriber, 0)
+}
+
+// SubscribeWithReplay is like Subscribe, but first calls the subscriber with up to the given number of the most
+// recent entries retained (see BroadcastSinkOptions.HistorySize), oldest first. The history is replayed without
+// blocking logging, and entries logged in the meantime are delivered once it has been replayed, so that no entries are
+// missed or repeated between the replayed history and the live stream.
+func (b *BroadcastSink) SubscribeWithReplay(subscriber func(e Entry), replay int) (unsubscribe func()) {
+	b.lock.Lock()
+	history := b.recentLocked(replay)
+
+	var replaying *replayingSubscriber
+	if len(history) > 0 {
+		replaying = &replayingSubscriber{subscriber: subscriber, replaying: true}
+		subscriber = replaying.deliver
+	}
+
+	id := b.nextID
+	b.nextID++
+
+	subscribers := make([]broadcastSubscriber, len(b.subscribers), len(b.subscribers)+1)
+	copy(subscribers, b.subscribers)
+	b.subscribers = append(subscribers, broadcastSubscriber{
+		id:         id,
+		subscriber: subscriber,
+	})
+	b.lock.Unlock()
+
+	if replaying != nil {
+		replaying.replay(history)
+	}
+
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		subscribers := make([]broadcastSubscriber, 0, len(b.subscribers))
+		for _, s := range b.subscribers {
+			if s.id != id {
+				subscribers = append(subscribers, s)
+			}
+		}
+		b.subscribers = subscribers
+	}
+}
+
+// Attach subscribes a LogSink that is created after startup (e.g. a network sink that connects lazily), replaying up
+// to the given number of recent entries to it first. Errors returned by the sink are passed to the ErrorHandler. The
+// returned function detaches the sink.
+func (b *BroadcastSink) Attach(sink LogSink, replay int) (detach func()) {
+	return b.SubscribeWithReplay(func(e Entry) {
+		if err := sink.Log(e); err != nil {
+			b.options.ErrorHandler(err)
+		}
+	}, replay)
+}
+
+// replayingSubscriber holds back the live entries published to a subscriber while its history is replayed, so that
+// they are delivered after the history
+type replayingSubscriber struct {
+	subscriber func(e Entry)
+
+	lock      sync.Mutex
+	replaying bool
+	pending   []Entry
+}
+
+// deliver publishes a live Entry to the subscriber, or holds it back if the history is still being replayed
+func (r *replayingSubscriber) deliver(e Entry) {
+	r.lock.Lock()
+	if r.replaying {
+		r.pending = append(r.pending, e)
+		r.lock.Unlock()
+		return
+	}
+	r.lock.Unlock()
+
+	r.subscriber(e)
+}
+
+// replay publishes the history to the subscriber, followed by any live entries held back in the meantime
+func (r *replayingSubscriber) replay(history []Entry) {
+	for _, e := range history {
+		r.subscriber(e)
+	}
+
+	for {
+		r.lock.Lock()
+		pending := r.pending
+		r.pending = nil
+		if len(pending) == 0 {
+			r.replaying = false
+			r.lock.Unlock()
+			return
+		}
+		r.lock.Unlock()
+
+		for _, e := range pending {
+			r.subscriber(e)
+		}
+	}
+}
+
+// recentLocked returns up to n of the most recently retained entries, oldest first
+func (b *BroadcastSink) recentLocked(n int) []Entry {
+	if n > len(b.history) {
+		n = len(b.history)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	ordered := make([]Entry, 0, len(b.history))
+	if len(b.history) == b.options.HistorySize {
+		ordered = append(ordered, b.history[b.historyNext:]...)
+		ordered = append(ordered, b.history[:b.historyNext]...)
+	} else {
+		ordered = append(ordered, b.history...)
+	}
+
+	return ordered[len(ordered)-n:]
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink (if any)
+func (b *BroadcastSink) Unwrap() []LogSink {
+	if b.options.Sink == nil {
+		return nil
+	}
+	return []LogSink{b.options.Sink}
+}
+
+var _ LogSink = (*BroadcastSink)(nil)
+var _ WrapperSink = (*BroadcastSink)(nil)
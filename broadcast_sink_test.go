@@ -0,0 +1,79 @@
+package simplelogr
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcastSinkReplayDoesNotBlockLogging(t *testing.T) {
+	sink := NewBroadcastSink(BroadcastSinkOptions{HistorySize: 3})
+	for _, msg := range []string{"a", "b", "c"} {
+		_ = sink.Log(Entry{Message: msg})
+	}
+
+	var received []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sink.SubscribeWithReplay(func(e Entry) {
+			received = append(received, e.Message)
+			// subscribers that log, e.g. sinks attached using Attach, must not deadlock while the history is replayed
+			if e.Message == "a" {
+				_ = sink.Log(Entry{Message: "d"})
+			}
+		}, 3)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging while replaying history deadlocked")
+	}
+
+	if strings.Join(received, "") != "abcd" {
+		t.Errorf("expected the history followed by the entry logged during the replay, got %v", received)
+	}
+}
+
+func TestBroadcastSinkReplayIsContiguousWithLiveEntries(t *testing.T) {
+	sink := NewBroadcastSink(BroadcastSinkOptions{HistorySize: 10})
+
+	const total = 2000
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < total; i++ {
+			if i == total/4 {
+				close(started)
+			}
+			_ = sink.Log(Entry{KVs: []interface{}{"n", i}})
+		}
+	}()
+
+	<-started
+	var lock sync.Mutex
+	var received []int
+	unsubscribe := sink.SubscribeWithReplay(func(e Entry) {
+		n, _ := e.Value("n")
+		lock.Lock()
+		received = append(received, n.(int))
+		lock.Unlock()
+	}, 10)
+	defer unsubscribe()
+	wg.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) == 0 || received[len(received)-1] != total-1 {
+		t.Fatalf("expected entries up to the last one logged, got %v", received)
+	}
+	for i := 1; i < len(received); i++ {
+		if received[i] != received[i-1]+1 {
+			t.Fatalf("expected contiguous entries, got %d after %d", received[i], received[i-1])
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package simplelogr
+
+import (
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not returned to the pool, so that one unusually large
+// entry does not keep a large buffer alive indefinitely
+const maxPooledBufferSize = 64 * 1024
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// getBuffer retrieves an empty buffer from the pool, which should be returned using putBuffer once finished with
+func getBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putBuffer returns a buffer to the pool, the buffer must not be used afterwards
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
@@ -0,0 +1,187 @@
+package simplelogr
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultBufferedWriterSize is the Size used by BufferedWriter when none is specified
+	DefaultBufferedWriterSize = 64 * 1024
+	// DefaultBufferedFlushInterval is the FlushInterval used by BufferedWriter when none is specified
+	DefaultBufferedFlushInterval = time.Second
+)
+
+// BufferedWriter is a thread-safe io.Writer batching the data written to it in memory before writing it to another
+// io.Writer, e.g. a file or pipe, greatly reducing the number of system calls made when logging heavily. Unlike a
+// bufio.Writer, each write is kept whole, so entries are never split between writes to the underlying io.Writer.
+//
+// Buffered data is written once Size bytes are buffered, when it is flushed (e.g. by flushing the sinks writing to
+// it, see FlushSinks), every FlushInterval once Start is called, and when it is closed, which should be done once the
+// sinks writing to it have been closed.
+type BufferedWriter struct {
+	options    BufferedWriterOptions
+	lock       sync.Mutex
+	underlying io.Writer
+	buffer     []byte
+	closed     bool
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewBufferedWriter creates a new BufferedWriter buffering writes to the given io.Writer
+func NewBufferedWriter(w io.Writer, opts BufferedWriterOptions) *BufferedWriter {
+	return &BufferedWriter{
+		options:    opts,
+		underlying: w,
+		buffer:     make([]byte, 0, opts.Size),
+	}
+}
+
+// Write implements io.Writer, buffering the data, first writing any buffered data if it would not fit. Data at least
+// Size bytes long is written immediately.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+
+	if len(b.buffer) > 0 && len(b.buffer)+len(p) > b.options.Size {
+		if err := b.writeBufferLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(p) >= b.options.Size {
+		return b.underlying.Write(p)
+	}
+
+	b.buffer = append(b.buffer, p...)
+	return len(p), nil
+}
+
+// writeBufferLocked writes the buffered data to the underlying io.Writer, keeping any data that was not written
+func (b *BufferedWriter) writeBufferLocked() error {
+	if len(b.buffer) == 0 {
+		return nil
+	}
+
+	n, err := b.underlying.Write(b.buffer)
+	if n > 0 && n < len(b.buffer) {
+		copy(b.buffer, b.buffer[n:])
+	}
+	b.buffer = b.buffer[:len(b.buffer)-n]
+	if err == nil && len(b.buffer) > 0 {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// Buffered returns the number of bytes buffered, waiting to be written
+func (b *BufferedWriter) Buffered() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.buffer)
+}
+
+// Flush writes any buffered data to the underlying io.Writer, and then flushes it if it buffers writes
+func (b *BufferedWriter) Flush() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.writeBufferLocked(); err != nil {
+		return err
+	}
+	return flushWriter(b.underlying)
+}
+
+// Start begins flushing every FlushInterval in the background, until the context is cancelled or Close is called
+func (b *BufferedWriter) Start(ctx context.Context) {
+	b.lifecycleLock.Lock()
+	defer b.lifecycleLock.Unlock()
+
+	if b.done != nil {
+		return
+	}
+
+	ctx, b.cancel = context.WithCancel(ctx)
+	b.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(b.options.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.Flush(); err != nil {
+					b.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(b.done)
+}
+
+// Close stops any background flushing started by Start, and writes any buffered data. It does not close the underlying
+// io.Writer, which is flushed if it buffers writes. Writing to the BufferedWriter afterwards fails with os.ErrClosed
+func (b *BufferedWriter) Close() error {
+	b.lifecycleLock.Lock()
+	if b.done != nil {
+		b.cancel()
+		<-b.done
+		b.done = nil
+	}
+	b.lifecycleLock.Unlock()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	if err := b.writeBufferLocked(); err != nil {
+		return err
+	}
+	return flushWriter(b.underlying)
+}
+
+var _ io.WriteCloser = (*BufferedWriter)(nil)
+
+// BufferedWriterOptions configures the behaviour of a BufferedWriter
+type BufferedWriterOptions struct {
+	// Size is the number of bytes buffered before they are written to the underlying io.Writer
+	Size int
+	// FlushInterval is how often buffered data is written in the background once Start is called, bounding how long
+	// entries can be delayed, and how many would be lost if the process were to crash
+	FlushInterval time.Duration
+	// ErrorHandler is called with any errors encountered while flushing in the background
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (b *BufferedWriterOptions) AssertDefaults() {
+	if b.Size <= 0 {
+		b.Size = DefaultBufferedWriterSize
+	}
+
+	if b.FlushInterval <= 0 {
+		b.FlushInterval = DefaultBufferedFlushInterval
+	}
+
+	if b.ErrorHandler == nil {
+		b.ErrorHandler = DefaultErrorHandler
+	}
+}
@@ -0,0 +1,48 @@
+package simplelogr
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Base64BytesEncoder encodes []byte values using standard base64, as encoding/json does
+func Base64BytesEncoder(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// HexBytesEncoder encodes []byte values as lower case hexadecimal, which is easier to compare against packet captures
+// and protocol specifications
+func HexBytesEncoder(b []byte) string {
+	return hex.EncodeToString(b)
+}
+
+// PrintableBytesEncoder encodes []byte values as text when they are valid UTF-8 consisting only of printable characters
+// and whitespace, e.g. for payloads that are usually text, and otherwise falls back to standard base64
+func PrintableBytesEncoder(b []byte) string {
+	if !utf8.Valid(b) {
+		return Base64BytesEncoder(b)
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return Base64BytesEncoder(b)
+		}
+	}
+	return string(b)
+}
+
+// LengthBytesEncoder replaces []byte values with a placeholder giving only their length, e.g. "[1024 bytes]", for
+// values too large or too sensitive to be logged
+func LengthBytesEncoder(b []byte) string {
+	return "[" + strconv.Itoa(len(b)) + " bytes]"
+}
+
+// encodeBytes encodes the value using the BytesEncoder if it is a non-nil []byte, returning other values unchanged
+func encodeBytes(v interface{}, encoder func(b []byte) string) interface{} {
+	if b, ok := v.([]byte); ok && b != nil && encoder != nil {
+		return encoder(b)
+	}
+	return v
+}
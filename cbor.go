@@ -0,0 +1,145 @@
+package simplelogr
+
+import (
+	"math"
+	"time"
+)
+
+// CBOR (RFC 8949) major types
+const (
+	cborUnsigned byte = 0 << 5
+	cborNegative byte = 1 << 5
+	cborBytes    byte = 2 << 5
+	cborText     byte = 3 << 5
+	cborArray    byte = 4 << 5
+	cborMap      byte = 5 << 5
+	cborTag      byte = 6 << 5
+)
+
+// cborTagDateTime is the tag of standard date/time strings (RFC 3339)
+const cborTagDateTime = 0
+
+// appendCBOR appends the CBOR encoding of the given value to the buffer. Common types are encoded directly, while
+// anything else (e.g. structs) is encoded via its JSON representation so that it honours json.Marshaler
+// implementations and struct tags. Values that cannot be encoded are replaced by placeholders, which are returned as
+// placeholderErrors once the value is complete.
+func appendCBOR(buf []byte, v interface{}) ([]byte, error) {
+	var placeholders placeholderErrors
+	switch value := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+	case bool:
+		if value {
+			return append(buf, 0xf5), nil
+		}
+		return append(buf, 0xf4), nil
+	case int:
+		return appendCBORInt(buf, int64(value)), nil
+	case int8:
+		return appendCBORInt(buf, int64(value)), nil
+	case int16:
+		return appendCBORInt(buf, int64(value)), nil
+	case int32:
+		return appendCBORInt(buf, int64(value)), nil
+	case int64:
+		return appendCBORInt(buf, value), nil
+	case uint:
+		return appendCBORHead(buf, cborUnsigned, uint64(value)), nil
+	case uint8:
+		return appendCBORHead(buf, cborUnsigned, uint64(value)), nil
+	case uint16:
+		return appendCBORHead(buf, cborUnsigned, uint64(value)), nil
+	case uint32:
+		return appendCBORHead(buf, cborUnsigned, uint64(value)), nil
+	case uint64:
+		return appendCBORHead(buf, cborUnsigned, value), nil
+	case float32:
+		buf = append(buf, 0xfa)
+		return appendUint32(buf, math.Float32bits(value)), nil
+	case float64:
+		buf = append(buf, 0xfb)
+		return appendUint64(buf, math.Float64bits(value)), nil
+	case string:
+		return appendCBORString(buf, value), nil
+	case []byte:
+		buf = appendCBORHead(buf, cborBytes, uint64(len(value)))
+		return append(buf, value...), nil
+	case time.Time:
+		buf = appendCBORHead(buf, cborTag, cborTagDateTime)
+		return appendCBORString(buf, value.Format(time.RFC3339Nano)), nil
+	case *placeholderValue:
+		return appendCBORString(buf, value.placeholder), value
+	case error:
+		return appendCBORString(buf, value.Error()), nil
+	case []interface{}:
+		buf = appendCBORHead(buf, cborArray, uint64(len(value)))
+		for _, item := range value {
+			var err error
+			if buf, err = appendCBOR(buf, item); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	case *jsonObject:
+		buf = appendCBORHead(buf, cborMap, uint64(len(value.keys)))
+		for _, k := range value.keys {
+			buf = appendCBORString(buf, k)
+			var err error
+			if buf, err = appendCBOR(buf, value.values[k]); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	case map[string]interface{}:
+		buf = appendCBORHead(buf, cborMap, uint64(len(value)))
+		for k, item := range value {
+			buf = appendCBORString(buf, k)
+			var err error
+			if buf, err = appendCBOR(buf, item); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	}
+
+	// round trip anything else through JSON to reduce it to the basic types handled above
+	generic, err := jsonGeneric(v)
+	if err != nil {
+		placeholder, ok := err.(*placeholderValue)
+		if !ok {
+			placeholder = errorPlaceholder(v, err)
+		}
+		return appendCBORString(buf, placeholder.placeholder), placeholder
+	}
+	return appendCBOR(buf, generic)
+}
+
+func appendCBORInt(buf []byte, v int64) []byte {
+	if v >= 0 {
+		return appendCBORHead(buf, cborUnsigned, uint64(v))
+	}
+	// negative integers are encoded as -1 - n
+	return appendCBORHead(buf, cborNegative, uint64(-1-v))
+}
+
+func appendCBORString(buf []byte, s string) []byte {
+	buf = appendCBORHead(buf, cborText, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// appendCBORHead appends the initial byte of a data item of the given major type, followed by its argument (a value,
+// length or tag number) in the fewest bytes possible
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return appendUint32(append(buf, major|26), uint32(n))
+	default:
+		return appendUint64(append(buf, major|27), n)
+	}
+}
@@ -0,0 +1,283 @@
+package simplelogr
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultCEFDeviceVendor is the device vendor given in CEF headers when none is specified
+	DefaultCEFDeviceVendor = "Unknown"
+	// DefaultCEFSignatureID is the device event class ID given in CEF headers of entries from loggers without names
+	DefaultCEFSignatureID = "log"
+	// DefaultCEFTimestampKey is the CEF extension key receipt times are stored in, as milliseconds since the Unix epoch
+	DefaultCEFTimestampKey = "rt"
+	// DefaultCEFErrorKey is the CEF extension key error messages are stored in
+	DefaultCEFErrorKey = "msg"
+)
+
+// CEFLogSink emits log Entry objects as ArcSight Common Event Format (CEF) lines, so that security-relevant services
+// can log directly into a SIEM. The header identifies the device from the options, with the logger name as the device
+// event class ID, the message as the name, and a severity from 0 to 10, while the key-value pairs become extensions.
+type CEFLogSink struct {
+	options CEFLogSinkOptions
+}
+
+// NewCEFLogSink creates a new CEFLogSink with the provided options
+func NewCEFLogSink(options CEFLogSinkOptions) *CEFLogSink {
+	return &CEFLogSink{
+		options: options,
+	}
+}
+
+// Log implements LogSink, encoding the given Entry as CEF before writing it to the configured io.Writer
+func (c CEFLogSink) Log(e Entry) error {
+	return c.Encode(c.options.Output, e)
+}
+
+// Encode implements EntryEncoder, writing the CEF encoding of the given Entry to the given io.Writer
+func (c CEFLogSink) Encode(w io.Writer, e Entry) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	b, err := c.appendEntry(*buffer, e)
+	if e.reportPlaceholders(err) != nil {
+		return err
+	}
+	b = append(b, '\n')
+	*buffer = b
+
+	_, err = w.Write(b)
+	return err
+}
+
+// EntryDelimiter implements DelimitedEncoder, returning the newline ending each encoded Entry
+func (c CEFLogSink) EntryDelimiter() string {
+	return "\n"
+}
+
+// appendEntry appends the CEF encoding of the given Entry to buf, without a trailing newline. Values replaced by
+// placeholders are returned as placeholderErrors once the entry is complete
+func (c CEFLogSink) appendEntry(buf []byte, e Entry) ([]byte, error) {
+	signatureID := DefaultCEFSignatureID
+	if len(e.Names) > 0 {
+		signatureID = c.options.NameEncoder(e.Names)
+	}
+
+	buf = append(buf, "CEF:0|"...)
+	for _, field := range []string{c.options.DeviceVendor, c.options.DeviceProduct, c.options.DeviceVersion, signatureID, e.Message} {
+		buf = appendCEFHeaderField(buf, field)
+		buf = append(buf, '|')
+	}
+	buf = strconv.AppendInt(buf, int64(c.options.SeverityEncoder(e.Level, e.Error)), 10)
+	buf = append(buf, '|')
+
+	var err error
+	var placeholders placeholderErrors
+	separator := ""
+	appendExtension := func(k string, v interface{}) {
+		if err != nil {
+			return
+		}
+		buf = append(buf, separator...)
+		separator = " "
+		buf = append(buf, cefKey(k)...)
+		buf = append(buf, '=')
+		if buf, err = appendCEFValue(buf, v); err != nil && placeholders.collect(err) {
+			err = nil
+		}
+	}
+
+	if c.options.TimestampKey != "" {
+		appendExtension(c.options.TimestampKey, e.Timestamp.UnixNano()/int64(time.Millisecond))
+	}
+
+	if e.Error != nil && c.options.ErrorKey != "" {
+		appendExtension(c.options.ErrorKey, c.options.ErrorEncoder(e.Error).Message)
+	}
+
+	kvs := flattenGroups(e.KVs)
+	for i := 0; i < len(kvs); i += 2 {
+		k := kvs[i]
+		v := kvs[i+1]
+
+		kStr, ok := k.(string)
+		if !ok {
+			return buf, fmt.Errorf("logging keys must be strings, got %T: %v", k, k)
+		}
+
+		appendExtension(kStr, resolveValue(v))
+	}
+
+	if err != nil {
+		return buf, err
+	}
+	return buf, placeholders.err()
+}
+
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (c CEFLogSink) Flush() error {
+	return flushWriter(c.options.Output)
+}
+
+// DefaultCEFSeverityEncoder maps verbosity levels and errors onto CEF severities: errors are High (7), warnings are
+// Medium (5), non-verbose messages are Low (3), and all verbose messages are 1
+func DefaultCEFSeverityEncoder(level int, err error) int {
+	if err != nil {
+		return 7
+	}
+
+	if level < 0 {
+		return 5
+	}
+
+	if level > 0 {
+		return 1
+	}
+
+	return 3
+}
+
+// cefKey replaces characters that are not permitted in CEF extension keys, which may contain only letters, digits,
+// underscores and dots
+func cefKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, k)
+}
+
+// appendCEFHeaderField appends a header field, escaping backslashes and pipes, and replacing line breaks (which are
+// not permitted in headers) with spaces
+func appendCEFHeaderField(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; ch {
+		case '\\', '|':
+			buf = append(buf, '\\', ch)
+		case '\r', '\n':
+			buf = append(buf, ' ')
+		default:
+			buf = append(buf, ch)
+		}
+	}
+	return buf
+}
+
+// appendCEFExtensionValue appends an extension value, escaping backslashes, equals signs and line breaks
+func appendCEFExtensionValue(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; ch {
+		case '\\', '=':
+			buf = append(buf, '\\', ch)
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		default:
+			buf = append(buf, ch)
+		}
+	}
+	return buf
+}
+
+// appendCEFValue appends strings directly, and anything else as JSON, escaping the result as an extension value.
+// Values that panic or fail to be encoded are replaced by a placeholder, which is returned as the error
+func appendCEFValue(buf []byte, v interface{}) (result []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			placeholder := panicPlaceholder(v, recovered)
+			result, err = appendCEFExtensionValue(buf, placeholder.placeholder), placeholder
+		}
+	}()
+
+	switch value := v.(type) {
+	case *placeholderValue:
+		return appendCEFExtensionValue(buf, value.placeholder), value
+	case string:
+		return appendCEFExtensionValue(buf, value), nil
+	case time.Time:
+		return appendCEFExtensionValue(buf, value.Format(time.RFC3339Nano)), nil
+	case error:
+		return appendCEFExtensionValue(buf, value.Error()), nil
+	case fmt.Stringer:
+		return appendCEFExtensionValue(buf, value.String()), nil
+	}
+
+	encoded := getBuffer()
+	defer putBuffer(encoded)
+	b, err := defaultJSONEncoder.appendValue(*encoded, v)
+	*encoded = b
+	if placeholder, ok := err.(*placeholderValue); ok {
+		return appendCEFExtensionValue(buf, placeholder.placeholder), placeholder
+	} else if err != nil {
+		return buf, err
+	}
+	return appendCEFExtensionValue(buf, string(b)), nil
+}
+
+var _ LogSink = (*CEFLogSink)(nil)
+var _ EntryEncoder = (*CEFLogSink)(nil)
+var _ DelimitedEncoder = (*CEFLogSink)(nil)
+var _ FlushSink = (*CEFLogSink)(nil)
+
+// CEFLogSinkOptions configures the behaviour of a CEFLogSink
+type CEFLogSinkOptions struct {
+	// Output configures where to write CEF lines to
+	Output io.Writer
+	// DeviceVendor identifies the vendor of the service in the header of each line
+	DeviceVendor string
+	// DeviceProduct identifies the service in the header of each line, by default the name of the executable
+	DeviceProduct string
+	// DeviceVersion identifies the version of the service in the header of each line
+	DeviceVersion string
+	// NameEncoder collapses the series of Logger names down into the device event class ID, which identifies the type
+	// of event. Entries from loggers without names use DefaultCEFSignatureID
+	NameEncoder func(names []string) string
+	// SeverityEncoder maps the verbosity level and the presence of any errors to a CEF severity, from 0 to 10
+	SeverityEncoder func(level int, err error) int
+	// TimestampKey determines the extension key to store the timestamp in, as milliseconds since the Unix epoch
+	TimestampKey string
+	// ErrorKey determines the extension key to store any error messages in
+	ErrorKey string
+	// ErrorEncoder extracts loggable EncodedError information from an error
+	ErrorEncoder func(err error) EncodedError
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (c *CEFLogSinkOptions) AssertDefaults() {
+	if c.Output == nil {
+		c.Output = os.Stderr
+	}
+
+	if c.DeviceVendor == "" {
+		c.DeviceVendor = DefaultCEFDeviceVendor
+	}
+	if c.DeviceProduct == "" {
+		c.DeviceProduct = filepath.Base(os.Args[0])
+	}
+
+	if c.NameEncoder == nil {
+		c.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+	if c.SeverityEncoder == nil {
+		c.SeverityEncoder = DefaultCEFSeverityEncoder
+	}
+
+	if c.TimestampKey == "" {
+		c.TimestampKey = DefaultCEFTimestampKey
+	}
+
+	if c.ErrorKey == "" {
+		c.ErrorKey = DefaultCEFErrorKey
+	}
+	if c.ErrorEncoder == nil {
+		c.ErrorEncoder = DefaultErrorEncoder
+	}
+}
@@ -0,0 +1,95 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	// DefaultProgressPrefix is placed before progress messages written by CLIOutput
+	DefaultProgressPrefix = "... "
+)
+
+// CLIOutput writes the user-facing output of command line tools, such as progress updates and results, as plain text.
+// It is intended to be kept separate from diagnostic logs (see NewCLI), so that CLI authors have no need to mix
+// fmt.Println with logging. It is safe for concurrent use.
+type CLIOutput struct {
+	options CLIOutputOptions
+	lock    sync.Mutex
+}
+
+// CLIOutputOptions configures the behaviour of a CLIOutput
+type CLIOutputOptions struct {
+	// Output configures where to write user-facing output to
+	Output io.Writer
+	// ProgressPrefix is placed before progress messages
+	ProgressPrefix string
+	// ResultPrefix is placed before result messages
+	ResultPrefix string
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (c *CLIOutputOptions) AssertDefaults() {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+
+	if c.ProgressPrefix == "" {
+		c.ProgressPrefix = DefaultProgressPrefix
+	}
+}
+
+// NewCLIOutput creates a new CLIOutput with the provided options
+func NewCLIOutput(opts CLIOutputOptions) *CLIOutput {
+	return &CLIOutput{
+		options: opts,
+	}
+}
+
+// Progress reports the progress of an ongoing operation to the user, along with optional key-value pairs
+func (c *CLIOutput) Progress(msg string, keysAndValues ...interface{}) error {
+	return c.write(c.options.ProgressPrefix, msg, keysAndValues)
+}
+
+// Result reports the outcome of an operation to the user, along with optional key-value pairs
+func (c *CLIOutput) Result(msg string, keysAndValues ...interface{}) error {
+	return c.write(c.options.ResultPrefix, msg, keysAndValues)
+}
+
+// Printf writes arbitrary formatted output to the user
+func (c *CLIOutput) Printf(format string, args ...interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err := fmt.Fprintf(c.options.Output, format, args...)
+	return err
+}
+
+func (c *CLIOutput) write(prefix string, msg string, keysAndValues []interface{}) error {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(prefix)
+	buffer.WriteString(msg)
+
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		v := resolveValue(keysAndValues[i+1])
+		if s, ok := v.(string); ok {
+			_, _ = fmt.Fprintf(&buffer, " %v=%s", keysAndValues[i], s)
+			continue
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(&buffer, " %v=%s", keysAndValues[i], b)
+	}
+	buffer.WriteString("\n")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err := c.options.Output.Write(buffer.Bytes())
+	return err
+}
@@ -0,0 +1,129 @@
+package simplelogr
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultClockSkewThreshold is the clock skew above which a ClockSkewMonitor reports, HTTP Date headers only
+	// have a resolution of one second so smaller thresholds are not meaningful for HTTP sinks
+	DefaultClockSkewThreshold = 5 * time.Second
+	// DefaultClockSkewReportInterval is the minimum time between reports by a ClockSkewMonitor
+	DefaultClockSkewReportInterval = time.Hour
+	// DefaultClockSkewMessage is the message of the diagnostic entries emitted by a ClockSkewMonitor
+	DefaultClockSkewMessage = "clock skew detected"
+)
+
+// ClockSkewMonitor compares the local clock against the timestamps in responses from remote collectors (e.g. the Date
+// header of HTTP responses to SplunkHECLogSink and SentrySink), emitting a diagnostic entry when they disagree by more
+// than a threshold, since skewed timestamps silently wreck log correlation. Reports are rate limited, and it is safe
+// for concurrent use.
+type ClockSkewMonitor struct {
+	options ClockSkewMonitorOptions
+
+	lock       sync.Mutex
+	skew       time.Duration
+	observed   bool
+	lastReport time.Time
+}
+
+// NewClockSkewMonitor creates a new ClockSkewMonitor with the provided options
+func NewClockSkewMonitor(opts ClockSkewMonitorOptions) *ClockSkewMonitor {
+	return &ClockSkewMonitor{
+		options: opts,
+	}
+}
+
+// Observe records a remote timestamp received in response to a request sent and answered at the given local times,
+// estimating the skew as the difference between the remote time and the midpoint of the request
+func (c *ClockSkewMonitor) Observe(remote, sent, received time.Time) {
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := remote.Sub(local)
+
+	c.lock.Lock()
+	c.skew = skew
+	c.observed = true
+	report := (skew > c.options.Threshold || -skew > c.options.Threshold) &&
+		(c.lastReport.IsZero() || received.Sub(c.lastReport) >= c.options.ReportInterval)
+	if report {
+		c.lastReport = received
+	}
+	c.lock.Unlock()
+
+	if !report {
+		return
+	}
+
+	if err := c.options.Sink.Log(Entry{
+		Timestamp: received.UTC(),
+		Message:   c.options.Message,
+		KVs: []interface{}{
+			"skew", skew.String(),
+			"remote_time", remote.UTC().Format(time.RFC3339),
+			"threshold", c.options.Threshold.String(),
+		},
+	}); err != nil {
+		c.options.ErrorHandler(err)
+	}
+}
+
+// ObserveHTTP records the Date header of an HTTP response, if it has one
+func (c *ClockSkewMonitor) ObserveHTTP(resp *http.Response, sent, received time.Time) {
+	remote, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	// the Date header is truncated to the second, so compare against the middle of that second
+	c.Observe(remote.Add(500*time.Millisecond), sent, received)
+}
+
+// Skew returns the most recently estimated skew (positive if the remote clock is ahead), and whether any observations
+// have been made
+func (c *ClockSkewMonitor) Skew() (time.Duration, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.skew, c.observed
+}
+
+// ClockSkewMonitorOptions configures the behaviour of a ClockSkewMonitor
+type ClockSkewMonitorOptions struct {
+	// Sink is where diagnostic entries are emitted to, which should not itself be monitored, e.g. a sink writing to
+	// stderr
+	Sink LogSink
+	// Threshold is the skew above which a diagnostic entry is emitted
+	Threshold time.Duration
+	// ReportInterval is the minimum time between diagnostic entries
+	ReportInterval time.Duration
+	// Message is the message of diagnostic entries
+	Message string
+	// ErrorHandler is called with any errors emitting diagnostic entries
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (c *ClockSkewMonitorOptions) AssertDefaults() {
+	if c.Sink == nil {
+		sinkOpts := JSONLogSinkOptions{}
+		sinkOpts.AssertDefaults()
+		c.Sink = NewJSONLogSink(sinkOpts)
+	}
+
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultClockSkewThreshold
+	}
+
+	if c.ReportInterval <= 0 {
+		c.ReportInterval = DefaultClockSkewReportInterval
+	}
+
+	if c.Message == "" {
+		c.Message = DefaultClockSkewMessage
+	}
+
+	if c.ErrorHandler == nil {
+		c.ErrorHandler = DefaultErrorHandler
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// ColourTheme is a set of colours for a DevelopmentLogSink, see DevelopmentLogSinkOptions.Theme
+type ColourTheme struct {
+	// PrimaryColour is the colour of log messages, logger names, and the values of key-value pairs
+	PrimaryColour *color.Color
+	// SecondaryColour is the colour of timestamps, and the keys of key-value pairs
+	SecondaryColour *color.Color
+	// SeverityColours maps severity names to colours
+	SeverityColours map[string]*color.Color
+}
+
+// ColourThemes are the themes that can be selected by name using DevelopmentLogSinkOptions.Theme, and may be added to
+var ColourThemes = map[string]ColourTheme{
+	// default matches the colours used when no theme is selected, suited to terminals with dark backgrounds
+	"default": {
+		PrimaryColour:   DefaultPrimaryColour,
+		SecondaryColour: DefaultSecondaryColour,
+		SeverityColours: DefaultSeverityColours,
+	},
+	// dark dims timestamps and keys so that messages and values stand out on dark backgrounds
+	"dark": {
+		PrimaryColour:   color.New(color.FgHiWhite),
+		SecondaryColour: color.New(color.FgHiBlack),
+		SeverityColours: map[string]*color.Color{
+			"ERROR": color.New(color.FgHiRed, color.Bold),
+			"WARN":  color.New(color.FgHiYellow, color.Bold),
+			"INFO":  color.New(color.FgHiGreen),
+			"DEBUG": color.New(color.FgHiCyan),
+			"TRACE": color.New(color.FgHiMagenta),
+		},
+	},
+	// light uses dark colours, legible on terminals with light backgrounds
+	"light": {
+		PrimaryColour:   color.New(color.FgBlack),
+		SecondaryColour: color.New(color.FgHiBlack),
+		SeverityColours: map[string]*color.Color{
+			"ERROR": color.New(color.FgRed, color.Bold),
+			"WARN":  color.New(color.FgYellow, color.Bold),
+			"INFO":  color.New(color.FgBlue),
+			"DEBUG": color.New(color.FgCyan),
+			"TRACE": color.New(color.FgMagenta),
+		},
+	},
+	// solarized uses the 256-colour approximations of the Solarized palette
+	"solarized": {
+		PrimaryColour:   Colour256(244),
+		SecondaryColour: Colour256(240),
+		SeverityColours: map[string]*color.Color{
+			"ERROR": Colour256(160),
+			"WARN":  Colour256(136),
+			"INFO":  Colour256(64),
+			"DEBUG": Colour256(33),
+			"TRACE": Colour256(61),
+		},
+	},
+	// monochrome uses no colours, only emphasising errors and dimming timestamps and keys
+	"monochrome": {
+		PrimaryColour:   color.New(color.Reset),
+		SecondaryColour: color.New(color.Faint),
+		SeverityColours: map[string]*color.Color{
+			"ERROR": color.New(color.Bold),
+		},
+	},
+}
+
+// Colour256 creates a colour for text using the given index into the 256-colour palette supported by most terminals
+func Colour256(index uint8) *color.Color {
+	return color.New(38, 5, color.Attribute(index))
+}
+
+// ColourRGB creates a colour for text using the given 24-bit "truecolour", supported by many modern terminals
+func ColourRGB(r, g, b uint8) *color.Color {
+	return color.New(38, 2, color.Attribute(r), color.Attribute(g), color.Attribute(b))
+}
+
+// colourModeFromEnvironment resolves ColourModeAuto using the NO_COLOR (see https://no-color.org) and FORCE_COLOR
+// environment variables, which take precedence over detecting whether the output is a terminal. NO_COLOR takes
+// precedence over FORCE_COLOR, and FORCE_COLOR is ignored if set to "0" or "false".
+func colourModeFromEnvironment() ColourMode {
+	if os.Getenv("NO_COLOR") != "" {
+		return ColourModeForceOff
+	}
+
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return ColourModeForceOn
+	}
+
+	return ColourModeAuto
+}
@@ -0,0 +1,169 @@
+package simplelogr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultCompressedFlushInterval is the FlushInterval used by CompressedWriter when none is specified
+	DefaultCompressedFlushInterval = time.Second
+)
+
+// CompressedWriter is a thread-safe io.Writer compressing everything written to it into another io.Writer, e.g. a
+// FileOutput, using a registered CompressionCodec, for long-running jobs that write large volumes of logs. Flushing it
+// (e.g. by flushing the sinks writing to it, see FlushSinks) completes a block of compressed data so that everything
+// written so far can be decompressed, which is also done every FlushInterval once Start is called. It must be closed
+// once the sinks writing to it have been closed, to finalise the compressed stream.
+type CompressedWriter struct {
+	options    CompressedWriterOptions
+	lock       sync.Mutex
+	underlying io.Writer
+	compressor io.WriteCloser
+	// pending is true if data has been written since the last flush
+	pending bool
+	closed  bool
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewCompressedWriter creates a new CompressedWriter compressing into the given io.Writer using the codec named by
+// the options
+func NewCompressedWriter(w io.Writer, opts CompressedWriterOptions) (*CompressedWriter, error) {
+	codec, err := LookupCompressionCodec(opts.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	compressor, err := codec.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s compressor: %w", codec.Name, err)
+	}
+
+	return &CompressedWriter{
+		options:    opts,
+		underlying: w,
+		compressor: compressor,
+	}, nil
+}
+
+// Write implements io.Writer, compressing the data. It may be buffered by the compressor until the next flush
+func (c *CompressedWriter) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return 0, os.ErrClosed
+	}
+
+	c.pending = true
+	return c.compressor.Write(p)
+}
+
+// Flush writes any data buffered by the compressor to the underlying io.Writer as a complete block, if the codec's
+// writer supports flushing (as gzip and zstd do), and then flushes the underlying io.Writer if it buffers writes
+func (c *CompressedWriter) Flush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed || !c.pending {
+		return nil
+	}
+
+	if err := flushWriter(c.compressor); err != nil {
+		return err
+	}
+	c.pending = false
+	return flushWriter(c.underlying)
+}
+
+// Start begins flushing every FlushInterval in the background, until the context is cancelled or Close is called
+func (c *CompressedWriter) Start(ctx context.Context) {
+	c.lifecycleLock.Lock()
+	defer c.lifecycleLock.Unlock()
+
+	if c.done != nil {
+		return
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(c.options.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Flush(); err != nil {
+					c.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(c.done)
+}
+
+// Close stops any background flushing started by Start, and finalises the compressed stream. It does not close the
+// underlying io.Writer, which is flushed if it buffers writes. Writing to the CompressedWriter afterwards fails with
+// os.ErrClosed
+func (c *CompressedWriter) Close() error {
+	c.lifecycleLock.Lock()
+	if c.done != nil {
+		c.cancel()
+		<-c.done
+		c.done = nil
+	}
+	c.lifecycleLock.Unlock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if err := c.compressor.Close(); err != nil {
+		return err
+	}
+	return flushWriter(c.underlying)
+}
+
+var _ io.WriteCloser = (*CompressedWriter)(nil)
+
+// CompressedWriterOptions configures the behaviour of a CompressedWriter
+type CompressedWriterOptions struct {
+	// Codec is the name of the CompressionCodec to compress with, see RegisterCompressionCodec
+	Codec string
+	// FlushInterval is how often data is flushed in the background once Start is called, bounding how much of the log
+	// would be lost if the process were to crash, at the cost of a slightly worse compression ratio
+	FlushInterval time.Duration
+	// ErrorHandler is called with any errors encountered while flushing in the background
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (c *CompressedWriterOptions) AssertDefaults() {
+	if c.Codec == "" {
+		c.Codec = DefaultCompressionCodec
+	}
+
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultCompressedFlushInterval
+	}
+
+	if c.ErrorHandler == nil {
+		c.ErrorHandler = DefaultErrorHandler
+	}
+}
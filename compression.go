@@ -0,0 +1,133 @@
+package simplelogr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// DefaultCompressionCodec is the name of the codec used by components that compress when none is specified
+	DefaultCompressionCodec = "gzip"
+)
+
+// CompressionCodec describes a compression format, so that components that compress (such as a RetentionManager
+// compressing segments, a SplunkHECLogSink compressing batches, or a CompressedWriter) can be configured with the same
+// codec by name. gzip is registered by default, zstd and snappy are registered by importing the simplelogrcompress
+// module, which keeps their dependencies out of this one, and others can be added using RegisterCompressionCodec, e.g.
+// deflate:
+//
+//	simplelogr.RegisterCompressionCodec(simplelogr.CompressionCodec{
+//	    Name:            "deflate",
+//	    ContentEncoding: "deflate",
+//	    Extension:       ".zz",
+//	    NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+//	        return zlib.NewWriter(w), nil
+//	    },
+//	    NewReader: func(r io.Reader) (io.ReadCloser, error) {
+//	        return zlib.NewReader(r)
+//	    },
+//	})
+type CompressionCodec struct {
+	// Name identifies the codec
+	Name string
+	// ContentEncoding, if specified, is the HTTP Content-Encoding of request bodies compressed using the codec, e.g.
+	// "gzip". Codecs without one cannot be used to compress HTTP requests
+	ContentEncoding string
+	// Extension is appended to the names of files compressed using the codec, e.g. ".gz"
+	Extension string
+	// NewWriter creates a writer compressing data written to it into w, which must be closed to complete the
+	// compressed stream
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	// NewReader creates a reader decompressing data read from r
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	compressionCodecsLock sync.RWMutex
+	compressionCodecs     = map[string]CompressionCodec{
+		"gzip": {
+			Name:            "gzip",
+			ContentEncoding: "gzip",
+			Extension:       ".gz",
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			},
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+	}
+)
+
+// RegisterCompressionCodec makes a codec available to be selected by name, replacing any codec registered with the
+// same name. Codecs should be registered before any components using them are created, typically in an init function
+func RegisterCompressionCodec(codec CompressionCodec) error {
+	if codec.Name == "" || codec.Extension == "" || codec.NewWriter == nil || codec.NewReader == nil {
+		return fmt.Errorf("compression codec %q must have a name, extension, writer and reader", codec.Name)
+	}
+
+	compressionCodecsLock.Lock()
+	defer compressionCodecsLock.Unlock()
+	compressionCodecs[codec.Name] = codec
+	return nil
+}
+
+// LookupCompressionCodec retrieves the codec registered with the given name
+func LookupCompressionCodec(name string) (CompressionCodec, error) {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+
+	codec, ok := compressionCodecs[name]
+	if !ok {
+		return CompressionCodec{}, fmt.Errorf("unknown compression codec %q", name)
+	}
+	return codec, nil
+}
+
+// CompressionCodecs lists the names of the registered codecs, sorted
+func CompressionCodecs() []string {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+
+	names := make([]string, 0, len(compressionCodecs))
+	for name := range compressionCodecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compressionCodecForFile identifies the codec a file was compressed with by the extension of its name
+func compressionCodecForFile(name string) (CompressionCodec, bool) {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+
+	for _, codec := range compressionCodecs {
+		if strings.HasSuffix(name, codec.Extension) {
+			return codec, true
+		}
+	}
+	return CompressionCodec{}, false
+}
+
+// compress compresses data in its entirety using the codec
+func (c CompressionCodec) compress(data []byte) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	w, err := c.NewWriter(&buffer)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
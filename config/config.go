@@ -0,0 +1,374 @@
+// Package config builds loggers from declarative descriptions, so that operators can change where and how a program
+// logs through configuration files rather than code changes, see Config.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/omaskery/simple-logr"
+)
+
+// Sink types supported by Sink
+const (
+	// SinkTypeJSON writes structured JSON using a JSONLogSink
+	SinkTypeJSON = "json"
+	// SinkTypeLogfmt writes structured logfmt using a LogfmtLogSink
+	SinkTypeLogfmt = "logfmt"
+	// SinkTypeDevelopment writes human-readable text using a DevelopmentLogSink, it is not available in minimal builds
+	SinkTypeDevelopment = "dev"
+	// SinkTypeCEF writes Common Event Format lines using a CEFLogSink
+	SinkTypeCEF = "cef"
+	// SinkTypeMsgpack writes MessagePack records using a BinaryLogSink
+	SinkTypeMsgpack = "msgpack"
+	// SinkTypeCBOR writes CBOR records using a BinaryLogSink
+	SinkTypeCBOR = "cbor"
+)
+
+// Outputs supported by Sink, other than file paths
+const (
+	// OutputStdout writes to the standard output stream
+	OutputStdout = "stdout"
+	// OutputStderr writes to the standard error stream
+	OutputStderr = "stderr"
+)
+
+// Config declaratively describes a logger and the sinks it writes to, e.g. as JSON:
+//
+//	{
+//	    "verbosity": 1,
+//	    "names": {"http": 0, "db.pool": 3},
+//	    "sinks": [
+//	        {"type": "dev", "output": "stdout"},
+//	        {"type": "json", "output": "/var/log/app", "rotation": {"name": "app", "max_size": 104857600},
+//	            "verbosity": 0, "options": {"MessageKey": "message", "SortKeys": true}},
+//	        {"type": "logfmt", "output": "/var/log/app-audit.log", "transforms": [{"op": "drop", "key": "password"}]}
+//	    ]
+//	}
+//
+// See Parse, ParseWith (for YAML) and New
+type Config struct {
+	// Verbosity determines which verbose log messages are enabled
+	Verbosity int `json:"verbosity,omitempty"`
+	// Names overrides the verbosity of loggers with the given name prefixes, see
+	// simplelogr.VerbosityController.SetNameVerbosity
+	Names map[string]int `json:"names,omitempty"`
+	// CaptureCaller records the location entries were logged from, see simplelogr.Options.CaptureCaller
+	CaptureCaller bool `json:"capture_caller,omitempty"`
+	// Sinks describes where entries are written, at least one is required
+	Sinks []Sink `json:"sinks"`
+}
+
+// Sink declaratively describes a sink, see Config
+type Sink struct {
+	// Type is the type of sink, one of SinkTypeJSON, SinkTypeLogfmt, SinkTypeDevelopment, SinkTypeCEF,
+	// SinkTypeMsgpack or SinkTypeCBOR
+	Type string `json:"type"`
+	// Output is where the sink writes to: OutputStdout, OutputStderr (the default), the path of a file to append to,
+	// or the directory to write segments to if Rotation is specified
+	Output string `json:"output,omitempty"`
+	// Rotation, if specified, writes to a series of segment files using a RotatingFileOutput
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// Verbosity, if specified, limits the sink to entries up to this verbosity level (errors are always included),
+	// e.g. to keep verbose entries out of some sinks
+	Verbosity *int `json:"verbosity,omitempty"`
+	// Severities, if specified, names the entry of simplelogr.SeverityTaxonomies used to describe the severity of
+	// entries, e.g. "syslog" or "otel". Only supported by SinkTypeJSON and SinkTypeLogfmt
+	Severities string `json:"severities,omitempty"`
+	// Options configures the sink, with the names of the fields of its options struct (e.g. JSONLogSinkOptions or
+	// DevelopmentLogSinkOptions) as keys. Options that cannot be described, such as functions, keep their defaults
+	Options json.RawMessage `json:"options,omitempty"`
+	// Transforms are applied to entries before they are written, see simplelogr.CompileTransforms
+	Transforms []simplelogr.TransformSpec `json:"transforms,omitempty"`
+}
+
+// Rotation declaratively describes a RotatingFileOutput, see simplelogr.RotatingFileOutputOptions
+type Rotation struct {
+	// Name prefixes the file name of every segment
+	Name string `json:"name"`
+	// Extension is the file extension of every segment, simplelogr.DefaultSegmentExtension if unspecified
+	Extension string `json:"extension,omitempty"`
+	// MaxSize, if specified, is the size in bytes after which a new segment is started
+	MaxSize int64 `json:"max_size,omitempty"`
+	// Interval, if specified, is a duration (e.g. "24h") after which a new segment is started
+	Interval string `json:"interval,omitempty"`
+	// Checksums frames each entry with its length and checksum
+	Checksums bool `json:"checksums,omitempty"`
+}
+
+// Parse decodes a JSON Config, rejecting unknown fields so that mistakes are not silently ignored
+func Parse(data []byte) (Config, error) {
+	var config Config
+	if err := decodeStrictJSON(data, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to decode logging config: %w", err)
+	}
+	return config, nil
+}
+
+// ParseWith decodes a Config using the given unmarshal function, such as yaml.Unmarshal from gopkg.in/yaml.v3 or
+// sigs.k8s.io/yaml to decode YAML documents, whose fields are named as in JSON. The document is decoded into generic
+// values and then converted to JSON, so that any decoder producing maps, slices and basic values can be used.
+func ParseWith(data []byte, unmarshal func(data []byte, v interface{}) error) (Config, error) {
+	var generic interface{}
+	if err := unmarshal(data, &generic); err != nil {
+		return Config{}, fmt.Errorf("failed to decode logging config: %w", err)
+	}
+
+	encoded, err := json.Marshal(stringKeys(generic))
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to decode logging config: %w", err)
+	}
+	return Parse(encoded)
+}
+
+// New creates a logr.Logger as described by the Config, opening any files it writes to. The returned function closes
+// them, and should be called at shutdown after closing the logger, see simplelogr.Close.
+func New(config Config) (logr.Logger, func() error, error) {
+	if len(config.Sinks) == 0 {
+		return logr.Discard(), nil, fmt.Errorf("logging config must describe at least one sink")
+	}
+
+	var sinks []simplelogr.LogSink
+	var closers []func() error
+	closeAll := func() error {
+		var errs multiError
+		for _, c := range closers {
+			if err := c(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+
+	for i, spec := range config.Sinks {
+		output, closeOutput, err := spec.output()
+		if err != nil {
+			_ = closeAll()
+			return logr.Discard(), nil, fmt.Errorf("sink %d: %w", i, err)
+		}
+		if closeOutput != nil {
+			closers = append(closers, closeOutput)
+		}
+
+		sink, err := spec.sink(output)
+		if err != nil {
+			_ = closeAll()
+			return logr.Discard(), nil, fmt.Errorf("sink %d: %w", i, err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	var sink simplelogr.LogSink = simplelogr.NewMultiSink(sinks...)
+	if len(sinks) == 1 {
+		sink = sinks[0]
+	}
+
+	controller := simplelogr.NewVerbosityController(config.Verbosity)
+	for prefix, verbosity := range config.Names {
+		controller.SetNameVerbosity(prefix, verbosity)
+	}
+
+	return logr.New(simplelogr.New(simplelogr.Options{
+		Sink:          sink,
+		Verbosity:     config.Verbosity,
+		Controller:    controller,
+		CaptureCaller: config.CaptureCaller,
+	})), closeAll, nil
+}
+
+// output opens the io.Writer the sink writes to, returning a function to close it if necessary
+func (s Sink) output() (io.Writer, func() error, error) {
+	if s.Rotation != nil {
+		if s.Output == "" || s.Output == OutputStdout || s.Output == OutputStderr {
+			return nil, nil, fmt.Errorf("rotation requires the output to be a directory")
+		}
+
+		opts := simplelogr.RotatingFileOutputOptions{
+			Dir:       s.Output,
+			Name:      s.Rotation.Name,
+			Extension: s.Rotation.Extension,
+			MaxSize:   s.Rotation.MaxSize,
+			Checksums: s.Rotation.Checksums,
+		}
+		if s.Rotation.Interval != "" {
+			interval, err := time.ParseDuration(s.Rotation.Interval)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid rotation interval: %w", err)
+			}
+			opts.RotationInterval = interval
+		}
+		opts.AssertDefaults()
+
+		output, err := simplelogr.OpenRotatingFileOutput(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return output, output.Close, nil
+	}
+
+	switch s.Output {
+	case OutputStdout:
+		return os.Stdout, nil, nil
+	case "", OutputStderr:
+		return os.Stderr, nil, nil
+	}
+
+	opts := simplelogr.FileOutputOptions{}
+	opts.AssertDefaults()
+	output, err := simplelogr.OpenFileOutput(s.Output, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return output, output.Close, nil
+}
+
+// sink creates the sink writing to the given output, applying its options, verbosity and transforms
+func (s Sink) sink(output io.Writer) (simplelogr.LogSink, error) {
+	// the standard streams are synchronised, except for the DevelopmentLogSink which detects whether they are
+	// terminals
+	structuredOutput := output
+	if f, ok := output.(*os.File); ok {
+		structuredOutput = simplelogr.SynchronizeWritesTo(f)
+	}
+
+	var taxonomy *simplelogr.SeverityTaxonomy
+	if s.Severities != "" {
+		t, ok := simplelogr.SeverityTaxonomies[s.Severities]
+		if !ok {
+			return nil, fmt.Errorf("unknown severities %q", s.Severities)
+		}
+		if s.Type != SinkTypeJSON && s.Type != SinkTypeLogfmt {
+			return nil, fmt.Errorf("severities are not supported by %q sinks", s.Type)
+		}
+		taxonomy = &t
+	}
+
+	var sink simplelogr.LogSink
+	switch s.Type {
+	case SinkTypeJSON:
+		opts := simplelogr.JSONLogSinkOptions{}
+		if err := decodeSinkOptions(s.Options, &opts); err != nil {
+			return nil, err
+		}
+		if taxonomy != nil {
+			taxonomy.ApplyJSON(&opts)
+		}
+		opts.Output = structuredOutput
+		opts.AssertDefaults()
+		sink = simplelogr.NewJSONLogSink(opts)
+	case SinkTypeLogfmt:
+		opts := simplelogr.LogfmtLogSinkOptions{}
+		if err := decodeSinkOptions(s.Options, &opts); err != nil {
+			return nil, err
+		}
+		if taxonomy != nil {
+			taxonomy.ApplyLogfmt(&opts)
+		}
+		opts.Output = structuredOutput
+		opts.AssertDefaults()
+		sink = simplelogr.NewLogfmtLogSink(opts)
+	case SinkTypeCEF:
+		opts := simplelogr.CEFLogSinkOptions{}
+		if err := decodeSinkOptions(s.Options, &opts); err != nil {
+			return nil, err
+		}
+		opts.Output = structuredOutput
+		opts.AssertDefaults()
+		sink = simplelogr.NewCEFLogSink(opts)
+	case SinkTypeMsgpack, SinkTypeCBOR:
+		opts := simplelogr.BinaryLogSinkOptions{}
+		if err := decodeSinkOptions(s.Options, &opts); err != nil {
+			return nil, err
+		}
+		opts.Output = structuredOutput
+		if s.Type == SinkTypeCBOR {
+			opts.Format = simplelogr.BinaryFormatCBOR
+		} else {
+			opts.Format = simplelogr.BinaryFormatMsgpack
+		}
+		opts.AssertDefaults()
+		sink = simplelogr.NewBinaryLogSink(opts)
+	case SinkTypeDevelopment:
+		var err error
+		if sink, err = developmentSink(output, s.Options); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", s.Type)
+	}
+
+	if len(s.Transforms) > 0 {
+		severityEncoder := simplelogr.DefaultSeverityEncoder(simplelogr.DefaultSeverity, simplelogr.DefaultErrorSeverity,
+			simplelogr.DefaultSeverityThresholds)
+		transforms, err := simplelogr.CompileTransforms(s.Transforms, severityEncoder)
+		if err != nil {
+			return nil, err
+		}
+		sink = simplelogr.NewProcessorSink(sink, transforms...)
+	}
+	if s.Verbosity != nil {
+		sink = simplelogr.NewFilterSink(sink, simplelogr.VerbosityFilter(*s.Verbosity))
+	}
+
+	return sink, nil
+}
+
+// decodeSinkOptions decodes the options of a sink into its options struct, rejecting unknown fields
+func decodeSinkOptions(data json.RawMessage, options interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := decodeStrictJSON(data, options); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
+
+func decodeStrictJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// stringKeys converts maps with interface{} keys (as produced by some YAML decoders) into maps with string keys, so
+// that they can be encoded as JSON
+func stringKeys(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			converted[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return converted
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = stringKeys(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = stringKeys(item)
+		}
+	}
+	return v
+}
+
+// multiError combines the errors of closing several outputs into one
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omaskery/simple-logr"
+)
+
+func TestNewWritesToConfiguredSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	config, err := Parse([]byte(`{
+		"verbosity": 1,
+		"sinks": [
+			{"type": "logfmt", "output": "` + filepath.ToSlash(path) + `", "verbosity": 0,
+				"options": {"MessageKey": "message"}, "transforms": [{"op": "drop", "key": "password"}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	logger, closeOutputs, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Info("logged in", "user", "alice", "password", "hunter2")
+	logger.V(1).Info("verbose")
+	if err := simplelogr.Close(logger); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+	if err := closeOutputs(); err != nil {
+		t.Fatalf("failed to close outputs: %v", err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	output := string(contents)
+	if !strings.Contains(output, `message="logged in" user=alice`) || strings.Contains(output, "hunter2") ||
+		strings.Contains(output, "verbose") {
+		t.Errorf("expected only the transformed info entry to be logged, got %s", output)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	if _, err := Parse([]byte(`{"sinks": [{"type": "json", "outptu": "stdout"}]}`)); err == nil {
+		t.Error("expected an error for the misspelled field")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package config
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/omaskery/simple-logr"
+)
+
+// developmentSink creates a DevelopmentLogSink writing to the given output, with the given options
+func developmentSink(output io.Writer, options json.RawMessage) (simplelogr.LogSink, error) {
+	opts := simplelogr.DevelopmentLogSinkOptions{}
+	if err := decodeSinkOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	opts.Output = output
+	opts.AssertDefaults()
+	return simplelogr.NewDevelopmentLogSink(opts), nil
+}
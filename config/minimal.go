@@ -0,0 +1,17 @@
+//go:build simplelogr_minimal
+// +build simplelogr_minimal
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/omaskery/simple-logr"
+)
+
+// developmentSink fails in minimal builds, as the DevelopmentLogSink is omitted
+func developmentSink(output io.Writer, options json.RawMessage) (simplelogr.LogSink, error) {
+	return nil, fmt.Errorf("sink type %q is not available in minimal builds", SinkTypeDevelopment)
+}
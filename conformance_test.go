@@ -0,0 +1,256 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+// conformanceScenario is a sequence of calls through the logr API, run against both funcr and Logger
+type conformanceScenario struct {
+	name string
+	run  func(l logr.Logger)
+}
+
+var conformanceScenarios = []conformanceScenario{
+	{"Info", func(l logr.Logger) {
+		l.Info("info message", "string", "value", "int", 1, "bool", true)
+	}},
+	{"Error", func(l logr.Logger) {
+		l.Error(errors.New("something went wrong"), "error message", "attempt", 3)
+	}},
+	{"NilError", func(l logr.Logger) {
+		l.Error(nil, "error message without an error", "attempt", 3)
+	}},
+	{"NoValues", func(l logr.Logger) {
+		l.Info("")
+		l.Error(errors.New("something went wrong"), "")
+	}},
+	{"WithName", func(l logr.Logger) {
+		l.WithName("outer").WithName("inner").Info("named message")
+	}},
+	{"WithValues", func(l logr.Logger) {
+		l.WithValues("a", 1).WithValues("b", 2).Info("message with values", "c", 3)
+		l.WithValues("a", 1).Error(errors.New("something went wrong"), "error with values", "b", 2)
+	}},
+	{"WithNameAndValues", func(l logr.Logger) {
+		l.WithName("outer").WithValues("a", 1).WithName("inner").V(1).WithValues("b", 2).Info("message", "c", 3)
+	}},
+	{"DerivedIsolation", func(l logr.Logger) {
+		parent := l.WithName("parent").WithValues("a", 1)
+		first := parent.WithName("first").WithValues("b", 2)
+		second := parent.WithName("second").WithValues("c", 3)
+		parent.Info("parent message")
+		first.Info("first message")
+		second.Info("second message")
+		parent.Info("parent message again")
+	}},
+	{"Verbosity", func(l logr.Logger) {
+		for level := 0; level <= 3; level++ {
+			l.V(level).Info("verbose message", "requested", level)
+		}
+		l.V(1).V(1).Info("stacked verbosity")
+		l.V(3).Error(errors.New("something went wrong"), "verbose error")
+	}},
+	{"CallDepth", func(l logr.Logger) {
+		conformanceHelper(l, "message from helper")
+		conformanceHelper(l.WithName("named").WithValues("a", 1), "named message from helper")
+	}},
+}
+
+// conformanceHelper logs on behalf of its caller, which both funcr and Logger should attribute the message to
+func conformanceHelper(l logr.Logger, msg string) {
+	l.WithCallDepth(1).Info(msg)
+}
+
+// recordingSink records the entries it is given, for tests to make assertions about
+type recordingSink struct {
+	lock    sync.Mutex
+	entries []Entry
+}
+
+// Log implements LogSink, recording the Entry
+func (r *recordingSink) Log(e Entry) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+var _ LogSink = (*recordingSink)(nil)
+
+// conformanceRecord is the meaning of a single log call, extracted from a funcr line or an Entry so that the two can
+// be compared. Values are represented as they decode from JSON, e.g. numbers are float64
+type conformanceRecord struct {
+	Name    string
+	IsError bool
+	Level   interface{}
+	Message string
+	Error   interface{}
+	Caller  interface{}
+	Values  []interface{}
+}
+
+// String renders the record for reporting differences
+func (r conformanceRecord) String() string {
+	return fmt.Sprintf("%+v", struct{ conformanceRecord }{r})
+}
+
+// TestLogrConformance runs a suite of scenarios through the logr API against both a Logger and the reference funcr
+// implementation from go-logr, and fails wherever their behaviour differs. The scenarios cover WithName and
+// WithValues (including that derived loggers do not affect one another), Enabled and V levels, errors including nil
+// errors, and call depth, comparing each entry's names, level, message, error, key-value pairs and caller.
+func TestLogrConformance(t *testing.T) {
+	for _, opts := range []Options{{}, {Verbosity: 1}, {Verbosity: 3}} {
+		for _, scenario := range conformanceScenarios {
+			t.Run(fmt.Sprintf("%s/Verbosity%d", scenario.name, opts.Verbosity), func(t *testing.T) {
+				assertConformance(t, scenario, opts)
+			})
+		}
+	}
+}
+
+// assertConformance runs a scenario against funcr and a Logger created with the given options, comparing the results
+func assertConformance(t *testing.T, scenario conformanceScenario, opts Options) {
+	var expected []conformanceRecord
+	reference := funcr.New(func(prefix, args string) {
+		record, err := parseConformanceLine(prefix, args)
+		if err != nil {
+			t.Fatalf("failed to parse funcr line %q: %v", args, err)
+		}
+		expected = append(expected, record)
+	}, funcr.Options{
+		LogCaller: funcr.All,
+		Verbosity: opts.Verbosity,
+	})
+
+	sink := &recordingSink{}
+	opts.Sink = sink
+	opts.CaptureCaller = true
+	logger := logr.New(New(opts))
+
+	for level := 0; level <= opts.Verbosity+1; level++ {
+		if want, got := reference.V(level).Enabled(), logger.V(level).Enabled(); want != got {
+			t.Errorf("V(%d).Enabled() returned %v, but funcr returned %v", level, got, want)
+		}
+	}
+
+	scenario.run(reference)
+	scenario.run(logger)
+
+	actual := make([]conformanceRecord, len(sink.entries))
+	for i, e := range sink.entries {
+		record, err := entryConformanceRecord(e)
+		if err != nil {
+			t.Fatalf("failed to describe entry %d: %v", i, err)
+		}
+		actual[i] = record
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("logged %d entries, but funcr logged %d\n--- funcr:\n%v\n--- simplelogr:\n%v", len(actual),
+			len(expected), expected, actual)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(actual[i], expected[i]) {
+			t.Errorf("entry %d differs from funcr\n--- funcr:\n%v\n--- simplelogr:\n%v", i, expected[i], actual[i])
+		}
+	}
+}
+
+// parseConformanceLine extracts the meaning of a line logged by funcr, which is a sequence of "key"=value pairs whose
+// values are encoded as JSON, starting with the caller and then the level and message of Info calls, or the message
+// and error of Error calls.
+//
+// Logger.Error passes nil errors on in the same way as Info at level 0, as sinks identify errors by the presence of an
+// error, so Error calls with a nil error are described as Info calls at level 0.
+func parseConformanceLine(prefix, args string) (conformanceRecord, error) {
+	record := conformanceRecord{Name: prefix}
+	header := true
+	for rest := args; rest != ""; {
+		key, n, err := decodeConformanceJSON(rest)
+		if err != nil {
+			return record, fmt.Errorf("invalid key: %w", err)
+		}
+		if !strings.HasPrefix(rest[n:], "=") {
+			return record, fmt.Errorf("expected = after key %v", key)
+		}
+		rest = rest[n+1:]
+
+		value, n, err := decodeConformanceJSON(rest)
+		if err != nil {
+			return record, fmt.Errorf("invalid value of %v: %w", key, err)
+		}
+		rest = strings.TrimPrefix(rest[n:], " ")
+
+		switch {
+		case header && key == "caller":
+			record.Caller = value
+		case header && key == "level":
+			record.Level = value
+		case header && key == "msg":
+			record.Message, _ = value.(string)
+		case header && key == "error":
+			record.IsError = true
+			record.Error = value
+		default:
+			header = false
+			record.Values = append(record.Values, key, value)
+		}
+	}
+
+	if record.IsError && record.Error == nil {
+		record.IsError = false
+		record.Level = float64(0)
+	}
+	return record, nil
+}
+
+// decodeConformanceJSON decodes the JSON value at the start of s, returning it along with its length
+func decodeConformanceJSON(s string) (interface{}, int, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, 0, err
+	}
+	return v, int(decoder.InputOffset()), nil
+}
+
+// entryConformanceRecord extracts the meaning of an Entry, in the same form as parseConformanceLine
+func entryConformanceRecord(e Entry) (conformanceRecord, error) {
+	record := conformanceRecord{
+		Name:    strings.Join(e.Names, "/"),
+		IsError: e.Error != nil,
+		Message: e.Message,
+		Caller:  map[string]interface{}{"file": "<unknown>", "line": float64(0)},
+	}
+	if e.Error != nil {
+		record.Error = e.Error.Error()
+	} else {
+		record.Level = float64(e.Level)
+	}
+	if e.Caller != nil {
+		record.Caller = map[string]interface{}{"file": filepath.Base(e.Caller.File), "line": float64(e.Caller.Line)}
+	}
+
+	for i := 0; i+1 < len(e.KVs); i += 2 {
+		b, err := json.Marshal(e.KVs[i+1])
+		if err != nil {
+			return record, err
+		}
+		var value interface{}
+		if err := json.Unmarshal(b, &value); err != nil {
+			return record, err
+		}
+		record.Values = append(record.Values, e.KVs[i], value)
+	}
+	return record, nil
+}
@@ -0,0 +1,77 @@
+package simplelogr
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultTraceIDKey is the key TraceContextHook uses for trace IDs
+	DefaultTraceIDKey = "trace_id"
+	// DefaultSpanIDKey is the key TraceContextHook uses for span IDs
+	DefaultSpanIDKey = "span_id"
+)
+
+// ContextHook extracts key-value pairs from a context.Context, which FromContext adds to the logger it returns. See
+// Options.ContextHooks
+type ContextHook func(ctx context.Context) []interface{}
+
+// TraceExtractor extracts tracing identifiers from a context.Context, returning false if the context carries none. For
+// example, when using OpenTelemetry:
+//
+//	func(ctx context.Context) (string, string, bool) {
+//	    sc := trace.SpanContextFromContext(ctx)
+//	    return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	}
+type TraceExtractor func(ctx context.Context) (traceID string, spanID string, ok bool)
+
+// TraceContextHook creates a ContextHook which uses the given TraceExtractor to add DefaultTraceIDKey and
+// DefaultSpanIDKey key-value pairs to loggers retrieved using FromContext, so that logs can be correlated with traces
+func TraceContextHook(extract TraceExtractor) ContextHook {
+	return func(ctx context.Context) []interface{} {
+		traceID, spanID, ok := extract(ctx)
+		if !ok {
+			return nil
+		}
+
+		var kvs []interface{}
+		if traceID != "" {
+			kvs = append(kvs, DefaultTraceIDKey, traceID)
+		}
+		if spanID != "" {
+			kvs = append(kvs, DefaultSpanIDKey, spanID)
+		}
+		return kvs
+	}
+}
+
+// IntoContext returns a copy of the context.Context carrying the given logger, see FromContext
+func IntoContext(ctx context.Context, logger logr.Logger) context.Context {
+	return logr.NewContext(ctx, logger)
+}
+
+// FromContext retrieves the logger stored in the context.Context by IntoContext, or a logger that discards everything
+// if there is none. If the logger is backed by a Logger, the key-value pairs produced by its Options.ContextHooks for
+// the given context are added to the returned logger.
+func FromContext(ctx context.Context) logr.Logger {
+	logger, err := logr.FromContext(ctx)
+	if err != nil {
+		return logr.Discard()
+	}
+
+	sink, ok := logger.GetSink().(*Logger)
+	if !ok {
+		return logger
+	}
+
+	var kvs []interface{}
+	for _, hook := range sink.options.ContextHooks {
+		kvs = append(kvs, hook(ctx)...)
+	}
+	if len(kvs) > 0 {
+		logger = logger.WithValues(kvs...)
+	}
+
+	return logger
+}
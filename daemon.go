@@ -0,0 +1,95 @@
+package simplelogr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+)
+
+// DaemonOptions configures NewDaemon
+type DaemonOptions struct {
+	// Name identifies the service, used as the journal identifier or event log source, defaults to the program name
+	Name string
+	// FilePath is the path of a file that logs are also written to as JSON, if unspecified no file is written
+	FilePath string
+	// File configures the file at FilePath, by default (SyncPolicyDefault) writes are flushed to stable storage every
+	// DefaultFileSyncInterval
+	File FileOutputOptions
+	// Verbosity is the verbosity of the returned logger
+	Verbosity int
+}
+
+// NewDaemon creates a logr.Logger suitable for a daemonized process, automatically selecting where to log based on
+// how the process was started:
+// - on Linux, when started by systemd, entries are sent to the journal
+// - on Windows, when running as a service, entries are sent to the Windows Event Log
+// - otherwise, entries are written to stderr as JSON
+// Entries are additionally written as JSON to DaemonOptions.FilePath, if specified. Coloured output is never used.
+// The returned function closes any resources held by the logger, and should be called at shutdown.
+func NewDaemon(opts DaemonOptions) (logr.Logger, func() error, error) {
+	if opts.Name == "" {
+		opts.Name = filepath.Base(os.Args[0])
+	}
+
+	var sinks []LogSink
+	var closers []func() error
+	closeAll := func() error {
+		var errs multiError
+		for _, c := range closers {
+			if err := c(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+
+	systemSink, closeSystemSink, err := daemonSystemSink(opts.Name)
+	if err != nil {
+		return logr.Discard(), nil, err
+	}
+	if systemSink != nil {
+		sinks = append(sinks, systemSink)
+		closers = append(closers, closeSystemSink)
+	} else {
+		sinkOpts := JSONLogSinkOptions{
+			Output: SynchronizeWritesTo(os.Stderr),
+		}
+		sinkOpts.AssertDefaults()
+		sinks = append(sinks, NewJSONLogSink(sinkOpts))
+	}
+
+	if opts.FilePath != "" {
+		if opts.File.SyncPolicy == SyncPolicyDefault {
+			opts.File.SyncPolicy = SyncPolicyInterval
+		}
+		opts.File.AssertDefaults()
+
+		file, err := OpenFileOutput(opts.FilePath, opts.File)
+		if err != nil {
+			_ = closeAll()
+			return logr.Discard(), nil, fmt.Errorf("failed to open daemon log file: %w", err)
+		}
+		closers = append(closers, file.Close)
+
+		sinkOpts := JSONLogSinkOptions{
+			Output: file,
+		}
+		sinkOpts.AssertDefaults()
+		sinks = append(sinks, NewJSONLogSink(sinkOpts))
+	}
+
+	var sink LogSink = NewMultiSink(sinks...)
+	if len(sinks) == 1 {
+		sink = sinks[0]
+	}
+
+	return logr.New(New(Options{
+		Sink:      sink,
+		Verbosity: opts.Verbosity,
+	})), closeAll, nil
+}
@@ -0,0 +1,16 @@
+package simplelogr
+
+// daemonSystemSink selects the journal when the process was started by systemd
+func daemonSystemSink(name string) (LogSink, func() error, error) {
+	if !JournaldAvailable() {
+		return nil, nil, nil
+	}
+
+	opts := JournaldLogSinkOptions{
+		Identifier: name,
+	}
+	opts.AssertDefaults()
+	sink := NewJournaldLogSink(opts)
+
+	return sink, sink.Close, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package simplelogr
+
+// daemonSystemSink has no system logging facility to select on this platform
+func daemonSystemSink(name string) (LogSink, func() error, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,29 @@
+package simplelogr
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// daemonSystemSink selects the Windows Event Log when the process is running as a Windows service
+func daemonSystemSink(name string) (LogSink, func() error, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to determine whether running as a service: %w", err)
+	}
+	if !isService {
+		return nil, nil, nil
+	}
+
+	opts := EventLogSinkOptions{
+		Source: name,
+	}
+	opts.AssertDefaults()
+	sink, err := NewEventLogSink(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sink, sink.Close, nil
+}
@@ -0,0 +1,129 @@
+package simplelogr
+
+import (
+	"sync"
+)
+
+var (
+	// DefaultDebugOnErrorBufferSize is the number of verbose entries a DebugOnErrorSink keeps when none is specified
+	DefaultDebugOnErrorBufferSize = 100
+)
+
+// DebugOnErrorSink passes entries at or below its verbosity on to another LogSink, while keeping the most recent
+// more verbose entries in a ring buffer instead. When an entry containing an error arrives, the buffered entries are
+// emitted ahead of it, giving detailed context for failures without the cost of always emitting verbose output.
+//
+// The Logger must be configured with a verbosity high enough to enable the entries that should be buffered, e.g.:
+//
+//	sink := NewDebugOnErrorSink(DebugOnErrorSinkOptions{Sink: jsonSink, Verbosity: 0})
+//	logger := logr.New(New(Options{Sink: sink, Verbosity: 10}))
+type DebugOnErrorSink struct {
+	options DebugOnErrorSinkOptions
+
+	lock sync.Mutex
+	// buffer holds the most recent verbose entries, oldest first
+	buffer []queuedEntry
+}
+
+// NewDebugOnErrorSink creates a new DebugOnErrorSink with the provided options
+func NewDebugOnErrorSink(opts DebugOnErrorSinkOptions) *DebugOnErrorSink {
+	return &DebugOnErrorSink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, buffering verbose entries and emitting any buffered entries ahead of errors
+func (d *DebugOnErrorSink) Log(e Entry) error {
+	if e.Error != nil {
+		var errs multiError
+		for _, buffered := range d.drain() {
+			if err := d.options.Sink.Log(buffered); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := d.options.Sink.Log(e); err != nil {
+			errs = append(errs, err)
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+
+	if e.Level <= d.options.Verbosity {
+		return d.options.Sink.Log(e)
+	}
+
+	size := d.options.Memory.size(e)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.buffer) >= d.options.BufferSize {
+		d.options.Memory.release(d.evictLocked())
+	}
+	if d.options.Memory.reserve(size, func() (int64, bool) {
+		if len(d.buffer) == 0 {
+			return 0, false
+		}
+		return d.evictLocked(), true
+	}) {
+		d.buffer = append(d.buffer, queuedEntry{entry: e, size: size})
+	}
+
+	return nil
+}
+
+// evictLocked discards the oldest buffered entry, returning the memory reserved for it
+func (d *DebugOnErrorSink) evictLocked() int64 {
+	oldest := d.buffer[0]
+	d.buffer[0] = queuedEntry{}
+	d.buffer = d.buffer[1:]
+	return oldest.size
+}
+
+// drain empties the buffer, returning its entries oldest first
+func (d *DebugOnErrorSink) drain() []Entry {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	ordered := make([]Entry, 0, len(d.buffer))
+	for _, buffered := range d.buffer {
+		d.options.Memory.release(buffered.size)
+		ordered = append(ordered, buffered.entry)
+	}
+	d.buffer = nil
+
+	return ordered
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (d *DebugOnErrorSink) Unwrap() []LogSink {
+	return []LogSink{d.options.Sink}
+}
+
+var _ LogSink = (*DebugOnErrorSink)(nil)
+var _ WrapperSink = (*DebugOnErrorSink)(nil)
+
+// DebugOnErrorSinkOptions configures the behaviour of a DebugOnErrorSink
+type DebugOnErrorSinkOptions struct {
+	// Sink is the LogSink that entries are passed on to
+	Sink LogSink
+	// Verbosity is the highest verbosity level passed straight on to the Sink, more verbose entries are buffered
+	Verbosity int
+	// BufferSize is the number of the most recent verbose entries kept
+	BufferSize int
+	// Memory optionally limits the memory used by buffered entries, shared with other buffering components. Entries
+	// that do not fit within the budget are not buffered, and with MemoryPolicyShrink the oldest entries make way for
+	// newer ones.
+	Memory MemoryLimit
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (d *DebugOnErrorSinkOptions) AssertDefaults() {
+	if d.BufferSize <= 0 {
+		d.BufferSize = DefaultDebugOnErrorBufferSize
+	}
+
+	d.Memory.assertDefaults("debug_on_error")
+}
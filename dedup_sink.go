@@ -0,0 +1,236 @@
+package simplelogr
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultDedupWindow is the Window used by DedupSink when none is specified
+	DefaultDedupWindow = 10 * time.Second
+	// DefaultDedupRepeatKey is the RepeatKey used by DedupSink when none is specified
+	DefaultDedupRepeatKey = "repeated"
+)
+
+// DedupSink collapses consecutive identical entries, such as those logged by a tight retry loop, before passing them
+// on to another LogSink. The first entry is passed on, and identical entries following it within the Window are
+// suppressed. Once a different entry arrives or the Window expires, a summary of the repeats is emitted: the last
+// repeated entry with a key-value pair (by default "repeated") counting the suppressed entries, similar to syslog's
+// "last message repeated N times". Pending summaries are also emitted when the sink is flushed, and every Window
+// once Start is called, so that they are not delayed indefinitely once the repetition stops.
+type DedupSink struct {
+	options DedupSinkOptions
+
+	lock sync.Mutex
+	// last is the entry most recently passed on, which repeats are compared against
+	last *Entry
+	// windowStart is the time the current Window began
+	windowStart time.Time
+	// latest is the most recent suppressed repeat of last, and repeats counts them
+	latest  Entry
+	repeats int
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewDedupSink creates a new DedupSink with the provided options
+func NewDedupSink(opts DedupSinkOptions) *DedupSink {
+	return &DedupSink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, suppressing the Entry if it repeats the previous one within the Window
+func (d *DedupSink) Log(e Entry) error {
+	timestamp := e.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	d.lock.Lock()
+	if d.last != nil && timestamp.Sub(d.windowStart) < d.options.Window && d.options.Equal(*d.last, e) {
+		d.latest = e
+		d.repeats++
+		d.lock.Unlock()
+		return nil
+	}
+
+	summary, ok := d.takeSummaryLocked()
+	d.last = &e
+	d.windowStart = timestamp
+	d.lock.Unlock()
+
+	var errs multiError
+	if ok {
+		if err := d.options.Sink.Log(summary); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := d.options.Sink.Log(e); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// takeSummaryLocked returns the summary of any repeats suppressed since the last summary, and false if there were none
+func (d *DedupSink) takeSummaryLocked() (Entry, bool) {
+	if d.repeats == 0 {
+		return Entry{}, false
+	}
+
+	summary := d.latest
+	kvs := make([]interface{}, len(summary.KVs), len(summary.KVs)+2)
+	copy(kvs, summary.KVs)
+	summary.KVs = append(kvs, d.options.RepeatKey, d.repeats)
+
+	d.latest = Entry{}
+	d.repeats = 0
+	return summary, true
+}
+
+// emitSummary passes on a summary of any suppressed repeats, if expired is true only once the Window has elapsed.
+// Repeats arriving afterwards are counted towards the next summary.
+func (d *DedupSink) emitSummary(expired bool) error {
+	d.lock.Lock()
+	if expired && time.Since(d.windowStart) < d.options.Window {
+		d.lock.Unlock()
+		return nil
+	}
+	summary, ok := d.takeSummaryLocked()
+	if ok {
+		d.windowStart = time.Now()
+	}
+	d.lock.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return d.options.Sink.Log(summary)
+}
+
+// Flush implements FlushSink, emitting a summary of any suppressed repeats
+func (d *DedupSink) Flush() error {
+	return d.emitSummary(false)
+}
+
+// Start begins emitting summaries of suppressed repeats in the background once the Window has elapsed, until the
+// context is cancelled or the sink is closed. Calling Start on a sink that is already started has no effect.
+func (d *DedupSink) Start(ctx context.Context) {
+	d.lifecycleLock.Lock()
+	defer d.lifecycleLock.Unlock()
+
+	if d.done != nil {
+		return
+	}
+
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(d.options.Window)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.emitSummary(true); err != nil {
+					d.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(d.done)
+}
+
+// Close implements CloserSink, stopping any background summaries started by Start and emitting a summary of any
+// suppressed repeats
+func (d *DedupSink) Close() error {
+	d.lifecycleLock.Lock()
+	if d.done != nil {
+		d.cancel()
+		<-d.done
+		d.done = nil
+	}
+	d.lifecycleLock.Unlock()
+
+	return d.Flush()
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (d *DedupSink) Unwrap() []LogSink {
+	return []LogSink{d.options.Sink}
+}
+
+var _ LogSink = (*DedupSink)(nil)
+var _ FlushSink = (*DedupSink)(nil)
+var _ CloserSink = (*DedupSink)(nil)
+var _ WrapperSink = (*DedupSink)(nil)
+
+// DefaultDedupEqual considers entries identical if they have the same level, logger name, message, error message and
+// key-value pairs
+func DefaultDedupEqual(a, b Entry) (equal bool) {
+	if a.Level != b.Level || a.Message != b.Message || a.Severity != b.Severity || len(a.Names) != len(b.Names) {
+		return false
+	}
+	for i := range a.Names {
+		if a.Names[i] != b.Names[i] {
+			return false
+		}
+	}
+
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+
+	if (a.Error == nil) != (b.Error == nil) || (a.Error != nil && a.Error.Error() != b.Error.Error()) {
+		return false
+	}
+	return reflect.DeepEqual(a.KVs, b.KVs)
+}
+
+// DedupSinkOptions configures the behaviour of a DedupSink
+type DedupSinkOptions struct {
+	// Sink is the LogSink that entries and summaries are passed on to
+	Sink LogSink
+	// Window is how long after an entry is passed on that identical entries are suppressed, after which a summary is
+	// emitted and the next repeat is passed on in full
+	Window time.Duration
+	// RepeatKey is the key of the key-value pair added to summaries, counting the suppressed entries
+	RepeatKey string
+	// Equal identifies which entries count as repetitions of each other
+	Equal func(a, b Entry) bool
+	// ErrorHandler is called with any errors encountered while emitting summaries in the background
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (d *DedupSinkOptions) AssertDefaults() {
+	if d.Window <= 0 {
+		d.Window = DefaultDedupWindow
+	}
+
+	if d.RepeatKey == "" {
+		d.RepeatKey = DefaultDedupRepeatKey
+	}
+
+	if d.Equal == nil {
+		d.Equal = DefaultDedupEqual
+	}
+
+	if d.ErrorHandler == nil {
+		d.ErrorHandler = DefaultErrorHandler
+	}
+}
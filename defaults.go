@@ -1,13 +1,12 @@
 package simplelogr
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
-
-	"github.com/fatih/color"
-	"github.com/pkg/errors"
 )
 
 var (
@@ -16,26 +15,24 @@ var (
 	DefaultTimestampKey       = "ts"
 	DefaultTimestampFormat    = time.RFC3339Nano
 	DefaultNameSeparator      = "."
-	DefaultTraceVerbosity     = 2
-	DefaultDebugVerbosity     = 1
+	DefaultTraceVerbosity     = int(LevelTrace)
+	DefaultDebugVerbosity     = int(LevelDebug)
 	DefaultSeverityKey        = "severity"
+	DefaultLevelKey           = "level"
 	DefaultErrorKey           = "error"
 	DefaultStackTraceKey      = "stacktrace"
+	DefaultCallerKey          = "caller"
+	DefaultSequenceKey        = "seq"
+	DefaultKVsKey             = "fields"
 	DefaultSeverity           = "INFO"
 	DefaultErrorSeverity      = "ERROR"
+	DefaultWarnSeverity       = "WARN"
 	DefaultEntrySuffix        = "\n"
 	DefaultSpaceSeparator     = " "
 	DefaultSeverityThresholds = []SeverityThreshold{
 		{Level: DefaultTraceVerbosity, Severity: "TRACE"},
 		{Level: DefaultDebugVerbosity, Severity: "DEBUG"},
-	}
-	DefaultPrimaryColour   = color.New(color.FgHiWhite)
-	DefaultSecondaryColour = color.New(color.FgWhite)
-	DefaultSeverityColours = map[string]*color.Color{
-		"ERROR": color.New(color.FgHiRed),
-		"INFO":  color.New(color.FgHiWhite),
-		"DEBUG": color.New(color.FgHiBlue),
-		"TRACE": color.New(color.FgMagenta),
+		{Level: int(LevelWarn), Severity: DefaultWarnSeverity},
 	}
 )
 
@@ -52,21 +49,43 @@ type EncodedError struct {
 	Message string
 	// StackTrace is optional stack trace information extracted from the error
 	StackTrace string
+	// Type is the name of the type of the error, e.g. "*fs.PathError", populated by StructuredErrorEncoder
+	Type string
+	// Frames is optional stack trace information extracted from the error as a list of frames, innermost first,
+	// populated by StructuredErrorEncoder
+	Frames []StackFrame
+	// Causes are the errors wrapped by the error, outermost first, found using errors.Unwrap and populated by
+	// StructuredErrorEncoder. Causes never have causes of their own.
+	Causes []EncodedError
+	// Errors are the constituent errors of a multi-error, e.g. one created using errors.Join, each encoded in full
+	Errors []EncodedError
+}
+
+// structured reports whether the EncodedError has more detail than a message and stack trace string, in which case
+// sinks that support it represent it as a structured object
+func (e EncodedError) structured() bool {
+	return e.Type != "" || len(e.Frames) > 0 || len(e.Causes) > 0 || len(e.Errors) > 0
 }
 
 // DefaultErrorEncoder uses an error's error.Error() implementation to populate the EncodedError.Message, and has
 // support for github.com/pkg/errors which may have built-in stack traces. If it detects a built-in stack trace it
-// will populate the EncodedError.StackTrace with it.
+// will populate the EncodedError.StackTrace with it. Support for github.com/pkg/errors is omitted from builds using
+// the simplelogr_minimal build tag. If the error is, or wraps, a multi-error (see EncodedError.Errors) each of its
+// constituent errors is encoded in the same way.
 func DefaultErrorEncoder(err error) EncodedError {
 	encoded := EncodedError{
 		Message: err.Error(),
 	}
 
-	type tracedError interface {
-		StackTrace() errors.StackTrace
+	if stackTrace, ok := pkgErrorsStackTrace(err); ok {
+		encoded.StackTrace = stackTrace
 	}
-	if traced, ok := err.(tracedError); ok {
-		encoded.StackTrace = fmt.Sprintf("%+v", traced.StackTrace())
+
+	for wrapped := err; wrapped != nil; wrapped = errors.Unwrap(wrapped) {
+		if errs, ok := multiErrors(wrapped); ok {
+			encoded.Errors = encodeErrors(errs, DefaultErrorEncoder)
+			break
+		}
 	}
 
 	return encoded
@@ -74,7 +93,8 @@ func DefaultErrorEncoder(err error) EncodedError {
 
 // SeverityThreshold describes a verbosity level at which logs are associated with a given severity level string
 type SeverityThreshold struct {
-	// Level at which the verbosity level must be greater than or equal to in order to satisfy this threshold
+	// Level at which the verbosity level must be greater than or equal to in order to satisfy this threshold, or for
+	// negative levels such as LevelWarn, less than or equal to
 	Level int
 	// Severity is the the severity level name
 	Severity string
@@ -82,7 +102,9 @@ type SeverityThreshold struct {
 
 // DefaultSeverityEncoder uses the provided defaults and thresholds to convert verbosity levels into a severity name.
 // - Errors take precedence, using the provided error severity name.
-// - The thresholds are then tested, to identify any specific severities that should be used based on the verbosity level.
+// - The thresholds are then tested in order, to identify any specific severities that should be used based on the
+// verbosity level. Verbose thresholds are satisfied by levels at or above them, negative ones by levels at or below
+// them.
 // - Finally a default severity is used.
 func DefaultSeverityEncoder(defaultSeverity string, errSeverity string, thresholds []SeverityThreshold) func(verbosity int, err error) string {
 	return func(level int, err error) string {
@@ -91,7 +113,11 @@ func DefaultSeverityEncoder(defaultSeverity string, errSeverity string, threshol
 		}
 
 		for _, threshold := range thresholds {
-			if level >= threshold.Level {
+			if threshold.Level < 0 {
+				if level <= threshold.Level {
+					return threshold.Severity
+				}
+			} else if level >= threshold.Level {
 				return threshold.Severity
 			}
 		}
@@ -107,7 +133,49 @@ func DefaultNameEncoder(separator string) func(names []string) string {
 	}
 }
 
-// DefaultErrorHandler simply emits logging errors to stderr
+// DefaultCallerEncoder formats caller information as "file:line", using only the last directory of the file path
+func DefaultCallerEncoder(c Caller) interface{} {
+	return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(filepath.Dir(c.File)), filepath.Base(c.File)), c.Line)
+}
+
+// DefaultErrorHandler simply emits logging errors to stderr, one per line
 func DefaultErrorHandler(err error) {
-	_, _ = fmt.Fprintf(os.Stderr, "logging error: %+v", err)
+	_, _ = fmt.Fprintf(os.Stderr, "logging error: %+v\n", err)
+}
+
+// DefaultSyslogPriorityEncoder maps verbosity levels and errors onto syslog priorities: errors are "err" (3), warnings
+// are "warning" (4), non-verbose messages are "info" (6), and all verbose messages are "debug" (7)
+func DefaultSyslogPriorityEncoder(level int, err error) int {
+	if err != nil {
+		return 3
+	}
+
+	if level < 0 {
+		return 4
+	}
+
+	if level > 0 {
+		return 7
+	}
+
+	return 6
+}
+
+// DefaultSeverityNumberEncoder maps verbosity levels and errors onto OpenTelemetry SeverityNumber values, consistently
+// with the default severity names: errors are ERROR (17), warnings are WARN (13), non-verbose messages are INFO (9),
+// and verbose messages are DEBUG (5) or TRACE (1) according to DefaultDebugVerbosity and DefaultTraceVerbosity. Numbers
+// increase with severity, so that downstream systems can filter entries numerically, e.g. severity_number >= 9.
+func DefaultSeverityNumberEncoder(level int, err error) int {
+	switch {
+	case err != nil:
+		return 17
+	case level < 0:
+		return 13
+	case level >= DefaultTraceVerbosity:
+		return 1
+	case level >= DefaultDebugVerbosity:
+		return 5
+	default:
+		return 9
+	}
 }
@@ -0,0 +1,100 @@
+package simplelogr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DeriveKV creates a Processor adding a key-value pair computed from each Entry, e.g. to avoid pushing derivations
+// into every downstream query. If derive returns false nothing is added.
+func DeriveKV(key string, derive func(e Entry) (interface{}, bool)) Processor {
+	return func(e Entry) (Entry, bool) {
+		value, ok := derive(e)
+		if !ok {
+			return e, true
+		}
+
+		kvs := make([]interface{}, len(e.KVs), len(e.KVs)+2)
+		copy(kvs, e.KVs)
+		e.KVs = append(kvs, key, value)
+		return e, true
+	}
+}
+
+// DeriveFromKV creates a Processor adding a key-value pair computed from the value of another key-value pair, e.g.
+// a "region" from a "host". Nothing is added if the source key is absent or derive returns false.
+func DeriveFromKV(source string, target string, derive func(v interface{}) (interface{}, bool)) Processor {
+	return DeriveKV(target, func(e Entry) (interface{}, bool) {
+		value, ok := LookupKV(e, source)
+		if !ok {
+			return nil, false
+		}
+		return derive(value)
+	})
+}
+
+// LookupKV finds the value of the last key-value pair in the Entry with the given key
+func LookupKV(e Entry, key string) (interface{}, bool) {
+	for i := len(e.KVs) - 2; i >= 0; i -= 2 {
+		if k, ok := e.KVs[i].(string); ok && k == key {
+			return e.KVs[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// NumericBuckets creates a derivation for use with DeriveFromKV which places numeric values into buckets with the
+// given ascending upper bounds, e.g. NumericBuckets(100, 500) labels values as "<100", "100-500" or ">=500". This is
+// useful for deriving e.g. a "latency_bucket" from "latency_ms". Non-numeric values are ignored.
+func NumericBuckets(bounds ...float64) func(v interface{}) (interface{}, bool) {
+	return func(v interface{}) (interface{}, bool) {
+		f, ok := toFloat64(v)
+		if !ok || len(bounds) == 0 {
+			return nil, false
+		}
+
+		format := func(b float64) string {
+			return strconv.FormatFloat(b, 'f', -1, 64)
+		}
+
+		if f < bounds[0] {
+			return "<" + format(bounds[0]), true
+		}
+		for i := 1; i < len(bounds); i++ {
+			if f < bounds[i] {
+				return fmt.Sprintf("%s-%s", format(bounds[i-1]), format(bounds[i])), true
+			}
+		}
+		return ">=" + format(bounds[len(bounds)-1]), true
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
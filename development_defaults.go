@@ -0,0 +1,24 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"github.com/fatih/color"
+)
+
+var (
+	// DefaultDevelopmentIndent indents the key-value pairs of entries logged by a DevelopmentLogSink using
+	// DevelopmentLayoutMultiLine
+	DefaultDevelopmentIndent = "    "
+
+	DefaultPrimaryColour   = color.New(color.FgHiWhite)
+	DefaultSecondaryColour = color.New(color.FgWhite)
+	DefaultSeverityColours = map[string]*color.Color{
+		"ERROR": color.New(color.FgHiRed),
+		"WARN":  color.New(color.FgHiYellow),
+		"INFO":  color.New(color.FgHiWhite),
+		"DEBUG": color.New(color.FgHiBlue),
+		"TRACE": color.New(color.FgMagenta),
+	}
+)
@@ -1,14 +1,21 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
 package simplelogr
 
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 	"github.com/pkg/errors"
 )
 
@@ -16,6 +23,51 @@ import (
 // for ease of reading in terminals during local development
 type DevelopmentLogSink struct {
 	options DevelopmentLogSinkOptions
+	// resetColours causes the colour to be reset at the end of each entry, as the color package does not reset colours
+	// that were forced on when it detects stdout is not a terminal
+	resetColours bool
+	// colours holds the escape sequences of each of the colours in the options
+	colours map[*color.Color]colourCodes
+}
+
+// colourReset is the escape sequence resetting all colours and attributes
+const colourReset = "\x1b[0m"
+
+// colourCodes are the escape sequences written before and after text in a colour, computed once when the sink is
+// created so that entries are appended straight into the buffer rather than printed through the color package
+type colourCodes struct {
+	start string
+	end   string
+}
+
+// newColourCodes determines the escape sequences the color package would write around text in the given colour
+func newColourCodes(c *color.Color) colourCodes {
+	wrapped := c.Sprint("")
+	if wrapped == "" {
+		return colourCodes{}
+	}
+
+	codes := colourCodes{start: strings.TrimSuffix(wrapped, colourReset)}
+	// like color.Color.Fprint, colours are not reset when the color package has disabled colours globally, see
+	// resetColours
+	if !color.NoColor {
+		codes.end = colourReset
+	}
+	return codes
+}
+
+// appendString appends the string in the colour
+func (c colourCodes) appendString(buf []byte, s string) []byte {
+	buf = append(buf, c.start...)
+	buf = append(buf, s...)
+	return append(buf, c.end...)
+}
+
+// appendBytes appends the bytes in the colour
+func (c colourCodes) appendBytes(buf []byte, b []byte) []byte {
+	buf = append(buf, c.start...)
+	buf = append(buf, b...)
+	return append(buf, c.end...)
 }
 
 // NewDevelopmentLogSink creates a new DevelopmentLogSink with the provided options
@@ -31,57 +83,249 @@ func NewDevelopmentLogSink(opts DevelopmentLogSinkOptions) *DevelopmentLogSink {
 	for _, c := range sink.options.SeverityColours {
 		allColours = append(allColours, c)
 	}
+	for _, rule := range sink.options.KeyColours {
+		allColours = append(allColours, rule.Colour)
+	}
+
+	colourMode := sink.options.ColouredOutput
+	if colourMode == ColourModeAuto {
+		colourMode = colourModeFromEnvironment()
+	}
+
+	// when writing to a file (e.g. os.Stderr), wrap it so that escape sequences are translated on Windows legacy
+	// consoles, and make auto-detection consider the file actually being written to rather than stdout
+	if f, ok := sink.options.Output.(*os.File); ok {
+		isTerminal := isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+		if colourMode == ColourModeAuto && !isTerminal {
+			colourMode = ColourModeForceOff
+		}
+		if colourMode == ColourModeForceOff {
+			sink.options.Output = colorable.NewNonColorable(f)
+		} else {
+			sink.options.Output = colorable.NewColorable(f)
+		}
+	}
 
-	switch sink.options.ColouredOutput {
+	switch colourMode {
 	case ColourModeAuto:
 		// do nothing, let the color package do its magic
 	case ColourModeForceOn:
 		for _, c := range allColours {
 			c.EnableColor()
 		}
+		sink.resetColours = color.NoColor
 	case ColourModeForceOff:
 		for _, c := range allColours {
 			c.DisableColor()
 		}
 	}
 
+	sink.colours = make(map[*color.Color]colourCodes, len(allColours))
+	for _, c := range allColours {
+		sink.colours[c] = newColourCodes(c)
+	}
+
 	return sink
 }
 
+// codes returns the escape sequences of the given colour
+func (d DevelopmentLogSink) codes(c *color.Color) colourCodes {
+	if codes, ok := d.colours[c]; ok {
+		return codes
+	}
+	return newColourCodes(c)
+}
+
 // Log implements LogSink, encoding the given Entry as human-readable text before writing it to the configured io.Writer
 func (d DevelopmentLogSink) Log(e Entry) error {
-	buffer := bytes.Buffer{}
+	buffer := getBuffer()
+	defer putBuffer(buffer)
 
-	severity := d.options.SeverityEncoder(e.Level, e.Error)
+	severity := e.severity(d.options.SeverityEncoder)
 	severityColour := d.options.SeverityColours[severity]
 	if severityColour == nil {
 		severityColour = d.options.PrimaryColour
 	}
+	severityCodes := d.codes(severityColour)
+	primary := d.codes(d.options.PrimaryColour)
+	secondary := d.codes(d.options.SecondaryColour)
 
-	if _, err := d.options.SecondaryColour.Fprint(&buffer, d.options.TimestampEncoder(e.Timestamp)); err != nil {
+	var encodedErr EncodedError
+	if e.Error != nil {
+		encodedErr = e.encodeError(d.options.ErrorEncoder)
+		encodedErr.Message, _ = truncateString(encodedErr.Message, d.options.MaxValueLength)
+	}
+	e.Message, _ = truncateString(e.Message, d.options.MaxMessageLength)
+	e.KVs = flattenGroups(e.KVs)
+
+	var err error
+	if d.options.Template != nil {
+		*buffer, err = d.appendTemplate(*buffer, e, severity, encodedErr, severityCodes)
+	} else {
+		b := *buffer
+		b = append(b, secondary.start...)
+		b = d.appendTimestamp(b, e.Timestamp)
+		b = append(b, secondary.end...)
+
+		b = append(b, severityCodes.start...)
+		b = append(b, d.options.SpaceSeparator...)
+		b = append(b, severity...)
+		b = append(b, severityCodes.end...)
+
+		if len(e.Names) > 0 {
+			b = append(b, primary.start...)
+			b = append(b, d.options.SpaceSeparator...)
+			b = append(b, d.options.NameEncoder(e.Names)...)
+			b = append(b, primary.end...)
+		}
+
+		b = append(b, primary.start...)
+		b = append(b, d.options.SpaceSeparator...)
+		b = append(b, e.Message...)
+		b = append(b, primary.end...)
+
+		*buffer, err = d.appendAllKVs(b, e, encodedErr, severityCodes, d.options.SpaceSeparator)
+	}
+	if err != nil {
 		return err
 	}
 
-	if _, err := severityColour.Fprintf(&buffer, "%s%s", d.options.SpaceSeparator, severity); err != nil {
+	if encodedErr.structured() {
+		*buffer = append(*buffer, primary.start...)
+		*buffer = appendErrorText(*buffer, encodedErr, "  ", "")
+		*buffer = append(*buffer, primary.end...)
+	} else if encodedErr.StackTrace != "" {
+		*buffer = primary.appendString(*buffer, encodedErr.StackTrace)
+	}
+
+	if d.resetColours {
+		*buffer = append(*buffer, colourReset...)
+	}
+	*buffer = append(*buffer, d.options.EntrySuffix...)
+	if _, err := d.options.Output.Write(*buffer); err != nil {
 		return err
 	}
 
-	if len(e.Names) > 0 {
-		if _, err := d.options.PrimaryColour.Fprintf(&buffer, "%s%s", d.options.SpaceSeparator, d.options.NameEncoder(e.Names)); err != nil {
-			return err
+	return nil
+}
+
+// appendTimestamp appends the timestamp according to the TimestampMode, TimestampLocation and TimestampFormat or
+// TimestampEncoder, formatting epoch timestamps in full rather than in exponent form
+func (d DevelopmentLogSink) appendTimestamp(buf []byte, t time.Time) []byte {
+	epoch, _ := d.options.TimestampMode.epoch(t)
+	switch value := epoch.(type) {
+	case float64:
+		return strconv.AppendFloat(buf, value, 'f', -1, 64)
+	case int64:
+		return strconv.AppendInt(buf, value, 10)
+	}
+
+	if d.options.TimestampFormat == "" {
+		return append(buf, formatTimestamp(t, d.options.TimestampLocation, d.options.TimestampEncoder)...)
+	}
+	if d.options.TimestampLocation != nil {
+		t = t.In(d.options.TimestampLocation)
+	}
+	return t.AppendFormat(buf, d.options.TimestampFormat)
+}
+
+// appendTemplate appends the components of the Entry arranged according to the Template. Literal text is held back
+// until the next non-empty component, so that whitespace around empty components can be collapsed.
+func (d DevelopmentLogSink) appendTemplate(buf []byte, e Entry, severity string, encodedErr EncodedError, severityCodes colourCodes) ([]byte, error) {
+	pending := ""
+	collapse := false
+
+	for _, part := range d.options.Template.parts {
+		var err error
+		switch part.field {
+		case templateLiteral:
+			if collapse && strings.TrimLeft(part.literal, " \t") != part.literal {
+				pending = strings.TrimRight(pending, " \t")
+			}
+			collapse = false
+			pending += part.literal
+			continue
+		case templateName:
+			if len(e.Names) == 0 {
+				collapse = true
+				continue
+			}
+		case templateMessage:
+			if e.Message == "" {
+				collapse = true
+				continue
+			}
+		case templateKVs:
+			if e.Error == nil && len(e.KVs) == 0 {
+				collapse = true
+				continue
+			}
+			if d.options.Layout == DevelopmentLayoutMultiLine {
+				// the key-value pairs start on a new line, so would otherwise leave trailing whitespace behind
+				pending = strings.TrimRight(pending, " \t")
+			}
+		case templateCaller:
+			if e.Caller == nil {
+				collapse = true
+				continue
+			}
+		}
+
+		buf = append(buf, pending...)
+		pending = ""
+		collapse = false
+
+		switch part.field {
+		case templateTimestamp:
+			secondary := d.codes(d.options.SecondaryColour)
+			buf = append(buf, secondary.start...)
+			buf = d.appendTimestamp(buf, e.Timestamp)
+			buf = append(buf, secondary.end...)
+		case templateSeverity:
+			buf = severityCodes.appendString(buf, severity)
+		case templateName:
+			buf = d.codes(d.options.PrimaryColour).appendString(buf, d.options.NameEncoder(e.Names))
+		case templateMessage:
+			buf = d.codes(d.options.PrimaryColour).appendString(buf, e.Message)
+		case templateKVs:
+			buf, err = d.appendAllKVs(buf, e, encodedErr, severityCodes, "")
+		case templateCaller:
+			buf = d.codes(d.options.SecondaryColour).appendString(buf, DefaultCallerEncoder(*e.Caller).(string))
+		}
+		if err != nil {
+			return buf, err
 		}
 	}
 
-	if _, err := d.options.PrimaryColour.Fprintf(&buffer, "%s%s", d.options.SpaceSeparator, e.Message); err != nil {
-		return err
+	if collapse {
+		pending = strings.TrimRight(pending, " \t")
 	}
+	buf = append(buf, pending...)
 
-	var encodedErr EncodedError
+	return buf, nil
+}
+
+// appendAllKVs appends the error message and key-value pairs of the Entry according to the Layout, with the given
+// separator before the first of them when written on the same line as the message
+func (d DevelopmentLogSink) appendAllKVs(buf []byte, e Entry, encodedErr EncodedError, severityCodes colourCodes, leading string) ([]byte, error) {
+	if d.options.Layout == DevelopmentLayoutMultiLine {
+		return d.appendKVLines(buf, e, encodedErr, severityCodes)
+	}
+	return d.appendKVs(buf, e, encodedErr, severityCodes, leading)
+}
+
+// appendKVs appends the error message and key-value pairs of the Entry on the same line as the message, separated by
+// SpaceSeparator, with the given separator before the first of them
+func (d DevelopmentLogSink) appendKVs(buf []byte, e Entry, encodedErr EncodedError, severityCodes colourCodes, leading string) ([]byte, error) {
+	separator := leading
 	if e.Error != nil {
-		encodedErr = d.options.ErrorEncoder(e.Error)
-		if _, err := severityColour.Fprintf(&buffer, "%s%s=%q", d.options.SpaceSeparator, d.options.ErrorKey, encodedErr.Message); err != nil {
-			return err
-		}
+		buf = append(buf, severityCodes.start...)
+		buf = append(buf, separator...)
+		buf = append(buf, d.options.ErrorKey...)
+		buf = append(buf, '=')
+		buf = strconv.AppendQuote(buf, encodedErr.Message)
+		buf = append(buf, severityCodes.end...)
+		separator = d.options.SpaceSeparator
 	}
 
 	for i := 0; i < len(e.KVs); i += 2 {
@@ -90,43 +334,177 @@ func (d DevelopmentLogSink) Log(e Entry) error {
 
 		kStr, ok := k.(string)
 		if !ok {
-			return errors.Errorf("logging keys must be strings, got %T: %v", k, k)
+			return buf, errors.Errorf("logging keys must be strings, got %T: %v", k, k)
 		}
 
-		if _, err := d.options.SecondaryColour.Fprintf(&buffer, "%s%s=", d.options.SpaceSeparator, kStr); err != nil {
-			return err
+		v = d.value(v)
+		keyColour, valueColour := d.kvColours(kStr, v)
+		keyCodes := d.codes(keyColour)
+		buf = append(buf, keyCodes.start...)
+		buf = append(buf, separator...)
+		buf = append(buf, kStr...)
+		buf = append(buf, '=')
+		buf = append(buf, keyCodes.end...)
+		separator = d.options.SpaceSeparator
+
+		valueCodes := d.codes(valueColour)
+		buf = append(buf, valueCodes.start...)
+		var err error
+		buf, _, err = d.appendValue(buf, v)
+		if err = e.reportPlaceholders(err); err != nil {
+			return buf, err
 		}
+		buf = append(buf, valueCodes.end...)
+	}
 
-		b, err := json.Marshal(v)
-		if err != nil {
-			return err
+	return buf, nil
+}
+
+// value resolves the value of a key-value pair, encoding []byte, time.Time and time.Duration values according to the
+// options
+func (d DevelopmentLogSink) value(v interface{}) interface{} {
+	v = resolveValue(v)
+	switch value := v.(type) {
+	case time.Time:
+		if d.options.FormatTimeValues {
+			if epoch, ok := d.options.TimestampMode.epoch(value); ok {
+				return epoch
+			}
+			if d.options.TimestampFormat != "" {
+				if d.options.TimestampLocation != nil {
+					value = value.In(d.options.TimestampLocation)
+				}
+				return value.Format(d.options.TimestampFormat)
+			}
+			return formatTimestamp(value, d.options.TimestampLocation, d.options.TimestampEncoder)
+		}
+	case time.Duration:
+		if d.options.DurationEncoder != nil {
+			return d.options.DurationEncoder(value)
 		}
+	}
+	return encodeBytes(v, d.options.BytesEncoder)
+}
+
+// appendValue appends the JSON encoding of the value, truncated to MaxValueLength. Strings are truncated before being
+// encoded, while other values have their encoding truncated. Values replaced by placeholders are appended as the
+// placeholder itself, e.g. <panic: ...>, rather than as an escaped string
+func (d DevelopmentLogSink) appendValue(buf []byte, v interface{}) ([]byte, bool, error) {
+	if s, ok := v.(string); ok {
+		s, truncated := truncateString(s, d.options.MaxValueLength)
+		return defaultJSONEncoder.appendString(buf, s), truncated, nil
+	}
+
+	start := len(buf)
+	buf, err := defaultJSONEncoder.appendValue(buf, v)
+	if placeholder, ok := err.(*placeholderValue); ok {
+		return append(buf[:start], placeholder.placeholder...), false, err
+	}
+	if err != nil {
+		return buf, false, err
+	}
+	buf, truncated := truncateEncoded(buf, start, d.options.MaxValueLength)
+	return buf, truncated, nil
+}
 
-		if _, err := d.options.PrimaryColour.Fprintf(&buffer, "%s", b); err != nil {
-			return err
+// appendKVLines appends the error message and key-value pairs of the Entry on lines of their own following the
+// message, with their keys padded so that the values line up, and with maps, slices and structs indented
+func (d DevelopmentLogSink) appendKVLines(buf []byte, e Entry, encodedErr EncodedError, severityCodes colourCodes) ([]byte, error) {
+	width := 0
+	if e.Error != nil {
+		width = len(d.options.ErrorKey)
+	}
+	for i := 0; i < len(e.KVs); i += 2 {
+		k, ok := e.KVs[i].(string)
+		if !ok {
+			return buf, errors.Errorf("logging keys must be strings, got %T: %v", e.KVs[i], e.KVs[i])
 		}
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+
+	// continuation lines of indented values start in the same column as the value itself
+	continuation := d.options.Indent + strings.Repeat(" ", width+len(" = "))
+
+	if e.Error != nil {
+		buf = append(buf, severityCodes.start...)
+		buf = d.appendKeyLine(buf, d.options.ErrorKey, width)
+		buf = strconv.AppendQuote(buf, encodedErr.Message)
+		buf = append(buf, severityCodes.end...)
 	}
 
-	if encodedErr.StackTrace != "" {
-		if _, err := d.options.PrimaryColour.Fprintf(&buffer, "%s", encodedErr.StackTrace); err != nil {
-			return err
+	for i := 0; i < len(e.KVs); i += 2 {
+		k := e.KVs[i].(string)
+		v := d.value(e.KVs[i+1])
+		keyColour, valueColour := d.kvColours(k, v)
+		keyCodes := d.codes(keyColour)
+		buf = append(buf, keyCodes.start...)
+		buf = d.appendKeyLine(buf, k, width)
+		buf = append(buf, keyCodes.end...)
+
+		value := getBuffer()
+		b, truncated, err := d.appendValue(*value, v)
+		*value = b
+		if err = e.reportPlaceholders(err); err == nil && !truncated && len(b) > 2 && (b[0] == '{' || b[0] == '[') {
+			indented := getBuffer()
+			indentedBuffer := bytes.NewBuffer(*indented)
+			if err = json.Indent(indentedBuffer, b, continuation, "  "); err == nil {
+				buf = d.codes(valueColour).appendBytes(buf, indentedBuffer.Bytes())
+			}
+			*indented = indentedBuffer.Bytes()
+			putBuffer(indented)
+		} else if err == nil {
+			buf = d.codes(valueColour).appendBytes(buf, b)
+		}
+		putBuffer(value)
+		if err != nil {
+			return buf, err
 		}
 	}
 
-	if _, err := fmt.Fprintf(d.options.Output, "%s%s", buffer.String(), d.options.EntrySuffix); err != nil {
-		return err
+	return buf, nil
+}
+
+// appendKeyLine starts a new line for a key-value pair, with the key padded to the given width (in runes, as %-*s
+// would) followed by " = "
+func (d DevelopmentLogSink) appendKeyLine(buf []byte, key string, width int) []byte {
+	buf = append(buf, '\n')
+	buf = append(buf, d.options.Indent...)
+	buf = append(buf, key...)
+	for n := utf8.RuneCountInString(key); n < width; n++ {
+		buf = append(buf, ' ')
 	}
+	return append(buf, " = "...)
+}
 
-	return nil
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (d DevelopmentLogSink) Flush() error {
+	return flushWriter(d.options.Output)
 }
 
 var _ LogSink = (*DevelopmentLogSink)(nil)
+var _ FlushSink = (*DevelopmentLogSink)(nil)
+
+// DevelopmentLayout determines how a DevelopmentLogSink arranges the parts of each entry
+type DevelopmentLayout int
+
+const (
+	// DevelopmentLayoutSingleLine writes each entry on a single line, with the key-value pairs following the message
+	DevelopmentLayoutSingleLine DevelopmentLayout = iota
+	// DevelopmentLayoutMultiLine writes the timestamp, severity, name and message on the first line, followed by each
+	// key-value pair on a line of its own, indented, with the values aligned in a column. Maps, slices and structs are
+	// pretty-printed as indented JSON.
+	DevelopmentLayoutMultiLine
+)
 
 // ColourMode controls whether the DevelopmentLogSink emits coloured output or not
 type ColourMode int
 
 const (
-	// ColourModeAuto will use the color package's built-in auto-detection to guess whether to use coloured output
+	// ColourModeAuto disables coloured output if the NO_COLOR environment variable is set, or enables it if
+	// FORCE_COLOR is set, and otherwise uses the color package's built-in auto-detection to guess whether to use
+	// coloured output
 	ColourModeAuto ColourMode = iota
 	// ColourModeForceOff forces coloured output to be disabled, use this if you're seeing garbled escape characters
 	// in the output
@@ -138,12 +516,17 @@ const (
 
 // DevelopmentLogSinkOptions configures the behaviour of a DevelopmentLogSink
 type DevelopmentLogSinkOptions struct {
-	// Output configures where to write logs to
+	// Output configures where to write logs to. If it is an *os.File (e.g. os.Stderr) it is wrapped so that colour
+	// escape sequences are translated on Windows legacy consoles, or stripped when colours are disabled
 	Output io.Writer
 	// ColouredOutput determines whether coloured output will be used, if unspecified it will attempt to auto-detect
 	// from the environment. This is usually confused by integrated terminals in IDEs, so for coloured output in IDEs
 	// you may wish to use ColourModeForceOn
 	ColouredOutput ColourMode
+	// Theme names one of the ColourThemes (e.g. "light" for terminals with light backgrounds) providing the colours
+	// that are not otherwise specified. If unspecified or unknown, DefaultSeverityColours, DefaultPrimaryColour and
+	// DefaultSecondaryColour are used
+	Theme string
 	// SeverityColours maps severity names (produced by SeverityEncoder) to colours, used when displaying severity names
 	// and when Entry objects contain an Entry.Error
 	SeverityColours map[string]*color.Color
@@ -151,22 +534,61 @@ type DevelopmentLogSinkOptions struct {
 	PrimaryColour *color.Color
 	// SecondaryColour is the colour of timestamps, and the keys of key-value pairs
 	SecondaryColour *color.Color
+	// KeyColours highlight key-value pairs with particular keys, optionally only for particular values, in place of
+	// PrimaryColour and SecondaryColour. The first matching rule applies
+	KeyColours []KeyColour
 	// SeverityEncoder identifies the severity name based on the verbosity level and the presence of any errors
 	SeverityEncoder func(level int, err error) string
 	// NameEncoder collapses the series of Logger names down into one string for logging
 	NameEncoder func(names []string) string
-	// TimestampEncoder formats timestamps into string representations
+	// TimestampEncoder formats timestamps into string representations, e.g. ElapsedTimestampEncoder to show the time
+	// since the process started, or DeltaTimestampEncoder to show the time since the previous entry
 	TimestampEncoder func(t time.Time) string
+	// TimestampFormat, if specified, is the layout timestamps are formatted with (see time.Time.Format) instead of
+	// using the TimestampEncoder, formatting them straight into the buffer to avoid an allocation per entry
+	TimestampFormat string
+	// TimestampMode determines whether timestamps are formatted using the TimestampEncoder, or are numbers relative
+	// to the Unix epoch
+	TimestampMode TimestampMode
+	// TimestampLocation, if specified, is the time zone timestamps are converted to before being formatted, otherwise
+	// they are formatted as logged, which is in UTC for entries logged by a Logger
+	TimestampLocation *time.Location
 	// ErrorKey determines the key prefix on any error messages, displayed as though "just another key-value pair",
 	// but (if colours are enabled) printed using the relevant colour (see SeverityColours)
 	ErrorKey string
-	// ErrorEncoder  extracts loggable EncodedError information from an error
+	// ErrorEncoder  extracts loggable EncodedError information from an error, e.g. StructuredErrorEncoder to show the
+	// causes of errors and their stack traces frame by frame
 	ErrorEncoder func(err error) EncodedError
 	// EntrySuffix is appended to the end of log entries, typically to add a newline between them
 	EntrySuffix string
 	// SpaceSeparator is placed between all log elements: timestamp, severity, logger name, message, and key-value pairs
 	// It can be useful, for example, to change this to "\t" to increase spacing - which may improve readability
 	SpaceSeparator string
+	// Layout determines whether entries are written on a single line, or with their key-value pairs on lines of their
+	// own, which can be easier to read when entries have many or large values
+	Layout DevelopmentLayout
+	// Indent is placed before each key-value pair when using DevelopmentLayoutMultiLine
+	Indent string
+	// BytesEncoder converts []byte values into strings, e.g. HexBytesEncoder or PrintableBytesEncoder. It applies only
+	// to the values of key-value pairs, not to []byte fields of maps, slices or structs, which are encoded as usual
+	BytesEncoder func(b []byte) string
+	// FormatTimeValues formats time.Time values of key-value pairs in the same way as the entry's timestamp, according
+	// to the TimestampMode, TimestampLocation and TimestampFormat or TimestampEncoder, rather than as RFC 3339. It
+	// should not be combined with stateful encoders such as DeltaTimestampEncoder, which would treat each value as
+	// another entry
+	FormatTimeValues bool
+	// DurationEncoder, if specified, converts time.Duration values of key-value pairs into values for logging, e.g.
+	// StringDurationEncoder or UnitDurationEncoder(time.Millisecond), rather than integer numbers of nanoseconds
+	DurationEncoder func(d time.Duration) interface{}
+	// MaxValueLength, if positive, limits the length in bytes of key-value pair values and error messages, which are
+	// truncated and followed by DefaultTruncationSuffix
+	MaxValueLength int
+	// MaxMessageLength, if positive, limits the length in bytes of messages in the same way as MaxValueLength
+	MaxMessageLength int
+	// Template, if specified, arranges the timestamp, severity, name, message and key-value pairs of each entry in
+	// place of the default layout, and SpaceSeparator is then only used between key-value pairs. See
+	// ParseDevelopmentTemplate
+	Template *DevelopmentTemplate
 }
 
 // AssertDefaults replaces all uninitialised options with reasonable defaults
@@ -175,21 +597,31 @@ func (d *DevelopmentLogSinkOptions) AssertDefaults() {
 		d.Output = colorable.NewColorableStdout()
 	}
 
+	theme, ok := ColourThemes[d.Theme]
+	if !ok {
+		theme = ColourTheme{
+			PrimaryColour:   DefaultPrimaryColour,
+			SecondaryColour: DefaultSecondaryColour,
+			SeverityColours: DefaultSeverityColours,
+		}
+	}
+
+	// colours are copied, as the sink enables or disables them according to its ColouredOutput
 	if d.SeverityColours == nil {
 		d.SeverityColours = map[string]*color.Color{}
-		for severity, colour := range DefaultSeverityColours {
+		for severity, colour := range theme.SeverityColours {
 			colourCopy := *colour
 			d.SeverityColours[severity] = &colourCopy
 		}
 	}
 
 	if d.PrimaryColour == nil {
-		colourCopy := *DefaultPrimaryColour
+		colourCopy := *theme.PrimaryColour
 		d.PrimaryColour = &colourCopy
 	}
 
 	if d.SecondaryColour == nil {
-		colourCopy := *DefaultSecondaryColour
+		colourCopy := *theme.SecondaryColour
 		d.SecondaryColour = &colourCopy
 	}
 
@@ -213,6 +645,10 @@ func (d *DevelopmentLogSinkOptions) AssertDefaults() {
 		d.ErrorEncoder = DefaultErrorEncoder
 	}
 
+	if d.BytesEncoder == nil {
+		d.BytesEncoder = Base64BytesEncoder
+	}
+
 	if d.EntrySuffix == "" {
 		d.EntrySuffix = DefaultEntrySuffix
 	}
@@ -220,4 +656,8 @@ func (d *DevelopmentLogSinkOptions) AssertDefaults() {
 	if d.SpaceSeparator == "" {
 		d.SpaceSeparator = DefaultSpaceSeparator
 	}
+
+	if d.Indent == "" {
+		d.Indent = DefaultDevelopmentIndent
+	}
 }
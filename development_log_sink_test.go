@@ -0,0 +1,30 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDevelopmentPlaceholders(t *testing.T) {
+	assertPlaceholders(t, func(buffer *bytes.Buffer) LogSink {
+		opts := DevelopmentLogSinkOptions{Output: buffer}
+		opts.AssertDefaults()
+		return NewDevelopmentLogSink(opts)
+	}, `message`, `error="<panic: broken error>"`, `value=<panic: broken value>`, `other=1`)
+}
+
+func BenchmarkDevelopmentLogSink(b *testing.B) {
+	opts := DevelopmentLogSinkOptions{Output: ioutil.Discard, ColouredOutput: ColourModeForceOff}
+	opts.AssertDefaults()
+	benchmarkSink(b, NewDevelopmentLogSink(opts))
+}
+
+func BenchmarkDevelopmentLogSinkColour(b *testing.B) {
+	opts := DevelopmentLogSinkOptions{Output: ioutil.Discard, ColouredOutput: ColourModeForceOn}
+	opts.AssertDefaults()
+	benchmarkSink(b, NewDevelopmentLogSink(opts))
+}
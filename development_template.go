@@ -0,0 +1,98 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// templateField identifies a component of an entry, as referenced by a DevelopmentTemplate
+type templateField int
+
+const (
+	templateLiteral templateField = iota
+	templateTimestamp
+	templateSeverity
+	templateName
+	templateMessage
+	templateKVs
+	templateCaller
+)
+
+// templateFields maps the names used in templates to the components they reference
+var templateFields = map[string]templateField{
+	"ts":     templateTimestamp,
+	"sev":    templateSeverity,
+	"name":   templateName,
+	"msg":    templateMessage,
+	"kvs":    templateKVs,
+	"caller": templateCaller,
+}
+
+// templatePart is either literal text, or a component of the entry
+type templatePart struct {
+	field   templateField
+	literal string
+}
+
+// DevelopmentTemplate describes the layout of entries written by a DevelopmentLogSink, see ParseDevelopmentTemplate
+type DevelopmentTemplate struct {
+	parts []templatePart
+}
+
+// ParseDevelopmentTemplate parses a pattern describing the layout of entries written by a DevelopmentLogSink, in
+// which components of the entry are referenced by name between percent signs, e.g.
+// "%ts% [%sev%] %name% — %msg% %kvs%".
+// The components are:
+//
+//   - %ts% - the timestamp
+//   - %sev% - the severity name
+//   - %name% - the logger name
+//   - %msg% - the message
+//   - %kvs% - the error (if any) and key-value pairs, according to the sink's Layout
+//   - %caller% - the caller, if captured (see Options.CaptureCaller)
+//
+// A literal percent sign is written "%%". Components may be reordered, repeated or omitted. When a component is empty
+// (e.g. an entry without a name) the whitespace around it collapses, so that the entry is not left with gaps.
+func ParseDevelopmentTemplate(pattern string) (*DevelopmentTemplate, error) {
+	template := &DevelopmentTemplate{}
+	literal := strings.Builder{}
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			literal.WriteByte(pattern[i])
+			continue
+		}
+
+		if i+1 < len(pattern) && pattern[i+1] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(pattern[i+1:], '%')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated component at offset %d of template %q", i, pattern)
+		}
+		name := pattern[i+1 : i+1+end]
+		field, ok := templateFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown component %q in template %q", name, pattern)
+		}
+
+		if literal.Len() > 0 {
+			template.parts = append(template.parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+		template.parts = append(template.parts, templatePart{field: field})
+		i += end + 1
+	}
+
+	if literal.Len() > 0 {
+		template.parts = append(template.parts, templatePart{literal: literal.String()})
+	}
+
+	return template, nil
+}
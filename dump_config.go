@@ -0,0 +1,244 @@
+package simplelogr
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultConfigMessage is the message of the entry emitted by DumpConfig
+	DefaultConfigMessage = "logging configuration"
+	// DefaultConfigKey is the key of the configuration described by DumpConfig
+	DefaultConfigKey = "config"
+	// DefaultSecretNames are the case-insensitive substrings identifying option fields, map keys and key-value pairs
+	// whose values DumpConfig masks
+	DefaultSecretNames = []string{"password", "secret", "token", "dsn", "credential", "authorization", "apikey", "api_key"}
+	// DefaultSecretMask replaces the values masked by DumpConfig
+	DefaultSecretMask = "********"
+)
+
+var (
+	processorType = reflect.TypeOf(Processor(nil))
+	durationType  = reflect.TypeOf(time.Duration(0))
+)
+
+// DumpConfig emits a single entry using the given logr.Logger, if it is backed by a Logger, describing its fully
+// resolved configuration so that support engineers can verify how a running instance is logging. The description,
+// under DefaultConfigKey, includes the logger's options and verbosity (including any overrides and boosts in effect
+// on its VerbosityController), and the chain of sinks behind it (see WrapperSink), each with its type, options and
+// processors. Processors and other functions are identified by name. Values whose names contain any of
+// DefaultSecretNames, such as the Token of a SplunkHECLogSink, are masked.
+func DumpConfig(logger logr.Logger) {
+	l, ok := logger.GetSink().(*Logger)
+	if !ok {
+		return
+	}
+
+	logger.Info(DefaultConfigMessage, DefaultConfigKey, l.describeConfig())
+}
+
+// describeConfig describes the options of the Logger and the chain of sinks behind it
+func (l Logger) describeConfig() map[string]interface{} {
+	config := map[string]interface{}{
+		"Verbosity":         l.options.Verbosity,
+		"CaptureCaller":     l.options.CaptureCaller,
+		"CaptureStackTrace": l.options.CaptureStackTrace,
+		"NameMode":          int(l.options.NameMode),
+		"MaxNameDepth":      l.options.MaxNameDepth,
+		"ErrorHandler":      describeValue(reflect.ValueOf(l.options.ErrorHandler)),
+		"ContextHooks":      describeValue(reflect.ValueOf(l.options.ContextHooks)),
+		"Names":             l.names,
+		"Values":            maskValues(l.values),
+		"Sink":              describeSink(l.options.Sink),
+	}
+	if l.options.Controller != nil {
+		config["Verbosity"] = l.options.Controller.Verbosity()
+		config["Controller"] = l.options.Controller.describe()
+	}
+	return config
+}
+
+// describe describes the verbosity overrides and the boosts currently in effect
+func (c *VerbosityController) describe() map[string]interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	names := make(map[string]interface{}, len(c.names))
+	for prefix, verbosity := range c.names {
+		names[prefix] = verbosity
+	}
+
+	now := c.now()
+	boosts := make([]interface{}, 0, len(c.boosts))
+	for _, b := range c.boosts {
+		if !now.Before(b.expires) {
+			continue
+		}
+		boost := map[string]interface{}{
+			"Verbosity": b.level,
+			"Expires":   b.expires.UTC().Format(time.RFC3339),
+		}
+		if b.byValue {
+			boost["Key"] = b.key
+			boost["Value"] = maskValue(b.key, b.value)
+		} else {
+			boost["Prefix"] = b.prefix
+		}
+		boosts = append(boosts, boost)
+	}
+
+	return map[string]interface{}{
+		"NameVerbosities": names,
+		"Boosts":          boosts,
+	}
+}
+
+// describeSink describes the type of the sink, its options and processors, and the sinks it wraps
+func describeSink(sink LogSink) map[string]interface{} {
+	description := map[string]interface{}{
+		"Type": reflect.TypeOf(sink).String(),
+	}
+
+	v := reflect.ValueOf(sink)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		if options := v.FieldByName("options"); options.IsValid() {
+			description["Options"] = describeValue(options)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Type() == processorType || (field.Kind() == reflect.Slice && field.Type().Elem() == processorType) {
+				description["Processors"] = describeValue(field)
+			}
+		}
+	}
+
+	if wrapper, ok := sink.(WrapperSink); ok {
+		var wrapped []interface{}
+		for _, s := range wrapper.Unwrap() {
+			wrapped = append(wrapped, describeSink(s))
+		}
+		description["Sinks"] = wrapped
+	}
+
+	return description
+}
+
+// describeValue converts a value into a form suitable for logging, made up of basic types, slices and maps. Values
+// reached through unexported fields can be read but not converted back into interfaces, so are rebuilt by kind.
+// Functions are identified by name, and other references by type, as they cannot be described without side effects.
+func describeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			return time.Duration(v.Int()).String()
+		}
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = describeValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		values := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, ok := describeValue(iter.Key()).(string)
+			if !ok {
+				key = iter.Key().Type().String()
+			}
+			values[key] = maskDescription(key, describeValue(iter.Value()))
+		}
+		return values
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			fields[field.Name] = maskDescription(field.Name, describeValue(v.Field(i)))
+		}
+		return fields
+	case reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			return fn.Name()
+		}
+		return v.Type().String()
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return describeValue(v.Elem())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Type().String()
+	default:
+		return v.Type().String()
+	}
+}
+
+// isSecretName reports whether the name contains any of DefaultSecretNames
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range DefaultSecretNames {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskDescription replaces the description of a value with DefaultSecretMask if its name is secret, unless it is
+// empty, in which case it is left so that it is clear no secret is configured
+func maskDescription(name string, description interface{}) interface{} {
+	if description == nil || description == "" || !isSecretName(name) {
+		return description
+	}
+	return DefaultSecretMask
+}
+
+// maskValue describes a value associated with a key, masking it if the key is secret
+func maskValue(key string, value interface{}) interface{} {
+	return maskDescription(key, describeValue(reflect.ValueOf(value)))
+}
+
+// maskValues describes a sequence of key-value pairs, masking the values of secret keys
+func maskValues(kvs []interface{}) []interface{} {
+	masked := make([]interface{}, 0, len(kvs))
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, _ := kvs[i].(string)
+		masked = append(masked, kvs[i], maskValue(key, kvs[i+1]))
+	}
+	return masked
+}
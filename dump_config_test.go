@@ -0,0 +1,37 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestDumpConfigNilValue(t *testing.T) {
+	buffer := bytes.Buffer{}
+	opts := JSONLogSinkOptions{Output: &buffer}
+	opts.AssertDefaults()
+	logger := logr.New(New(Options{Sink: NewJSONLogSink(opts)})).WithValues("user", nil, "token", nil)
+
+	DumpConfig(logger)
+
+	var entry struct {
+		Config struct {
+			Values []interface{} `json:"Values"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode %q: %v", buffer.String(), err)
+	}
+
+	expected := []interface{}{"user", nil, "token", nil}
+	if len(entry.Config.Values) != len(expected) {
+		t.Fatalf("expected values %v, got %v", expected, entry.Config.Values)
+	}
+	for i := range expected {
+		if entry.Config.Values[i] != expected[i] {
+			t.Errorf("expected values %v, got %v", expected, entry.Config.Values)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package simplelogr
+
+import (
+	"time"
+)
+
+// StringDurationEncoder encodes time.Duration values as human-readable strings, e.g. "1m30.5s", for use as a sink's
+// DurationEncoder
+func StringDurationEncoder(d time.Duration) interface{} {
+	return d.String()
+}
+
+// UnitDurationEncoder creates an encoder for a sink's DurationEncoder that encodes time.Duration values as numbers of
+// the given unit, e.g. time.Millisecond or time.Second, with fractions of a unit kept as decimals
+func UnitDurationEncoder(unit time.Duration) func(d time.Duration) interface{} {
+	return func(d time.Duration) interface{} {
+		if d%unit == 0 {
+			return int64(d / unit)
+		}
+		return float64(d) / float64(unit)
+	}
+}
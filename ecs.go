@@ -0,0 +1,35 @@
+package simplelogr
+
+var (
+	// ECSVersion is the version of the Elastic Common Schema that ECSJSONLogSinkOptions conforms to
+	ECSVersion = "1.12.0"
+	// ECSTimestampFormat is the timestamp format used by ECSJSONLogSinkOptions, with millisecond precision
+	ECSTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+	// ECSSeverityEncoder produces the lowercase severity names conventionally used in the ECS log.level field
+	ECSSeverityEncoder = DefaultSeverityEncoder("info", "error", []SeverityThreshold{
+		{Level: DefaultTraceVerbosity, Severity: "trace"},
+		{Level: DefaultDebugVerbosity, Severity: "debug"},
+		{Level: int(LevelWarn), Severity: "warn"},
+	})
+)
+
+// ECSJSONLogSinkOptions produces JSONLogSinkOptions emitting fields that follow the conventions of the Elastic Common
+// Schema (ECS), so that the output can be ingested by Elasticsearch without rewriting keys: @timestamp, log.level,
+// log.logger, message, error.message, error.stack_trace, and key-value pairs nested under labels. The remaining
+// options (e.g. Output) can be customised before calling AssertDefaults as usual.
+func ECSJSONLogSinkOptions() JSONLogSinkOptions {
+	return JSONLogSinkOptions{
+		TimestampKey:     "@timestamp",
+		TimestampEncoder: DefaultTimestampEncoder(ECSTimestampFormat),
+		SeverityKey:      "log.level",
+		SeverityEncoder:  ECSSeverityEncoder,
+		NameKey:          "log.logger",
+		MessageKey:       "message",
+		ErrorKey:         "error.message",
+		StackTraceKey:    "error.stack_trace",
+		KVsKey:           "labels",
+		StaticFields: map[string]interface{}{
+			"ecs.version": ECSVersion,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package simplelogr
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// DefaultEncoderArenaBuffers is the number of buffers in an EncoderArena when not specified
+	DefaultEncoderArenaBuffers = 4 * runtime.GOMAXPROCS(0)
+	// DefaultEncoderArenaBufferSize is the capacity of each buffer in an EncoderArena when not specified
+	DefaultEncoderArenaBufferSize = 16 * 1024
+)
+
+// EncoderArena is an experimental alternative to the shared buffer pool used by the JSONLogSink to encode entries,
+// for services logging at very high rates. Its buffers are carved out of a single slab allocated up front, and are
+// reused indefinitely: unlike a sync.Pool, which is emptied by the garbage collector and must then allocate new
+// buffers, an arena reaches zero steady-state allocations for encoding regardless of GC activity. Each buffer is
+// append-only while an entry is encoded, and is reset once the entry has been written. If every buffer is in use, or
+// an entry does not fit in a buffer, a temporary buffer is allocated instead, see Misses.
+//
+// The runtime's own arena experiment is not used, as it requires GOEXPERIMENT=arenas and is not covered by the Go
+// compatibility promise. An EncoderArena is safe for concurrent use, and may be shared between sinks.
+type EncoderArena struct {
+	options EncoderArenaOptions
+	misses  uint64
+
+	lock sync.Mutex
+	free []*[]byte
+}
+
+// NewEncoderArena allocates a new EncoderArena with the provided options
+func NewEncoderArena(opts EncoderArenaOptions) *EncoderArena {
+	slab := make([]byte, opts.Buffers*opts.BufferSize)
+	buffers := make([][]byte, opts.Buffers)
+	free := make([]*[]byte, opts.Buffers)
+	for i := range buffers {
+		// the capacity is limited to the buffer's own region of the slab, so that appending beyond it reallocates
+		// rather than overwriting its neighbour
+		buffers[i] = slab[i*opts.BufferSize : i*opts.BufferSize : (i+1)*opts.BufferSize]
+		free[i] = &buffers[i]
+	}
+
+	return &EncoderArena{
+		options: opts,
+		free:    free,
+	}
+}
+
+// Misses returns the number of times a temporary buffer had to be allocated because every buffer was in use or an
+// entry did not fit, which suggests that Buffers or BufferSize should be increased
+func (a *EncoderArena) Misses() uint64 {
+	return atomic.LoadUint64(&a.misses)
+}
+
+// get retrieves an empty buffer from the arena, which should be returned using put once finished with
+func (a *EncoderArena) get() *[]byte {
+	a.lock.Lock()
+	if n := len(a.free); n > 0 {
+		b := a.free[n-1]
+		a.free = a.free[:n-1]
+		a.lock.Unlock()
+		*b = (*b)[:0]
+		return b
+	}
+	a.lock.Unlock()
+
+	atomic.AddUint64(&a.misses, 1)
+	b := make([]byte, 0, a.options.BufferSize)
+	return &b
+}
+
+// put returns a buffer to the arena, the buffer must not be used afterwards. A buffer that outgrew its region of the
+// slab is replaced by one of the usual size, so that the arena does not retain unusually large buffers
+func (a *EncoderArena) put(b *[]byte) {
+	if cap(*b) != a.options.BufferSize {
+		atomic.AddUint64(&a.misses, 1)
+		*b = make([]byte, 0, a.options.BufferSize)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	// when temporary buffers are returned the arena may be full, in which case this one is left to be collected
+	if len(a.free) < cap(a.free) {
+		a.free = append(a.free, b)
+	}
+}
+
+// EncoderArenaOptions configures the behaviour of an EncoderArena
+type EncoderArenaOptions struct {
+	// Buffers is the number of buffers, which bounds how many entries can be encoded concurrently without allocating
+	Buffers int
+	// BufferSize is the capacity of each buffer, which should exceed the size of almost all encoded entries
+	BufferSize int
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (a *EncoderArenaOptions) AssertDefaults() {
+	if a.Buffers <= 0 {
+		a.Buffers = DefaultEncoderArenaBuffers
+	}
+
+	if a.BufferSize <= 0 {
+		a.BufferSize = DefaultEncoderArenaBufferSize
+	}
+}
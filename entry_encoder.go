@@ -0,0 +1,21 @@
+package simplelogr
+
+import (
+	"io"
+)
+
+// EntryEncoder encodes log Entry objects, allowing sinks that transport entries somewhere (e.g. NetworkLogSink) to be
+// agnostic of the format. JSONLogSink, LogfmtLogSink, BinaryLogSink and CEFLogSink implement it.
+type EntryEncoder interface {
+	// Encode writes the encoding of a single Entry to the io.Writer, using a single call to Write
+	Encode(w io.Writer, e Entry) error
+}
+
+// DelimitedEncoder is implemented by EntryEncoder types that end each encoded Entry with a delimiter separating it from
+// the next in a stream, e.g. the newline ending each line of JSONLogSink, LogfmtLogSink and CEFLogSink. Sinks that
+// frame each Entry themselves, such as KafkaLogSink, use it to omit the redundant delimiter.
+type DelimitedEncoder interface {
+	EntryEncoder
+	// EntryDelimiter returns the delimiter ending each encoded Entry
+	EntryDelimiter() string
+}
@@ -0,0 +1,242 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// StackFrame is a single frame of a stack trace extracted from an error
+type StackFrame struct {
+	// Function is the fully qualified name of the function
+	Function string
+	// File is the full path of the source file
+	File string
+	// Line is the line number within the source file
+	Line int
+}
+
+// maxCapturedStackFrames limits the number of frames captured for Options.CaptureStackTrace
+const maxCapturedStackFrames = 32
+
+// encodeError extracts loggable information from the Entry's Error using the encoder, falling back to the stack
+// captured in Entry.Stack when the encoder found no stack trace. Captured stacks are represented as frames by
+// encoders producing structured errors, and otherwise formatted as a string in the style of github.com/pkg/errors.
+// Errors that panic while being encoded (e.g. in their Error method) are replaced by a placeholder message, reported
+// using Entry.reportError, so that the rest of the entry can still be logged.
+func (e Entry) encodeError(encoder func(err error) EncodedError) (encoded EncodedError) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			placeholder := panicPlaceholder(e.Error, recovered)
+			e.reportError(placeholder)
+			encoded = EncodedError{Message: placeholder.placeholder}
+		}
+	}()
+
+	encoded = encoder(e.Error)
+	if len(e.Stack) == 0 || encoded.StackTrace != "" || len(encoded.Frames) > 0 {
+		return encoded
+	}
+
+	if encoded.structured() {
+		encoded.Frames = e.Stack
+		return encoded
+	}
+
+	encoded.StackTrace = formatStackFrames(e.Stack)
+	return encoded
+}
+
+// StructuredErrorEncoder extracts the same information from an error as DefaultErrorEncoder, and in addition the name
+// of its type, its stack trace as a list of frames, and the chain of causes it wraps (each with its own type, message
+// and frames), so that sinks can represent errors as structured objects rather than a single string. Wrappers that do
+// not change the message (e.g. those that only add a stack trace) are merged with the error they wrap, which provides
+// the type while the outermost stack trace is kept. The chain of causes ends at a multi-error, whose constituent errors
+// are each encoded in full as its Errors.
+func StructuredErrorEncoder(err error) EncodedError {
+	encoded := EncodedError{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+	}
+	encoded.StackTrace, _ = pkgErrorsStackTrace(err)
+	encoded.Frames, _ = pkgErrorsFrames(err)
+
+	if errs, ok := multiErrors(err); ok {
+		encoded.Errors = encodeErrors(errs, StructuredErrorEncoder)
+		return encoded
+	}
+
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		message := cause.Error()
+		frames, _ := pkgErrorsFrames(cause)
+
+		last := &encoded
+		if len(encoded.Causes) > 0 {
+			last = &encoded.Causes[len(encoded.Causes)-1]
+		}
+		if message == last.Message {
+			last.Type = fmt.Sprintf("%T", cause)
+			if len(last.Frames) == 0 {
+				last.Frames = frames
+			}
+		} else {
+			encoded.Causes = append(encoded.Causes, EncodedError{
+				Message: message,
+				Type:    fmt.Sprintf("%T", cause),
+				Frames:  frames,
+			})
+			last = &encoded.Causes[len(encoded.Causes)-1]
+		}
+
+		if errs, ok := multiErrors(cause); ok {
+			last.Errors = encodeErrors(errs, StructuredErrorEncoder)
+			break
+		}
+	}
+
+	return encoded
+}
+
+// multiErrors returns the constituent errors of a multi-error, supporting both those created using errors.Join (or
+// otherwise implementing Unwrap() []error) and github.com/hashicorp/go-multierror
+func multiErrors(err error) ([]error, bool) {
+	switch multi := err.(type) {
+	case interface{ Unwrap() []error }:
+		return multi.Unwrap(), true
+	case interface{ WrappedErrors() []error }:
+		return multi.WrappedErrors(), true
+	default:
+		return nil, false
+	}
+}
+
+// encodeErrors encodes each of the non-nil errors using the encoder
+func encodeErrors(errs []error, encoder func(err error) EncodedError) []EncodedError {
+	encoded := make([]EncodedError, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			encoded = append(encoded, encoder(err))
+		}
+	}
+	return encoded
+}
+
+// appendError appends the JSON encoding of a structured EncodedError as an object
+func (e jsonEncoder) appendError(buf []byte, encoded EncodedError) []byte {
+	buf = append(buf, '{')
+	buf = e.appendString(buf, "message")
+	buf = append(buf, ':')
+	buf = e.appendString(buf, encoded.Message)
+
+	if encoded.Type != "" {
+		buf = append(buf, ',')
+		buf = e.appendString(buf, "type")
+		buf = append(buf, ':')
+		buf = e.appendString(buf, encoded.Type)
+	}
+
+	if len(encoded.Frames) > 0 {
+		buf = append(buf, ',')
+		buf = e.appendString(buf, "frames")
+		buf = append(buf, ":["...)
+		for i, frame := range encoded.Frames {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, '{')
+			buf = e.appendString(buf, "function")
+			buf = append(buf, ':')
+			buf = e.appendString(buf, frame.Function)
+			buf = append(buf, ',')
+			buf = e.appendString(buf, "file")
+			buf = append(buf, ':')
+			buf = e.appendString(buf, frame.File)
+			buf = append(buf, ',')
+			buf = e.appendString(buf, "line")
+			buf = append(buf, ':')
+			buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+			buf = append(buf, '}')
+		}
+		buf = append(buf, ']')
+	} else if encoded.StackTrace != "" {
+		buf = append(buf, ',')
+		buf = e.appendString(buf, "stacktrace")
+		buf = append(buf, ':')
+		buf = e.appendString(buf, encoded.StackTrace)
+	}
+
+	if len(encoded.Causes) > 0 {
+		buf = append(buf, ',')
+		buf = e.appendString(buf, "causes")
+		buf = append(buf, ":["...)
+		for i, cause := range encoded.Causes {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = e.appendError(buf, cause)
+		}
+		buf = append(buf, ']')
+	}
+
+	if len(encoded.Errors) > 0 {
+		buf = append(buf, ',')
+		buf = e.appendString(buf, "errors")
+		buf = append(buf, ":["...)
+		for i, constituent := range encoded.Errors {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = e.appendError(buf, constituent)
+		}
+		buf = append(buf, ']')
+	}
+
+	return append(buf, '}')
+}
+
+// appendErrorText appends a human-readable rendering of a structured EncodedError, with the type, frames and causes
+// on indented lines following the entry, and the constituents of a multi-error indented further
+func appendErrorText(buf []byte, encoded EncodedError, indent string, header string) []byte {
+	buf = append(buf, '\n')
+	buf = append(buf, indent...)
+	buf = append(buf, header...)
+	if encoded.Type != "" {
+		buf = append(buf, encoded.Type...)
+		buf = append(buf, ": "...)
+	}
+	// messages spanning several lines (e.g. those of errors.Join) are kept within the error's indentation
+	for i := 0; i < len(encoded.Message); i++ {
+		buf = append(buf, encoded.Message[i])
+		if encoded.Message[i] == '\n' {
+			buf = append(buf, indent...)
+			buf = append(buf, "  "...)
+		}
+	}
+
+	for _, frame := range encoded.Frames {
+		buf = append(buf, '\n')
+		buf = append(buf, indent...)
+		buf = append(buf, "    at "...)
+		buf = append(buf, frame.Function...)
+		buf = append(buf, " ("...)
+		buf = append(buf, frame.File...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+		buf = append(buf, ')')
+	}
+
+	if len(encoded.Frames) == 0 && encoded.StackTrace != "" {
+		buf = append(buf, encoded.StackTrace...)
+	}
+
+	for _, cause := range encoded.Causes {
+		buf = appendErrorText(buf, cause, indent, "caused by ")
+	}
+
+	for i, constituent := range encoded.Errors {
+		header := "error " + strconv.Itoa(i+1) + " of " + strconv.Itoa(len(encoded.Errors)) + ": "
+		buf = appendErrorText(buf, constituent, indent+"  ", header)
+	}
+
+	return buf
+}
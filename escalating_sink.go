@@ -0,0 +1,210 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// DefaultEscalationThreshold is the number of consecutive fatal errors after which an EscalatingSink escalates
+	DefaultEscalationThreshold = 10
+	// DefaultEscalationMessage is the message of the entry an EscalatingSink sends to its alert sink
+	DefaultEscalationMessage = "log output is failing persistently"
+	// DefaultEscalationExitCode is the exit code used by an EscalatingSink that terminates the process
+	DefaultEscalationExitCode = 1
+	// DefaultEscalationRetryInterval is how often an escalated EscalatingSink tries its sink again
+	DefaultEscalationRetryInterval = time.Minute
+)
+
+// IsFatalResourceError reports whether an error means that the output cannot be written to until something outside of
+// the process changes, e.g. the disk is full (ENOSPC), the reading end of a pipe has gone away (EPIPE), or the file
+// has been closed
+func IsFatalResourceError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed)
+}
+
+// EscalatingSink passes entries on to another LogSink, escalating when it fails persistently with errors it cannot
+// recover from (such as a full disk) rather than reporting an error for every entry forever, which floods stderr and
+// hides the root cause. Once escalated it sends a single entry describing the failure to an alert sink, then emits
+// entries to a fallback sink (e.g. stderr) or drops them, or terminates the process, as configured.
+type EscalatingSink struct {
+	options EscalatingSinkOptions
+
+	lock        sync.Mutex
+	failures    int
+	escalated   bool
+	lastAttempt time.Time
+	dropped     uint64
+}
+
+// NewEscalatingSink creates a new EscalatingSink with the provided options
+func NewEscalatingSink(opts EscalatingSinkOptions) *EscalatingSink {
+	return &EscalatingSink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, emitting the Entry to the sink unless escalated, in which case it is emitted to the fallback
+// sink (if any) and the sink is only tried again once every RetryInterval
+func (s *EscalatingSink) Log(e Entry) error {
+	s.lock.Lock()
+	if s.escalated && time.Since(s.lastAttempt) < s.options.RetryInterval {
+		s.lock.Unlock()
+		return s.fallback(e)
+	}
+	s.lastAttempt = time.Now()
+	s.lock.Unlock()
+
+	err := s.options.Sink.Log(e)
+
+	s.lock.Lock()
+	if err == nil || !s.options.IsFatal(err) {
+		s.failures = 0
+		s.escalated = false
+		s.lock.Unlock()
+		return err
+	}
+	s.failures++
+	escalated := s.escalated
+	escalate := !escalated && s.failures >= s.options.Threshold
+	s.escalated = escalated || escalate
+	failures := s.failures
+	s.lock.Unlock()
+
+	switch {
+	case escalate:
+		return s.escalate(e, err, failures)
+	case escalated:
+		return s.fallback(e)
+	default:
+		return err
+	}
+}
+
+// Escalated reports whether the sink has failed persistently and has escalated
+func (s *EscalatingSink) Escalated() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.escalated
+}
+
+// Dropped implements DropCounter, reporting how many entries were discarded while escalated without a fallback sink
+func (s *EscalatingSink) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+// escalate alerts about the failure, terminating the process if configured to, and otherwise emits the Entry to the
+// fallback sink. The error that caused the escalation is returned once, so that the ErrorHandler reports it.
+func (s *EscalatingSink) escalate(e Entry, err error, failures int) error {
+	errs := multiError{fmt.Errorf("escalating after %d consecutive failures: %w", failures, err)}
+
+	if s.options.Alert != nil {
+		if alertErr := s.options.Alert.Log(Entry{
+			Timestamp: time.Now().UTC(),
+			Message:   s.options.Message,
+			Error:     err,
+			KVs:       []interface{}{"consecutive_failures", failures},
+		}); alertErr != nil {
+			errs = append(errs, alertErr)
+		}
+	}
+
+	if s.options.Terminate {
+		if s.options.Alert != nil {
+			_ = FlushSinks(s.options.Alert)
+		}
+		s.options.Exit(s.options.ExitCode)
+	}
+
+	if fallbackErr := s.fallback(e); fallbackErr != nil {
+		errs = append(errs, fallbackErr)
+	}
+
+	return errs
+}
+
+func (s *EscalatingSink) fallback(e Entry) error {
+	if s.options.Fallback != nil {
+		return s.options.Fallback.Log(e)
+	}
+
+	s.lock.Lock()
+	s.dropped++
+	s.lock.Unlock()
+	return nil
+}
+
+// Unwrap implements WrapperSink, returning the sink along with the fallback and alert sinks, if any
+func (s *EscalatingSink) Unwrap() []LogSink {
+	sinks := []LogSink{s.options.Sink}
+	if s.options.Fallback != nil {
+		sinks = append(sinks, s.options.Fallback)
+	}
+	if s.options.Alert != nil {
+		sinks = append(sinks, s.options.Alert)
+	}
+	return sinks
+}
+
+var _ LogSink = (*EscalatingSink)(nil)
+var _ DropCounter = (*EscalatingSink)(nil)
+var _ WrapperSink = (*EscalatingSink)(nil)
+
+// EscalatingSinkOptions configures the behaviour of an EscalatingSink
+type EscalatingSinkOptions struct {
+	// Sink is the LogSink entries are normally emitted to, e.g. a JSONLogSink writing to a file
+	Sink LogSink
+	// IsFatal determines whether an error returned by the Sink is one it cannot recover from by itself
+	IsFatal func(err error) bool
+	// Threshold is the number of consecutive fatal errors after which the sink escalates
+	Threshold int
+	// Fallback, if specified, is where entries are emitted once escalated, e.g. a sink writing to stderr. Otherwise
+	// entries are dropped without reporting further errors
+	Fallback LogSink
+	// Alert, if specified, is sent a single entry describing the failure when escalating, e.g. a sink that notifies
+	// whoever is on call
+	Alert LogSink
+	// Message is the message of the entry sent to the Alert sink
+	Message string
+	// Terminate exits the process when escalating (after alerting), for services that must not run without logs
+	Terminate bool
+	// ExitCode is the exit code used when terminating the process
+	ExitCode int
+	// Exit terminates the process
+	Exit func(code int)
+	// RetryInterval is how often the Sink is tried again once escalated, recovering if it succeeds
+	RetryInterval time.Duration
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (s *EscalatingSinkOptions) AssertDefaults() {
+	if s.IsFatal == nil {
+		s.IsFatal = IsFatalResourceError
+	}
+
+	if s.Threshold <= 0 {
+		s.Threshold = DefaultEscalationThreshold
+	}
+
+	if s.Message == "" {
+		s.Message = DefaultEscalationMessage
+	}
+
+	if s.ExitCode == 0 {
+		s.ExitCode = DefaultEscalationExitCode
+	}
+
+	if s.Exit == nil {
+		s.Exit = os.Exit
+	}
+
+	if s.RetryInterval <= 0 {
+		s.RetryInterval = DefaultEscalationRetryInterval
+	}
+}
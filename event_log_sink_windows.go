@@ -0,0 +1,122 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+var (
+	// DefaultEventID is the event ID used by EventLogSink when none is specified
+	DefaultEventID uint32 = 1
+)
+
+// EventLogSink emits log Entry objects to the Windows Event Log as plain text, with errors reported as error events,
+// entries at negative levels such as LevelWarn as warning events, and everything else as information events
+type EventLogSink struct {
+	options EventLogSinkOptions
+	log     *eventlog.Log
+}
+
+// NewEventLogSink creates a new EventLogSink with the provided options, opening the event log for the configured
+// Source
+func NewEventLogSink(opts EventLogSinkOptions) (*EventLogSink, error) {
+	log, err := eventlog.Open(opts.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	return &EventLogSink{
+		options: opts,
+		log:     log,
+	}, nil
+}
+
+// Log implements LogSink, encoding the given Entry as text before reporting it to the event log
+func (w *EventLogSink) Log(e Entry) error {
+	buffer := bytes.Buffer{}
+
+	if len(e.Names) > 0 {
+		_, _ = fmt.Fprintf(&buffer, "%s: ", w.options.NameEncoder(e.Names))
+	}
+	buffer.WriteString(e.Message)
+
+	var encodedErr EncodedError
+	if e.Error != nil {
+		encodedErr = e.encodeError(w.options.ErrorEncoder)
+		_, _ = fmt.Fprintf(&buffer, " %s=%q", w.options.ErrorKey, encodedErr.Message)
+	}
+
+	e.KVs = flattenGroups(e.KVs)
+	for i := 0; i < len(e.KVs); i += 2 {
+		k := e.KVs[i]
+		v := e.KVs[i+1]
+
+		kStr, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("logging keys must be strings, got %T: %v", k, k)
+		}
+
+		b, err := json.Marshal(resolveValue(v))
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(&buffer, " %s=%s", kStr, b)
+	}
+
+	if encodedErr.StackTrace != "" {
+		buffer.WriteString(encodedErr.StackTrace)
+	}
+
+	if e.Error != nil {
+		return w.log.Error(w.options.EventID, buffer.String())
+	}
+	if e.Level < 0 {
+		return w.log.Warning(w.options.EventID, buffer.String())
+	}
+	return w.log.Info(w.options.EventID, buffer.String())
+}
+
+// Close closes the event log
+func (w *EventLogSink) Close() error {
+	return w.log.Close()
+}
+
+var _ LogSink = (*EventLogSink)(nil)
+var _ CloserSink = (*EventLogSink)(nil)
+
+// EventLogSinkOptions configures the behaviour of an EventLogSink
+type EventLogSinkOptions struct {
+	// Source is the event source name that events are reported under, typically the service name
+	Source string
+	// EventID is the event ID all events are reported with
+	EventID uint32
+	// NameEncoder collapses the series of Logger names down into one string for logging
+	NameEncoder func(names []string) string
+	// ErrorKey determines the key prefix on any error messages
+	ErrorKey string
+	// ErrorEncoder extracts loggable EncodedError information from an error
+	ErrorEncoder func(err error) EncodedError
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (w *EventLogSinkOptions) AssertDefaults() {
+	if w.EventID == 0 {
+		w.EventID = DefaultEventID
+	}
+
+	if w.NameEncoder == nil {
+		w.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+
+	if w.ErrorKey == "" {
+		w.ErrorKey = DefaultErrorKey
+	}
+
+	if w.ErrorEncoder == nil {
+		w.ErrorEncoder = DefaultErrorEncoder
+	}
+}
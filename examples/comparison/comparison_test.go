@@ -0,0 +1,234 @@
+// Package comparison measures the cost of logging identical entries as JSON with the JSONLogSink, zap and zerolog, to
+// help evaluate the trade-offs between them, e.g.:
+//
+//	go test -bench . -benchmem ./comparison
+//
+// Each library writes using its production JSON configuration. The JSONLogSink is used through the logr API, as it
+// would be in practice, while zap is measured using both its typed fields and its sugared key-value API. The tests
+// check that the libraries really do log identical entries, so that the comparison stays fair.
+package comparison
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/omaskery/simple-logr"
+)
+
+var (
+	ErrBenchmark = errors.New("benchmark error")
+)
+
+// workloads are the log calls compared between libraries, each logging a single entry with the same content
+type workloads struct {
+	Info       func()
+	Error      func()
+	Disabled   func()
+	WithValues func()
+}
+
+// each calls fn with each of the workloads, along with its name
+func (w workloads) each(fn func(name string, workload func())) {
+	fn("Info", w.Info)
+	fn("Error", w.Error)
+	fn("Disabled", w.Disabled)
+	fn("WithValues", w.WithValues)
+}
+
+// libraries creates the workloads of each library compared, writing to the given io.Writer
+var libraries = []struct {
+	name string
+	new  func(w io.Writer) workloads
+}{
+	{"simplelogr", simplelogrWorkloads},
+	{"zap", zapWorkloads},
+	{"zap.Sugar", zapSugarWorkloads},
+	{"zerolog", zerologWorkloads},
+}
+
+func BenchmarkComparison(b *testing.B) {
+	for _, library := range libraries {
+		library.new(ioutil.Discard).each(func(name string, workload func()) {
+			b.Run(library.name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					workload()
+				}
+			})
+		})
+	}
+}
+
+// TestComparisonEquivalence checks that each library logs the same entry for each workload, once the differences in
+// their key names are accounted for, so that none of them is measured doing less work than the others
+func TestComparisonEquivalence(t *testing.T) {
+	expected := map[string]map[string]interface{}{}
+	for _, library := range libraries {
+		buffer := bytes.Buffer{}
+		library.new(&buffer).each(func(name string, workload func()) {
+			buffer.Reset()
+			workload()
+
+			actual, err := normaliseEntry(buffer.Bytes())
+			if err != nil {
+				t.Fatalf("%s/%s logged %q: %v", library.name, name, buffer.String(), err)
+			}
+			if want, ok := expected[name]; !ok {
+				expected[name] = actual
+			} else if !reflect.DeepEqual(actual, want) {
+				t.Errorf("%s/%s logged %v, but %s logged %v", library.name, name, actual, libraries[0].name, want)
+			}
+		})
+	}
+}
+
+// entryKeyAliases maps the key names the libraries use for the same information onto common names
+var entryKeyAliases = map[string]string{
+	"severity": "level",
+	"message":  "msg",
+	"name":     "logger",
+}
+
+// normaliseEntry decodes a JSON entry, if one was logged, renaming keys to common names, lowercasing the level and
+// omitting the timestamp, which differs between libraries
+func normaliseEntry(line []byte) (map[string]interface{}, error) {
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+
+	normalised := make(map[string]interface{}, len(entry))
+	for key, value := range entry {
+		switch key {
+		case "ts", "time":
+			continue
+		}
+		if alias, ok := entryKeyAliases[key]; ok {
+			key = alias
+		}
+		if key == "level" {
+			value = strings.ToLower(fmt.Sprint(value))
+		}
+		normalised[key] = value
+	}
+	return normalised, nil
+}
+
+func simplelogrWorkloads(w io.Writer) workloads {
+	opts := simplelogr.JSONLogSinkOptions{Output: w}
+	opts.AssertDefaults()
+
+	logger := logr.New(simplelogr.New(simplelogr.Options{Sink: simplelogr.NewJSONLogSink(opts)})).
+		WithName("benchmark").
+		WithValues("request_id", "8f14e45f", "attempt", 3)
+	contextual := logger.WithValues("service", "inventory", "version", "1.4.2", "region", "eu-west-1")
+
+	return workloads{
+		Info: func() {
+			logger.Info("handled request", "path", "/api/v1/items", "status", 200, "duration_ms", 12.5)
+		},
+		Error: func() {
+			logger.Error(ErrBenchmark, "request failed", "path", "/api/v1/items", "retry", true)
+		},
+		Disabled: func() {
+			logger.V(1).Info("handled request", "path", "/api/v1/items", "status", 200, "duration_ms", 12.5)
+		},
+		WithValues: func() {
+			contextual.Info("handled request", "path", "/api/v1/items", "status", 200)
+		},
+	}
+}
+
+func newZap(w io.Writer) *zap.Logger {
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(w),
+		zapcore.InfoLevel,
+	)
+	return zap.New(core).
+		Named("benchmark").
+		With(zap.String("request_id", "8f14e45f"), zap.Int("attempt", 3))
+}
+
+func zapWorkloads(w io.Writer) workloads {
+	logger := newZap(w)
+	contextual := logger.With(zap.String("service", "inventory"), zap.String("version", "1.4.2"), zap.String("region", "eu-west-1"))
+
+	return workloads{
+		Info: func() {
+			logger.Info("handled request", zap.String("path", "/api/v1/items"), zap.Int("status", 200), zap.Float64("duration_ms", 12.5))
+		},
+		Error: func() {
+			logger.Error("request failed", zap.Error(ErrBenchmark), zap.String("path", "/api/v1/items"), zap.Bool("retry", true))
+		},
+		Disabled: func() {
+			logger.Debug("handled request", zap.String("path", "/api/v1/items"), zap.Int("status", 200), zap.Float64("duration_ms", 12.5))
+		},
+		WithValues: func() {
+			contextual.Info("handled request", zap.String("path", "/api/v1/items"), zap.Int("status", 200))
+		},
+	}
+}
+
+func zapSugarWorkloads(w io.Writer) workloads {
+	logger := newZap(w).Sugar()
+	contextual := logger.With("service", "inventory", "version", "1.4.2", "region", "eu-west-1")
+
+	return workloads{
+		Info: func() {
+			logger.Infow("handled request", "path", "/api/v1/items", "status", 200, "duration_ms", 12.5)
+		},
+		Error: func() {
+			logger.Errorw("request failed", "error", ErrBenchmark, "path", "/api/v1/items", "retry", true)
+		},
+		Disabled: func() {
+			logger.Debugw("handled request", "path", "/api/v1/items", "status", 200, "duration_ms", 12.5)
+		},
+		WithValues: func() {
+			contextual.Infow("handled request", "path", "/api/v1/items", "status", 200)
+		},
+	}
+}
+
+func zerologWorkloads(w io.Writer) workloads {
+	logger := zerolog.New(w).
+		Level(zerolog.InfoLevel).
+		With().
+		Timestamp().
+		Str("logger", "benchmark").
+		Str("request_id", "8f14e45f").
+		Int("attempt", 3).
+		Logger()
+	contextual := logger.With().Str("service", "inventory").Str("version", "1.4.2").Str("region", "eu-west-1").Logger()
+
+	return workloads{
+		Info: func() {
+			logger.Info().Str("path", "/api/v1/items").Int("status", 200).Float64("duration_ms", 12.5).Msg("handled request")
+		},
+		Error: func() {
+			logger.Error().Err(ErrBenchmark).Str("path", "/api/v1/items").Bool("retry", true).Msg("request failed")
+		},
+		Disabled: func() {
+			logger.Debug().Str("path", "/api/v1/items").Int("status", 200).Float64("duration_ms", 12.5).Msg("handled request")
+		},
+		WithValues: func() {
+			contextual.Info().Str("path", "/api/v1/items").Int("status", 200).Msg("handled request")
+		},
+	}
+}
@@ -9,4 +9,6 @@ require (
 	github.com/mattn/go-colorable v0.1.11
 	github.com/omaskery/simple-logr v0.0.0-00010101000000-000000000000
 	github.com/pkg/errors v0.9.1
+	github.com/rs/zerolog v1.26.1
+	go.uber.org/zap v1.21.0
 )
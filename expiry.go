@@ -0,0 +1,70 @@
+package simplelogr
+
+import (
+	"time"
+)
+
+var (
+	// DefaultExpiryMessage is the message of the summary entry emitted in place of expired entries
+	DefaultExpiryMessage = "log entries expired before delivery"
+)
+
+// ExpiryPolicy determines what happens to entries that have been queued for too long, e.g. by an AsyncSink or a
+// disconnected NetworkLogSink. Delivering minutes-old entries after an outage is often worse than dropping them,
+// especially for destinations that drive alerts. The age of an entry is measured from its Entry.Timestamp.
+type ExpiryPolicy struct {
+	// MaxAge is how old a queued entry may be before it expires rather than being delivered, zero disables expiry
+	MaxAge time.Duration
+	// Summarize replaces expired entries with a single summary entry, reporting how many expired and when they were
+	// logged, rather than dropping them silently
+	Summarize bool
+	// Message is the message of the summary entry
+	Message string
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (p *ExpiryPolicy) AssertDefaults() {
+	if p.Message == "" {
+		p.Message = DefaultExpiryMessage
+	}
+}
+
+// expired reports whether an entry logged at the given time has expired
+func (p ExpiryPolicy) expired(logged, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(logged) > p.MaxAge
+}
+
+// summary creates the entry summarizing the expired entries, if there are any and the policy is to summarize them
+func (p ExpiryPolicy) summary(expired expiredEntries, now time.Time) (Entry, bool) {
+	if !p.Summarize || expired.count == 0 {
+		return Entry{}, false
+	}
+
+	return Entry{
+		Timestamp: now.UTC(),
+		Message:   p.Message,
+		KVs: []interface{}{
+			"expired", expired.count,
+			"oldest", expired.oldest,
+			"newest", expired.newest,
+			"max_age", p.MaxAge.String(),
+		},
+	}, true
+}
+
+// expiredEntries accumulates the details of expired entries for the summary entry
+type expiredEntries struct {
+	count  uint64
+	oldest time.Time
+	newest time.Time
+}
+
+func (x *expiredEntries) add(logged time.Time) {
+	if x.count == 0 || logged.Before(x.oldest) {
+		x.oldest = logged
+	}
+	if x.count == 0 || logged.After(x.newest) {
+		x.newest = logged
+	}
+	x.count++
+}
@@ -0,0 +1,124 @@
+package simplelogr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultFailoverKey is the key of the diagnostic key-value pair FailoverSink adds to entries it fails over
+	DefaultFailoverKey = "failover_error"
+	// DefaultFailoverResetTimeout is how long a FailoverSink's open circuit waits before trying the primary again
+	DefaultFailoverResetTimeout = 30 * time.Second
+)
+
+// FailoverSink emits entries to a primary LogSink and, when it fails, to a secondary LogSink (e.g. stderr) instead,
+// adding a key-value pair describing the failure, so that entries are not lost when a destination is unavailable.
+// Optionally, after a number of consecutive failures the circuit opens, and entries are sent straight to the
+// secondary without trying the primary until the reset timeout has passed, to avoid hammering a dead destination.
+type FailoverSink struct {
+	options FailoverSinkOptions
+
+	lock      sync.Mutex
+	failures  int
+	openUntil time.Time
+	lastErr   error
+}
+
+// NewFailoverSink creates a new FailoverSink with the provided options
+func NewFailoverSink(opts FailoverSinkOptions) *FailoverSink {
+	return &FailoverSink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, emitting the Entry to the primary, or the secondary if the primary fails or the circuit
+// is open. Failures of the primary are only returned if the secondary also fails, along with the secondary's failure.
+func (f *FailoverSink) Log(e Entry) error {
+	if open, lastErr := f.circuitOpen(); open {
+		return f.failover(e, fmt.Errorf("circuit open: %w", lastErr))
+	}
+
+	err := f.options.Primary.Log(e)
+	f.record(err)
+	if err == nil {
+		return nil
+	}
+
+	return f.failover(e, err)
+}
+
+// Open reports whether the circuit is currently open, with entries being sent straight to the secondary
+func (f *FailoverSink) Open() bool {
+	open, _ := f.circuitOpen()
+	return open
+}
+
+func (f *FailoverSink) circuitOpen() (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return time.Now().Before(f.openUntil), f.lastErr
+}
+
+// record tracks consecutive failures of the primary, opening the circuit once there are too many
+func (f *FailoverSink) record(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err == nil {
+		f.failures = 0
+		return
+	}
+
+	f.failures++
+	f.lastErr = err
+	if f.options.FailureThreshold > 0 && f.failures >= f.options.FailureThreshold {
+		f.openUntil = time.Now().Add(f.options.ResetTimeout)
+	}
+}
+
+func (f *FailoverSink) failover(e Entry, err error) error {
+	kvs := make([]interface{}, 0, len(e.KVs)+2)
+	kvs = append(kvs, e.KVs...)
+	e.KVs = append(kvs, f.options.FailoverKey, err.Error())
+
+	if secondaryErr := f.options.Secondary.Log(e); secondaryErr != nil {
+		return multiError{err, secondaryErr}
+	}
+
+	return nil
+}
+
+// Unwrap implements WrapperSink, returning the primary and secondary sinks
+func (f *FailoverSink) Unwrap() []LogSink {
+	return []LogSink{f.options.Primary, f.options.Secondary}
+}
+
+var _ LogSink = (*FailoverSink)(nil)
+var _ WrapperSink = (*FailoverSink)(nil)
+
+// FailoverSinkOptions configures the behaviour of a FailoverSink
+type FailoverSinkOptions struct {
+	// Primary is the LogSink entries are normally emitted to
+	Primary LogSink
+	// Secondary is the LogSink entries are emitted to when the primary fails
+	Secondary LogSink
+	// FailoverKey is the key of the key-value pair describing why an entry was failed over
+	FailoverKey string
+	// FailureThreshold, if specified, is the number of consecutive failures of the primary that opens the circuit
+	FailureThreshold int
+	// ResetTimeout is how long the circuit stays open before the primary is tried again
+	ResetTimeout time.Duration
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (f *FailoverSinkOptions) AssertDefaults() {
+	if f.FailoverKey == "" {
+		f.FailoverKey = DefaultFailoverKey
+	}
+
+	if f.ResetTimeout <= 0 {
+		f.ResetTimeout = DefaultFailoverResetTimeout
+	}
+}
@@ -0,0 +1,66 @@
+package simplelogr
+
+// FlagEvaluator reports whether the named feature flag is enabled for an Entry, allowing changes to logging to be
+// rolled out progressively. It typically consults a feature flag provider, using attributes taken from the entry's
+// key-value pairs (see Entry.Value) such as the user or tenant to target. For example, when using OpenFeature:
+//
+//	func(flag string, e simplelogr.Entry) bool {
+//	    tenant, _ := e.Value("tenant")
+//	    evalCtx := openfeature.NewEvaluationContext(fmt.Sprint(tenant), nil)
+//	    enabled, _ := client.BooleanValue(context.Background(), flag, false, evalCtx)
+//	    return enabled
+//	}
+//
+// It is called for every Entry considered, so should be fast and safe for concurrent use.
+type FlagEvaluator func(flag string, e Entry) bool
+
+// WhenFlag creates a Processor applying the given processors, in order, only to entries for which the flag is enabled,
+// e.g. to add debugging fields for a flagged tenant. Entries for which the flag is disabled are left unchanged.
+func WhenFlag(flags FlagEvaluator, flag string, processors ...Processor) Processor {
+	return func(e Entry) (Entry, bool) {
+		if !flags(flag, e) {
+			return e, true
+		}
+
+		for _, processor := range processors {
+			var keep bool
+			if e, keep = processor(e); !keep {
+				return e, false
+			}
+		}
+		return e, true
+	}
+}
+
+// FlaggedSink passes entries on to another LogSink only when a feature flag is enabled for them, e.g. to trial an
+// experimental sink alongside the existing ones using a MultiSink
+type FlaggedSink struct {
+	flags FlagEvaluator
+	flag  string
+	sink  LogSink
+}
+
+// NewFlaggedSink creates a new FlaggedSink passing entries on to the given sink when the flag is enabled for them
+func NewFlaggedSink(flags FlagEvaluator, flag string, sink LogSink) *FlaggedSink {
+	return &FlaggedSink{
+		flags: flags,
+		flag:  flag,
+		sink:  sink,
+	}
+}
+
+// Log implements LogSink, passing the Entry on to the wrapped sink if the flag is enabled for it
+func (f FlaggedSink) Log(e Entry) error {
+	if !f.flags(f.flag, e) {
+		return nil
+	}
+	return f.sink.Log(e)
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (f FlaggedSink) Unwrap() []LogSink {
+	return []LogSink{f.sink}
+}
+
+var _ LogSink = (*FlaggedSink)(nil)
+var _ WrapperSink = (*FlaggedSink)(nil)
@@ -0,0 +1,249 @@
+package simplelogr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// DefaultFileSyncInterval is the SyncInterval used by FileOutput when none is specified
+	DefaultFileSyncInterval = time.Second
+	// DefaultFilePermissions is the permissions given to files created by FileOutput when none are specified
+	DefaultFilePermissions os.FileMode = 0644
+)
+
+// SyncPolicy controls how often a FileOutput flushes written data to stable storage using fsync
+type SyncPolicy int
+
+const (
+	// SyncPolicyDefault is the SyncPolicy of options that do not specify one, which AssertDefaults replaces with
+	// SyncPolicyNever, and NewDaemon with SyncPolicyInterval
+	SyncPolicyDefault SyncPolicy = iota
+	// SyncPolicyNever leaves flushing to stable storage up to the operating system
+	SyncPolicyNever
+	// SyncPolicyEveryWrite flushes to stable storage after every write, the safest but slowest option
+	SyncPolicyEveryWrite
+	// SyncPolicyInterval flushes to stable storage after a write if it has not done so within the SyncInterval
+	SyncPolicyInterval
+)
+
+// FileOutputOptions configures the behaviour of a FileOutput
+type FileOutputOptions struct {
+	// SyncPolicy determines how often writes are flushed to stable storage
+	SyncPolicy SyncPolicy
+	// SyncInterval is the maximum time between flushes when using SyncPolicyInterval
+	SyncInterval time.Duration
+	// Permissions are used when creating the file, DefaultFilePermissions if unspecified
+	Permissions os.FileMode
+	// ErrorHandler is called with any errors encountered while reopening the file in the background, see
+	// FileOutput.ReopenOnSignal
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (f *FileOutputOptions) AssertDefaults() {
+	if f.SyncPolicy == SyncPolicyDefault {
+		f.SyncPolicy = SyncPolicyNever
+	}
+
+	if f.SyncInterval <= 0 {
+		f.SyncInterval = DefaultFileSyncInterval
+	}
+
+	if f.Permissions == 0 {
+		f.Permissions = DefaultFilePermissions
+	}
+
+	if f.ErrorHandler == nil {
+		f.ErrorHandler = DefaultErrorHandler
+	}
+}
+
+// permissions returns the Permissions, or DefaultFilePermissions if they are unspecified, so that files are never
+// created with no permissions at all when AssertDefaults has not been called
+func (f FileOutputOptions) permissions() os.FileMode {
+	if f.Permissions == 0 {
+		return DefaultFilePermissions
+	}
+	return f.Permissions
+}
+
+// FileOutput is a thread-safe io.Writer appending to a file, flushing writes to stable storage according to its
+// SyncPolicy. It can reopen its path so that external tools such as logrotate can rotate the file: they rename it,
+// then signal the process (see ReopenOnSignal) or otherwise arrange for Reopen to be called, after which writes go to
+// a new file at the original path.
+type FileOutput struct {
+	options  FileOutputOptions
+	lock     sync.Mutex
+	path     string
+	file     *os.File
+	lastSync time.Time
+	closed   bool
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// OpenFileOutput opens (creating if necessary) the file at the given path for appending
+func OpenFileOutput(path string, opts FileOutputOptions) (*FileOutput, error) {
+	file, err := openFileForAppend(path, opts.permissions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	return &FileOutput{
+		options:  opts,
+		path:     path,
+		file:     file,
+		lastSync: time.Now(),
+	}, nil
+}
+
+// Path returns the path of the file being written to
+func (f *FileOutput) Path() string {
+	return f.path
+}
+
+// Write implements io.Writer, appending to the file and flushing to stable storage if required by the SyncPolicy
+func (f *FileOutput) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	n, err := f.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	switch f.options.SyncPolicy {
+	case SyncPolicyEveryWrite:
+		err = f.syncLocked()
+	case SyncPolicyInterval:
+		if time.Since(f.lastSync) >= f.options.SyncInterval {
+			err = f.syncLocked()
+		}
+	}
+
+	return n, err
+}
+
+// Sync flushes all written data to stable storage
+func (f *FileOutput) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.syncLocked()
+}
+
+// Reopen opens (creating if necessary) the file at the output's path again, and then flushes and closes the file
+// previously written to. If the file cannot be opened, writes continue to the previous file.
+func (f *FileOutput) Reopen() error {
+	file, err := openFileForAppend(f.path, f.options.permissions())
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file: %w", err)
+	}
+
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		_ = file.Close()
+		return os.ErrClosed
+	}
+	previous := f.file
+	f.file = file
+	f.lastSync = time.Now()
+	f.lock.Unlock()
+
+	syncErr := previous.Sync()
+	if err := previous.Close(); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// ReopenOnSignal begins reopening the file in the background whenever the process receives one of the given signals,
+// SIGHUP if none are given, until the context is cancelled or the output is closed. Errors are reported to the
+// ErrorHandler. Calling ReopenOnSignal on an output that is already doing so has no effect.
+func (f *FileOutput) ReopenOnSignal(ctx context.Context, signals ...os.Signal) {
+	f.lifecycleLock.Lock()
+	defer f.lifecycleLock.Unlock()
+
+	if f.done != nil {
+		return
+	}
+
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+		defer signal.Stop(received)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-received:
+				if err := f.Reopen(); err != nil {
+					f.reportError(err)
+				}
+			}
+		}
+	}(f.done)
+}
+
+// Close stops any reopening started by ReopenOnSignal, flushes all written data to stable storage and closes the file
+func (f *FileOutput) Close() error {
+	f.lifecycleLock.Lock()
+	if f.done != nil {
+		f.cancel()
+		<-f.done
+		f.done = nil
+	}
+	f.lifecycleLock.Unlock()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if err := f.syncLocked(); err != nil {
+		_ = f.file.Close()
+		return err
+	}
+
+	return f.file.Close()
+}
+
+func (f *FileOutput) syncLocked() error {
+	f.lastSync = time.Now()
+	return f.file.Sync()
+}
+
+func (f *FileOutput) reportError(err error) {
+	if f.options.ErrorHandler != nil {
+		f.options.ErrorHandler(err)
+	} else {
+		DefaultErrorHandler(err)
+	}
+}
+
+func openFileForAppend(path string, permissions os.FileMode) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, permissions)
+}
+
+var _ io.WriteCloser = (*FileOutput)(nil)
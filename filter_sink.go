@@ -0,0 +1,101 @@
+package simplelogr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Filter reports whether an Entry should be passed on by a FilterSink
+type Filter func(e Entry) bool
+
+// FilterSink passes on only the entries matching all of its filters to another LogSink, e.g. combined with a
+// MultiSink to send only errors to a network sink while everything is written to a local file:
+//
+//	NewMultiSink(fileSink, NewFilterSink(networkSink, ErrorFilter()))
+type FilterSink struct {
+	sink    LogSink
+	filters []Filter
+}
+
+// NewFilterSink creates a new FilterSink passing entries matching all the given filters on to the given sink
+func NewFilterSink(sink LogSink, filters ...Filter) *FilterSink {
+	return &FilterSink{
+		sink:    sink,
+		filters: filters,
+	}
+}
+
+// Log implements LogSink, passing the Entry on to the wrapped sink if it matches all the filters
+func (f FilterSink) Log(e Entry) error {
+	for _, filter := range f.filters {
+		if !filter(e) {
+			return nil
+		}
+	}
+
+	return f.sink.Log(e)
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (f FilterSink) Unwrap() []LogSink {
+	return []LogSink{f.sink}
+}
+
+var _ LogSink = (*FilterSink)(nil)
+var _ WrapperSink = (*FilterSink)(nil)
+
+// NamePrefixFilter matches entries from loggers whose name starts with the given prefix, matching whole segments
+// joined using DefaultNameSeparator as VerbosityController.SetNameVerbosity does
+func NamePrefixFilter(prefix string) Filter {
+	return func(e Entry) bool {
+		return nameHasPrefix(strings.Join(e.Names, DefaultNameSeparator), prefix)
+	}
+}
+
+// VerbosityFilter matches entries with a verbosity level no greater than the given level, and all errors
+func VerbosityFilter(level int) Filter {
+	return func(e Entry) bool {
+		return e.Error != nil || e.Level <= level
+	}
+}
+
+// ErrorFilter matches entries logged with an error
+func ErrorFilter() Filter {
+	return func(e Entry) bool {
+		return e.Error != nil
+	}
+}
+
+// MessageFilter matches entries whose message matches the given regular expression
+func MessageFilter(re *regexp.Regexp) Filter {
+	return func(e Entry) bool {
+		return re.MatchString(e.Message)
+	}
+}
+
+// KeyFilter matches entries with a key-value pair with the given key, see Entry.Value
+func KeyFilter(key string) Filter {
+	return func(e Entry) bool {
+		_, ok := e.Value(key)
+		return ok
+	}
+}
+
+// NotFilter matches entries not matched by the given filter
+func NotFilter(filter Filter) Filter {
+	return func(e Entry) bool {
+		return !filter(e)
+	}
+}
+
+// AnyFilter matches entries matched by any of the given filters
+func AnyFilter(filters ...Filter) Filter {
+	return func(e Entry) bool {
+		for _, filter := range filters {
+			if filter(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
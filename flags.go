@@ -0,0 +1,155 @@
+package simplelogr
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultLogFormat is the format used by LoggerFlags when none is specified
+	DefaultLogFormat = LogFormatJSON
+)
+
+// LogFormat names a format of log output, and can be used as a flag.Value (or a pflag.Value)
+type LogFormat string
+
+const (
+	// LogFormatJSON is structured JSON output, written by a JSONLogSink
+	LogFormatJSON LogFormat = "json"
+	// LogFormatLogfmt is structured logfmt output, written by a LogfmtLogSink
+	LogFormatLogfmt LogFormat = "logfmt"
+	// LogFormatDevelopment is human-readable, optionally coloured, output written by a DevelopmentLogSink. It is not
+	// available in minimal builds
+	LogFormatDevelopment LogFormat = "dev"
+)
+
+// String implements flag.Value
+func (f LogFormat) String() string {
+	return string(f)
+}
+
+// Set implements flag.Value, accepting the name of one of the formats
+func (f *LogFormat) Set(s string) error {
+	switch LogFormat(s) {
+	case LogFormatJSON, LogFormatLogfmt:
+	case LogFormatDevelopment:
+		if !developmentSupported {
+			return fmt.Errorf("log format %q is not available in minimal builds", s)
+		}
+	default:
+		return fmt.Errorf("unknown log format %q, expected json, logfmt or dev", s)
+	}
+
+	*f = LogFormat(s)
+	return nil
+}
+
+// Type implements pflag.Value, describing the flag's value in help text
+func (f *LogFormat) Type() string {
+	return "format"
+}
+
+// Verbosity is a verbosity level, and can be used as a flag.Value (or a pflag.Value)
+type Verbosity int
+
+// String implements flag.Value
+func (v Verbosity) String() string {
+	return strconv.Itoa(int(v))
+}
+
+// Set implements flag.Value, accepting an integer or the name of a level, see LookupLevel. Negative verbosities, such
+// as LevelWarn, leave only entries at or below that level and errors
+func (v *Verbosity) Set(s string) error {
+	level, err := strconv.Atoi(s)
+	if err != nil {
+		if named, ok := LookupLevel(s); ok {
+			level, err = int(named), nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid verbosity %q, expected an integer or level name", s)
+	}
+
+	*v = Verbosity(level)
+	return nil
+}
+
+// Type implements pflag.Value, describing the flag's value in help text
+func (v *Verbosity) Type() string {
+	return "level"
+}
+
+// LoggerFlags holds the logging settings commonly exposed by command-line tools, which can be bound to flags using
+// BindFlags, and builds a logr.Logger from them. Programs using github.com/spf13/pflag can bind them to a
+// flag.FlagSet and add it using pflag's AddGoFlagSet, or register the values directly, as they implement pflag.Value.
+type LoggerFlags struct {
+	// Verbosity determines which verbose log messages are enabled
+	Verbosity Verbosity
+	// Format determines the format of the log output
+	Format LogFormat
+	// Output configures where to write logs to, if unspecified stderr (with writes synchronised) for structured
+	// formats, or stdout for LogFormatDevelopment
+	Output io.Writer
+
+	developmentFlags
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (f *LoggerFlags) AssertDefaults() {
+	if f.Format == "" {
+		f.Format = DefaultLogFormat
+	}
+}
+
+// BindFlags registers flags setting the options: -v for the Verbosity, -log-format for the Format, and (except in
+// minimal builds) -log-colour for the colour mode of LogFormatDevelopment. The current values are used as the flags'
+// defaults, so AssertDefaults should be called first.
+func (f *LoggerFlags) BindFlags(fs *flag.FlagSet) {
+	fs.Var(&f.Verbosity, "v", "log verbosity `level`, as a number or a name such as warn, debug or trace")
+	fs.Var(&f.Format, "log-format", "log output `format`: json, logfmt or dev")
+	f.bindDevelopmentFlags(fs)
+}
+
+// Build creates a ready to use logr.Logger according to the options. In minimal builds, LogFormatDevelopment falls
+// back to LogFormatJSON
+func (f *LoggerFlags) Build() logr.Logger {
+	var sink LogSink
+	switch f.Format {
+	case LogFormatLogfmt:
+		sinkOpts := LogfmtLogSinkOptions{
+			Output: f.structuredOutput(),
+		}
+		sinkOpts.AssertDefaults()
+		sink = NewLogfmtLogSink(sinkOpts)
+	case LogFormatDevelopment:
+		sink = f.developmentSink()
+	}
+
+	if sink == nil {
+		sinkOpts := JSONLogSinkOptions{
+			Output: f.structuredOutput(),
+		}
+		sinkOpts.AssertDefaults()
+		sink = NewJSONLogSink(sinkOpts)
+	}
+
+	return logr.New(New(Options{
+		Sink:      sink,
+		Verbosity: int(f.Verbosity),
+	}))
+}
+
+func (f *LoggerFlags) structuredOutput() io.Writer {
+	if f.Output != nil {
+		return f.Output
+	}
+	return SynchronizeWritesTo(os.Stderr)
+}
+
+var _ flag.Value = (*LogFormat)(nil)
+var _ flag.Value = (*Verbosity)(nil)
@@ -0,0 +1,66 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"flag"
+	"fmt"
+)
+
+// developmentSupported reports whether the DevelopmentLogSink is available, which it is not in minimal builds
+const developmentSupported = true
+
+// developmentFlags holds the options of LoggerFlags that only apply to the DevelopmentLogSink
+type developmentFlags struct {
+	// Colour determines whether LogFormatDevelopment output is coloured
+	Colour ColourMode
+}
+
+func (d *developmentFlags) bindDevelopmentFlags(fs *flag.FlagSet) {
+	fs.Var(&d.Colour, "log-colour", "`mode` determining whether dev log output is coloured: auto, always or never")
+}
+
+func (f *LoggerFlags) developmentSink() LogSink {
+	sinkOpts := DevelopmentLogSinkOptions{
+		Output:         f.Output,
+		ColouredOutput: f.Colour,
+	}
+	sinkOpts.AssertDefaults()
+	return NewDevelopmentLogSink(sinkOpts)
+}
+
+// String implements flag.Value, naming the mode as accepted by Set
+func (c ColourMode) String() string {
+	switch c {
+	case ColourModeAuto:
+		return "auto"
+	case ColourModeForceOff:
+		return "never"
+	case ColourModeForceOn:
+		return "always"
+	}
+	return fmt.Sprintf("ColourMode(%d)", int(c))
+}
+
+// Set implements flag.Value, accepting "auto", "never" (or "off") and "always" (or "on")
+func (c *ColourMode) Set(s string) error {
+	switch s {
+	case "auto":
+		*c = ColourModeAuto
+	case "never", "off":
+		*c = ColourModeForceOff
+	case "always", "on":
+		*c = ColourModeForceOn
+	default:
+		return fmt.Errorf("unknown colour mode %q, expected auto, always or never", s)
+	}
+	return nil
+}
+
+// Type implements pflag.Value, describing the flag's value in help text
+func (c *ColourMode) Type() string {
+	return "mode"
+}
+
+var _ flag.Value = (*ColourMode)(nil)
@@ -0,0 +1,21 @@
+//go:build simplelogr_minimal
+// +build simplelogr_minimal
+
+package simplelogr
+
+import (
+	"flag"
+)
+
+// developmentSupported reports whether the DevelopmentLogSink is available, which it is not in minimal builds
+const developmentSupported = false
+
+// developmentFlags has no options in minimal builds, as the DevelopmentLogSink is omitted
+type developmentFlags struct{}
+
+func (d *developmentFlags) bindDevelopmentFlags(fs *flag.FlagSet) {}
+
+// developmentSink returns nil in minimal builds, so that LoggerFlags falls back to JSON
+func (f *LoggerFlags) developmentSink() LogSink {
+	return nil
+}
@@ -0,0 +1,183 @@
+package simplelogr
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultFluentAddress is the address of the Fluentd forward input used when none is specified
+	DefaultFluentAddress = "127.0.0.1:24224"
+	// DefaultFluentTagPrefix is the prefix of the tags given to entries when none is specified
+	DefaultFluentTagPrefix = "app"
+	// DefaultFluentTimeout bounds how long connecting, writing and awaiting acknowledgements may take
+	DefaultFluentTimeout = 5 * time.Second
+)
+
+// FluentLogSink emits log Entry objects to Fluentd or Fluent Bit using the forward protocol (MessagePack over TCP), so
+// that logs can be pushed directly to an aggregator without a sidecar. Each entry is tagged based on the logger
+// names, and may optionally wait for the aggregator to acknowledge it.
+type FluentLogSink struct {
+	options FluentLogSinkOptions
+	record  *JSONLogSink
+	lock    sync.Mutex
+	conn    net.Conn
+}
+
+// NewFluentLogSink creates a new FluentLogSink with the provided options, the aggregator is connected to lazily when
+// the first Entry is logged, and reconnected to if the connection fails
+func NewFluentLogSink(opts FluentLogSinkOptions) *FluentLogSink {
+	return &FluentLogSink{
+		options: opts,
+		record:  NewJSONLogSink(opts.Record),
+	}
+}
+
+// Log implements LogSink, encoding the given Entry as a forward protocol message before sending it
+func (f *FluentLogSink) Log(e Entry) error {
+	record, err := f.record.fields(e)
+	if err != nil {
+		return err
+	}
+
+	var chunk string
+	if f.options.RequireAck {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("failed to generate chunk ID: %w", err)
+		}
+		chunk = base64.StdEncoding.EncodeToString(id)
+	}
+
+	// message mode: [tag, time, record, option]
+	msg := appendMsgpackArrayHeader(nil, 4)
+	msg = appendMsgpackString(msg, f.options.TagEncoder(e.Names))
+	msg = appendFluentEventTime(msg, e.Timestamp)
+	if msg, err = appendMsgpack(msg, record); e.reportPlaceholders(err) != nil {
+		return fmt.Errorf("failed to encode log entry as msgpack: %w", err)
+	}
+	option := map[string]interface{}{}
+	if chunk != "" {
+		option["chunk"] = chunk
+	}
+	if msg, err = appendMsgpack(msg, option); err != nil {
+		return err
+	}
+
+	return f.send(msg, chunk)
+}
+
+// Close closes the connection to the aggregator
+func (f *FluentLogSink) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.conn == nil {
+		return nil
+	}
+
+	err := f.conn.Close()
+	f.conn = nil
+	return err
+}
+
+func (f *FluentLogSink) send(msg []byte, chunk string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.conn == nil {
+		conn, err := net.DialTimeout("tcp", f.options.Address, f.options.Timeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to fluentd: %w", err)
+		}
+		f.conn = conn
+	}
+
+	fail := func(err error) error {
+		_ = f.conn.Close()
+		f.conn = nil
+		return err
+	}
+
+	_ = f.conn.SetDeadline(time.Now().Add(f.options.Timeout))
+	if _, err := f.conn.Write(msg); err != nil {
+		return fail(fmt.Errorf("failed to write to fluentd: %w", err))
+	}
+
+	if chunk == "" {
+		return nil
+	}
+
+	buf := make([]byte, 512)
+	n, err := f.conn.Read(buf)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read fluentd acknowledgement: %w", err))
+	}
+
+	response, err := readMsgpackStringMap(buf[:n])
+	if err != nil {
+		return fail(fmt.Errorf("failed to decode fluentd acknowledgement: %w", err))
+	}
+	if response["ack"] != chunk {
+		return fail(fmt.Errorf("fluentd acknowledged chunk %q, expected %q", response["ack"], chunk))
+	}
+
+	return nil
+}
+
+// appendFluentEventTime appends the forward protocol's EventTime extension type, which has nanosecond precision
+func appendFluentEventTime(buf []byte, t time.Time) []byte {
+	data := appendUint32(nil, uint32(t.Unix()))
+	data = appendUint32(data, uint32(t.Nanosecond()))
+	return appendMsgpackExt(buf, 0, data)
+}
+
+// DefaultFluentTagEncoder creates a tag encoder which joins the given prefix and the logger names using "."
+func DefaultFluentTagEncoder(prefix string) func(names []string) string {
+	return func(names []string) string {
+		if len(names) == 0 {
+			return prefix
+		}
+		return prefix + "." + strings.Join(names, ".")
+	}
+}
+
+var _ LogSink = (*FluentLogSink)(nil)
+var _ CloserSink = (*FluentLogSink)(nil)
+
+// FluentLogSinkOptions configures the behaviour of a FluentLogSink
+type FluentLogSinkOptions struct {
+	// Address is the host:port of the Fluentd/Fluent Bit forward input
+	Address string
+	// TagEncoder derives the tag of each entry from the logger names
+	TagEncoder func(names []string) string
+	// RequireAck causes every entry to wait for acknowledgement from the aggregator, guaranteeing delivery at the
+	// cost of throughput
+	RequireAck bool
+	// Timeout bounds how long connecting, writing and awaiting acknowledgements may take
+	Timeout time.Duration
+	// Record configures the keys used for the fields of each record, which are laid out as by JSONLogSink
+	Record JSONLogSinkOptions
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (f *FluentLogSinkOptions) AssertDefaults() {
+	if f.Address == "" {
+		f.Address = DefaultFluentAddress
+	}
+
+	if f.TagEncoder == nil {
+		f.TagEncoder = DefaultFluentTagEncoder(DefaultFluentTagPrefix)
+	}
+
+	if f.Timeout <= 0 {
+		f.Timeout = DefaultFluentTimeout
+	}
+
+	f.Record.AssertDefaults()
+}
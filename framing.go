@@ -0,0 +1,176 @@
+package simplelogr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Framed output wraps each entry in a record of the form "#<length> <crc32c> <entry>\n", so that entries which were
+// only partially written (e.g. due to a crash) or have since been corrupted can be detected, and ends each segment with
+// a trailer of the form "!<entries> <bytes> <crc32c>\n" summarising all the entries in the segment, so that missing or
+// truncated segments can be detected. Checksums are hexadecimal CRC-32C (Castagnoli) checksums.
+const (
+	frameRecordMarker  = '#'
+	frameTrailerMarker = '!'
+)
+
+var (
+	// ErrCorruptEntry is returned when reading an entry whose framing or checksum is invalid
+	ErrCorruptEntry = errors.New("corrupt log entry")
+	// ErrCorruptSegment is returned when reading a segment trailer that does not match the entries before it
+	ErrCorruptSegment = errors.New("log segment trailer does not match its entries")
+
+	frameTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// frameWriter frames each write as a record, keeping track of the totals written for the trailer
+type frameWriter struct {
+	w       io.Writer
+	entries uint64
+	size    uint64
+	crc     uint32
+}
+
+func newFrameWriter(w io.Writer) *frameWriter {
+	return &frameWriter{w: w}
+}
+
+// Write frames the given entry, which must be written in a single call and not contain any newlines other than a
+// trailing one (as written by JSONLogSink and LogfmtLogSink), returning the number of bytes of the record written
+func (f *frameWriter) Write(p []byte) (int, error) {
+	payload := bytes.TrimSuffix(p, []byte("\n"))
+	if bytes.IndexByte(payload, '\n') >= 0 {
+		return 0, fmt.Errorf("framed log entries must not contain newlines")
+	}
+
+	record := make([]byte, 0, len(payload)+24)
+	record = append(record, frameRecordMarker)
+	record = strconv.AppendInt(record, int64(len(payload)), 10)
+	record = append(record, ' ')
+	record = append(record, fmt.Sprintf("%08x", crc32.Checksum(payload, frameTable))...)
+	record = append(record, ' ')
+	record = append(record, payload...)
+	record = append(record, '\n')
+
+	n, err := f.w.Write(record)
+	if err != nil {
+		return n, err
+	}
+
+	f.entries++
+	f.size += uint64(len(payload))
+	f.crc = crc32.Update(f.crc, frameTable, payload)
+
+	return n, nil
+}
+
+// WriteTrailer writes the trailer summarising everything written so far
+func (f *frameWriter) WriteTrailer() error {
+	_, err := fmt.Fprintf(f.w, "%c%d %d %08x\n", frameTrailerMarker, f.entries, f.size, f.crc)
+	return err
+}
+
+// frameReader reads entries back from framed or plain newline-delimited output, verifying the records and trailers
+// of framed output
+type frameReader struct {
+	reader  *bufio.Reader
+	framed  bool
+	entries uint64
+	size    uint64
+	crc     uint32
+	// trailer is true once a valid trailer has been read, and is reset by any subsequent entries
+	trailer bool
+}
+
+func newFrameReader(r io.Reader) *frameReader {
+	return &frameReader{reader: bufio.NewReader(r)}
+}
+
+// next returns the next entry, io.EOF once there are no more, or an error wrapping ErrCorruptEntry or
+// ErrCorruptSegment if corruption is detected, in which case next may be called again to continue
+func (f *frameReader) next() ([]byte, error) {
+	for {
+		line, err := f.reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// a final line without a newline can only be the result of an incomplete write
+		truncated := err != nil
+
+		switch line[0] {
+		case frameRecordMarker:
+			f.framed = true
+			f.trailer = false
+			return f.record(line, truncated)
+		case frameTrailerMarker:
+			if err := f.checkTrailer(line, truncated); err != nil {
+				return nil, err
+			}
+		default:
+			return bytes.TrimSpace(line), nil
+		}
+	}
+}
+
+func (f *frameReader) record(line []byte, truncated bool) ([]byte, error) {
+	if truncated {
+		return nil, fmt.Errorf("%w: truncated record", ErrCorruptEntry)
+	}
+
+	header := bytes.SplitN(line[1:len(line)-1], []byte(" "), 3)
+	if len(header) != 3 {
+		return nil, fmt.Errorf("%w: invalid record header", ErrCorruptEntry)
+	}
+
+	length, lengthErr := strconv.ParseUint(string(header[0]), 10, 32)
+	checksum, checksumErr := strconv.ParseUint(string(header[1]), 16, 32)
+	if lengthErr != nil || checksumErr != nil {
+		return nil, fmt.Errorf("%w: invalid record header", ErrCorruptEntry)
+	}
+
+	payload := header[2]
+	if uint64(len(payload)) != length {
+		return nil, fmt.Errorf("%w: expected %d bytes, read %d", ErrCorruptEntry, length, len(payload))
+	}
+	if crc32.Checksum(payload, frameTable) != uint32(checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptEntry)
+	}
+
+	f.entries++
+	f.size += uint64(len(payload))
+	f.crc = crc32.Update(f.crc, frameTable, payload)
+
+	return payload, nil
+}
+
+func (f *frameReader) checkTrailer(line []byte, truncated bool) error {
+	fields := strings.Fields(string(line[1:]))
+	if truncated || len(fields) != 3 {
+		return fmt.Errorf("%w: invalid trailer", ErrCorruptSegment)
+	}
+
+	entries, entriesErr := strconv.ParseUint(fields[0], 10, 64)
+	size, sizeErr := strconv.ParseUint(fields[1], 10, 64)
+	checksum, checksumErr := strconv.ParseUint(fields[2], 16, 32)
+	if entriesErr != nil || sizeErr != nil || checksumErr != nil {
+		return fmt.Errorf("%w: invalid trailer", ErrCorruptSegment)
+	}
+
+	if entries != f.entries || size != f.size || uint32(checksum) != f.crc {
+		return fmt.Errorf("%w: trailer expects %d entries (%d bytes), read %d entries (%d bytes)",
+			ErrCorruptSegment, entries, size, f.entries, f.size)
+	}
+
+	f.trailer = true
+	return nil
+}
@@ -0,0 +1,106 @@
+package simplelogr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// GCPTraceKey is the special field Cloud Logging uses to associate log entries with traces
+	GCPTraceKey = "logging.googleapis.com/trace"
+	// GCPSpanIDKey is the special field Cloud Logging uses to associate log entries with spans
+	GCPSpanIDKey = "logging.googleapis.com/spanId"
+	// GCPTraceSampledKey is the special field Cloud Logging uses to indicate whether the trace was sampled
+	GCPTraceSampledKey = "logging.googleapis.com/trace_sampled"
+	// GCPSourceLocationKey is the special field Cloud Logging uses to link log entries to the source code
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	// GCPSeverityEncoder produces severity names understood by Cloud Logging
+	GCPSeverityEncoder = DefaultSeverityEncoder("INFO", "ERROR", []SeverityThreshold{
+		{Level: DefaultDebugVerbosity, Severity: "DEBUG"},
+		{Level: int(LevelWarn), Severity: "WARNING"},
+	})
+)
+
+// GCPJSONLogSinkOptions produces JSONLogSinkOptions emitting the structure Google Cloud Logging expects from workloads
+// such as GKE, so that entries are shown with the proper severity and links to their source location. Source
+// locations are only included when the Logger has Options.CaptureCaller enabled. The remaining options (e.g. Output)
+// can be customised before calling AssertDefaults as usual.
+func GCPJSONLogSinkOptions() JSONLogSinkOptions {
+	return JSONLogSinkOptions{
+		SeverityKey:     "severity",
+		SeverityEncoder: GCPSeverityEncoder,
+		NameKey:         "logger",
+		MessageKey:      "message",
+		TimestampKey:    "timestamp",
+		ErrorKey:        "error",
+		StackTraceKey:   "stack_trace",
+		CallerKey:       GCPSourceLocationKey,
+		CallerEncoder:   GCPSourceLocationEncoder,
+	}
+}
+
+// GCPSourceLocationEncoder encodes caller information as a Cloud Logging LogEntrySourceLocation
+func GCPSourceLocationEncoder(c Caller) interface{} {
+	return map[string]interface{}{
+		"file":     c.File,
+		"line":     strconv.Itoa(c.Line),
+		"function": c.Function,
+	}
+}
+
+// GCPTraceKVs parses the value of an X-Cloud-Trace-Context header ("TRACE_ID/SPAN_ID;o=OPTIONS") into the key-value
+// pairs Cloud Logging uses to associate log entries with traces, for use with logr.Logger.WithValues. Returns nil if
+// the header could not be parsed.
+func GCPTraceKVs(projectID string, header string) []interface{} {
+	traceAndSpan, options := header, ""
+	if i := strings.Index(header, ";"); i >= 0 {
+		traceAndSpan, options = header[:i], header[i+1:]
+	}
+
+	traceID, spanID := traceAndSpan, ""
+	if i := strings.Index(traceAndSpan, "/"); i >= 0 {
+		traceID, spanID = traceAndSpan[:i], traceAndSpan[i+1:]
+	}
+
+	if traceID == "" {
+		return nil
+	}
+
+	kvs := []interface{}{GCPTraceKey, gcpTraceName(projectID, traceID)}
+
+	// the header carries the span ID in decimal, but Cloud Logging expects it in hex
+	if spanID != "" {
+		if id, err := strconv.ParseUint(spanID, 10, 64); err == nil {
+			kvs = append(kvs, GCPSpanIDKey, fmt.Sprintf("%016x", id))
+		}
+	}
+
+	if options != "" {
+		kvs = append(kvs, GCPTraceSampledKey, options == "o=1")
+	}
+
+	return kvs
+}
+
+// GCPTraceContextHook creates a ContextHook which uses the given TraceExtractor (e.g. backed by OpenTelemetry) to add
+// the key-value pairs Cloud Logging uses to associate log entries with traces
+func GCPTraceContextHook(projectID string, extract TraceExtractor) ContextHook {
+	return func(ctx context.Context) []interface{} {
+		traceID, spanID, ok := extract(ctx)
+		if !ok || traceID == "" {
+			return nil
+		}
+
+		kvs := []interface{}{GCPTraceKey, gcpTraceName(projectID, traceID)}
+		if spanID != "" {
+			kvs = append(kvs, GCPSpanIDKey, spanID)
+		}
+		return kvs
+	}
+}
+
+func gcpTraceName(projectID string, traceID string) string {
+	return fmt.Sprintf("projects/%s/traces/%s", projectID, traceID)
+}
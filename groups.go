@@ -0,0 +1,58 @@
+package simplelogr
+
+import (
+	"github.com/go-logr/logr"
+)
+
+// Group, used as the value of a key-value pair, opens a group named by the pair's key: the key-value pairs that follow
+// it, up to the end of the entry, are nested under that name, as with slog.Logger.WithGroup or zap.Namespace. The
+// JSONLogSink nests them in an object, while text sinks prefix their keys with the name of the group and a dot, e.g.
+// "http.method". Groups without any key-value pairs are omitted. See WithGroup.
+type Group struct{}
+
+// WithGroup returns a logger whose subsequent key-value pairs, whether added using WithValues or passed to Info and
+// Error, are nested in a group with the given name, see Group
+func WithGroup(logger logr.Logger, name string) logr.Logger {
+	return logger.WithValues(name, Group{})
+}
+
+// isGroup reports whether the value of a key-value pair opens a Group
+func isGroup(v interface{}) bool {
+	_, ok := v.(Group)
+	return ok
+}
+
+// flattenGroups removes the key-value pairs opening groups, prefixing the keys of those that follow with the names of
+// the groups and a dot, for sinks that do not nest values. The key-value pairs are returned unchanged if there are no
+// groups.
+func flattenGroups(kvs []interface{}) []interface{} {
+	first := -1
+	for i := 1; i < len(kvs); i += 2 {
+		if isGroup(kvs[i]) {
+			first = i - 1
+			break
+		}
+	}
+	if first < 0 {
+		return kvs
+	}
+
+	flattened := make([]interface{}, first, len(kvs))
+	copy(flattened, kvs[:first])
+
+	prefix := ""
+	for i := first; i+1 < len(kvs); i += 2 {
+		k, ok := kvs[i].(string)
+		if !ok {
+			// left for the sink to report
+			flattened = append(flattened, kvs[i], kvs[i+1])
+			continue
+		}
+		if isGroup(kvs[i+1]) {
+			prefix += k + "."
+			continue
+		}
+		flattened = append(flattened, prefix+k, kvs[i+1])
+	}
+	return flattened
+}
@@ -0,0 +1,133 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// GRPCErrorMessage is the message of entries logged by a GRPCLogger for gRPC's Error messages
+	GRPCErrorMessage = "gRPC error"
+	// GRPCFatalMessage is the message of entries logged by a GRPCLogger for gRPC's Fatal messages
+	GRPCFatalMessage = "gRPC fatal error"
+)
+
+// GRPCLogger adapts a logr.Logger to implement google.golang.org/grpc/grpclog.LoggerV2, so that gRPC's internal
+// logging is written by the same sinks as the rest of the application, e.g.:
+//
+//	grpclog.SetLoggerV2(simplelogr.NewGRPCLogger(logger.WithName("grpc"), 1))
+//
+// Info messages are logged as Info entries, and Warning messages as Info entries at LevelWarn. Error and Fatal messages
+// are logged as Error entries with the message as the error, as severities are identified by the presence of an
+// error, and with GRPCErrorMessage or GRPCFatalMessage as the message. After logging a Fatal message, the sinks are
+// flushed and the process exits. gRPC's verbosity levels map directly onto logr's verbosity levels.
+type GRPCLogger struct {
+	logger     logr.Logger
+	warnLogger logr.Logger
+	infoLevel  int
+}
+
+// NewGRPCLogger creates a GRPCLogger logging Info messages at the given verbosity level, which are usually too detailed
+// to log at level 0, and Warning messages at LevelWarn
+func NewGRPCLogger(logger logr.Logger, infoLevel int) *GRPCLogger {
+	// skip the GRPCLogger's own frames when identifying the caller
+	logger = logger.WithCallDepth(2)
+	return &GRPCLogger{
+		logger:     logger,
+		warnLogger: Warn(logger),
+		infoLevel:  infoLevel,
+	}
+}
+
+// Info logs the arguments as fmt.Sprint does
+func (g *GRPCLogger) Info(args ...interface{}) {
+	g.info(g.infoLevel, fmt.Sprint(args...))
+}
+
+// Infoln logs the arguments as fmt.Sprintln does
+func (g *GRPCLogger) Infoln(args ...interface{}) {
+	g.info(g.infoLevel, sprintln(args...))
+}
+
+// Infof logs the arguments as fmt.Sprintf does
+func (g *GRPCLogger) Infof(format string, args ...interface{}) {
+	g.info(g.infoLevel, fmt.Sprintf(format, args...))
+}
+
+// Warning logs the arguments as fmt.Sprint does
+func (g *GRPCLogger) Warning(args ...interface{}) {
+	g.warn(fmt.Sprint(args...))
+}
+
+// Warningln logs the arguments as fmt.Sprintln does
+func (g *GRPCLogger) Warningln(args ...interface{}) {
+	g.warn(sprintln(args...))
+}
+
+// Warningf logs the arguments as fmt.Sprintf does
+func (g *GRPCLogger) Warningf(format string, args ...interface{}) {
+	g.warn(fmt.Sprintf(format, args...))
+}
+
+// Error logs the arguments as fmt.Sprint does
+func (g *GRPCLogger) Error(args ...interface{}) {
+	g.error(fmt.Sprint(args...))
+}
+
+// Errorln logs the arguments as fmt.Sprintln does
+func (g *GRPCLogger) Errorln(args ...interface{}) {
+	g.error(sprintln(args...))
+}
+
+// Errorf logs the arguments as fmt.Sprintf does
+func (g *GRPCLogger) Errorf(format string, args ...interface{}) {
+	g.error(fmt.Sprintf(format, args...))
+}
+
+// Fatal logs the arguments as fmt.Sprint does, then exits
+func (g *GRPCLogger) Fatal(args ...interface{}) {
+	g.fatal(fmt.Sprint(args...))
+}
+
+// Fatalln logs the arguments as fmt.Sprintln does, then exits
+func (g *GRPCLogger) Fatalln(args ...interface{}) {
+	g.fatal(sprintln(args...))
+}
+
+// Fatalf logs the arguments as fmt.Sprintf does, then exits
+func (g *GRPCLogger) Fatalf(format string, args ...interface{}) {
+	g.fatal(fmt.Sprintf(format, args...))
+}
+
+// V reports whether messages at the given verbosity level are enabled
+func (g *GRPCLogger) V(l int) bool {
+	return g.logger.V(l).Enabled()
+}
+
+func (g *GRPCLogger) info(level int, msg string) {
+	g.logger.V(level).Info(msg)
+}
+
+func (g *GRPCLogger) warn(msg string) {
+	g.warnLogger.Info(msg)
+}
+
+func (g *GRPCLogger) error(msg string) {
+	g.logger.Error(errors.New(msg), GRPCErrorMessage)
+}
+
+// fatal logs the message and flushes any sinks buffering entries before exiting, as grpclog requires
+func (g *GRPCLogger) fatal(msg string) {
+	g.logger.Error(errors.New(msg), GRPCFatalMessage)
+	_ = Flush(g.logger)
+	os.Exit(1)
+}
+
+// sprintln formats the arguments as fmt.Sprintln does, without the trailing newline
+func sprintln(args ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
@@ -0,0 +1,165 @@
+package simplelogr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultHookTimeout is how long a hook may run for when no timeout is specified
+	DefaultHookTimeout = 5 * time.Second
+)
+
+// Hook is a function run at startup or shutdown, which should return promptly once the context is done
+type Hook func(ctx context.Context) error
+
+// Hooks is a registry of functions that components can register to run when logging starts up and shuts down, e.g. to
+// flush a remote destination or upload a final file segment. Hooks run in the order they were registered, each with
+// its own timeout, and any errors are passed to the ErrorHandler. It is safe for concurrent use.
+//
+// Hooks are typically attached to a Logger using Options.Hooks, and run using Start and Shutdown.
+type Hooks struct {
+	options HooksOptions
+
+	lock     sync.Mutex
+	startup  []registeredHook
+	shutdown []registeredHook
+}
+
+type registeredHook struct {
+	name    string
+	timeout time.Duration
+	hook    Hook
+}
+
+// NewHooks creates a new, empty, Hooks registry with the provided options
+func NewHooks(opts HooksOptions) *Hooks {
+	return &Hooks{
+		options: opts,
+	}
+}
+
+// OnStartup registers a hook to run at startup, a zero timeout uses HooksOptions.Timeout
+func (h *Hooks) OnStartup(name string, timeout time.Duration, hook Hook) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.startup = append(h.startup, registeredHook{name: name, timeout: timeout, hook: hook})
+}
+
+// OnShutdown registers a hook to run at shutdown, a zero timeout uses HooksOptions.Timeout
+func (h *Hooks) OnShutdown(name string, timeout time.Duration, hook Hook) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.shutdown = append(h.shutdown, registeredHook{name: name, timeout: timeout, hook: hook})
+}
+
+// Startup runs the startup hooks, returning the errors of any that failed
+func (h *Hooks) Startup(ctx context.Context) error {
+	h.lock.Lock()
+	hooks := h.startup
+	h.lock.Unlock()
+
+	return h.run(ctx, "startup", hooks)
+}
+
+// Shutdown runs the shutdown hooks, returning the errors of any that failed
+func (h *Hooks) Shutdown(ctx context.Context) error {
+	h.lock.Lock()
+	hooks := h.shutdown
+	h.lock.Unlock()
+
+	return h.run(ctx, "shutdown", hooks)
+}
+
+func (h *Hooks) run(ctx context.Context, phase string, hooks []registeredHook) error {
+	var errs multiError
+	for _, hook := range hooks {
+		if err := h.runOne(ctx, hook); err != nil {
+			err = fmt.Errorf("%s hook %q failed: %w", phase, hook.name, err)
+			h.options.ErrorHandler(err)
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// runOne runs a hook, giving up waiting for it once its timeout has passed even if it does not respect its context
+func (h *Hooks) runOne(ctx context.Context, hook registeredHook) error {
+	timeout := hook.timeout
+	if timeout <= 0 {
+		timeout = h.options.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- hook.hook(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HooksOptions configures the behaviour of a Hooks registry
+type HooksOptions struct {
+	// Timeout is how long each hook may run for, unless a timeout is given when it is registered
+	Timeout time.Duration
+	// ErrorHandler is called with the error of each hook that fails
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (h *HooksOptions) AssertDefaults() {
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultHookTimeout
+	}
+
+	if h.ErrorHandler == nil {
+		h.ErrorHandler = DefaultErrorHandler
+	}
+}
+
+// Start runs the startup hooks of the given logr.Logger, if it is backed by a Logger with Options.Hooks
+func Start(ctx context.Context, logger logr.Logger) error {
+	if l, ok := logger.GetSink().(*Logger); ok && l.options.Hooks != nil {
+		return l.options.Hooks.Startup(ctx)
+	}
+	return nil
+}
+
+// Shutdown runs the shutdown hooks of the given logr.Logger, if it is backed by a Logger with Options.Hooks, and then
+// closes its sinks (see Close) so that any buffered entries, including those logged by the hooks, are emitted. The
+// logger must not be used afterwards.
+func Shutdown(ctx context.Context, logger logr.Logger) error {
+	var errs multiError
+	if l, ok := logger.GetSink().(*Logger); ok && l.options.Hooks != nil {
+		if err := l.options.Hooks.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := Close(logger); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
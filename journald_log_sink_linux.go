@@ -0,0 +1,200 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// DefaultJournaldSocket is the path of the systemd journal's native protocol socket
+	DefaultJournaldSocket = "/run/systemd/journal/socket"
+)
+
+// JournaldLogSink emits log Entry objects to the systemd journal using its native protocol, storing key-value pairs
+// as journal fields so that they can be queried using journalctl
+type JournaldLogSink struct {
+	options JournaldLogSinkOptions
+	lock    sync.Mutex
+	conn    net.Conn
+}
+
+// NewJournaldLogSink creates a new JournaldLogSink with the provided options, the journal socket is connected to
+// lazily when the first Entry is logged
+func NewJournaldLogSink(opts JournaldLogSinkOptions) *JournaldLogSink {
+	return &JournaldLogSink{
+		options: opts,
+	}
+}
+
+// JournaldAvailable reports whether the current process appears to have been started by systemd with its output
+// connected to the journal
+func JournaldAvailable() bool {
+	if os.Getenv("JOURNAL_STREAM") == "" && os.Getenv("INVOCATION_ID") == "" {
+		return false
+	}
+	_, err := os.Stat(DefaultJournaldSocket)
+	return err == nil
+}
+
+// Log implements LogSink, encoding the given Entry as journal fields before sending it to the journal
+func (j *JournaldLogSink) Log(e Entry) error {
+	buffer := bytes.Buffer{}
+
+	writeJournaldField(&buffer, "MESSAGE", e.Message)
+	writeJournaldField(&buffer, "PRIORITY", fmt.Sprint(j.options.PriorityEncoder(e.Level, e.Error)))
+	writeJournaldField(&buffer, "SYSLOG_IDENTIFIER", j.options.Identifier)
+
+	if len(e.Names) > 0 {
+		writeJournaldField(&buffer, "LOGGER", j.options.NameEncoder(e.Names))
+	}
+
+	if e.Error != nil {
+		encodedErr := e.encodeError(j.options.ErrorEncoder)
+		writeJournaldField(&buffer, "ERROR", encodedErr.Message)
+		if encodedErr.StackTrace != "" {
+			writeJournaldField(&buffer, "STACK_TRACE", encodedErr.StackTrace)
+		}
+	}
+
+	e.KVs = flattenGroups(e.KVs)
+	for i := 0; i < len(e.KVs); i += 2 {
+		k := e.KVs[i]
+		v := resolveValue(e.KVs[i+1])
+
+		kStr, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("logging keys must be strings, got %T: %v", k, k)
+		}
+
+		vStr, ok := v.(string)
+		if !ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			vStr = string(b)
+		}
+
+		writeJournaldField(&buffer, journaldFieldName(kStr), vStr)
+	}
+
+	return j.send(buffer.Bytes())
+}
+
+// Close closes the connection to the journal
+func (j *JournaldLogSink) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+func (j *JournaldLogSink) send(datagram []byte) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.conn == nil {
+		conn, err := net.Dial("unixgram", j.options.SocketPath)
+		if err != nil {
+			return fmt.Errorf("failed to connect to journal: %w", err)
+		}
+		j.conn = conn
+	}
+
+	if _, err := j.conn.Write(datagram); err != nil {
+		_ = j.conn.Close()
+		j.conn = nil
+		return fmt.Errorf("failed to write to journal: %w", err)
+	}
+
+	return nil
+}
+
+// writeJournaldField writes a field using the native protocol, values containing newlines use the binary length
+// prefixed form
+func writeJournaldField(buffer *bytes.Buffer, name string, value string) {
+	buffer.WriteString(name)
+	if strings.ContainsRune(value, '\n') {
+		buffer.WriteByte('\n')
+		_ = binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buffer.WriteByte('=')
+	}
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
+}
+
+// journaldFieldName converts a key into a valid journal field name, which may only contain uppercase letters, digits
+// and underscores, and may not begin with an underscore
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "KEY_" + name
+	}
+
+	return name
+}
+
+var _ LogSink = (*JournaldLogSink)(nil)
+var _ CloserSink = (*JournaldLogSink)(nil)
+
+// JournaldLogSinkOptions configures the behaviour of a JournaldLogSink
+type JournaldLogSinkOptions struct {
+	// SocketPath is the path of the journal's native protocol socket
+	SocketPath string
+	// Identifier is stored in the SYSLOG_IDENTIFIER field, identifying the program that logged the entry
+	Identifier string
+	// PriorityEncoder identifies the syslog priority based on the verbosity level and the presence of any errors
+	PriorityEncoder func(level int, err error) int
+	// NameEncoder collapses the series of Logger names down into one string, stored in the LOGGER field
+	NameEncoder func(names []string) string
+	// ErrorEncoder extracts loggable EncodedError information from an error
+	ErrorEncoder func(err error) EncodedError
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (j *JournaldLogSinkOptions) AssertDefaults() {
+	if j.SocketPath == "" {
+		j.SocketPath = DefaultJournaldSocket
+	}
+
+	if j.Identifier == "" {
+		j.Identifier = filepath.Base(os.Args[0])
+	}
+
+	if j.PriorityEncoder == nil {
+		j.PriorityEncoder = DefaultSyslogPriorityEncoder
+	}
+
+	if j.NameEncoder == nil {
+		j.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+
+	if j.ErrorEncoder == nil {
+		j.ErrorEncoder = DefaultErrorEncoder
+	}
+}
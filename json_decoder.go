@@ -0,0 +1,200 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONDecoder reads back log Entry objects from the output of a JSONLogSink, one JSON object per line, including
+// output framed with checksums by a RotatingFileOutput. Decoded entries have their Entry.Severity set from the logged
+// severity name, and all fields other than the well known ones become key-value pairs, sorted by key. Numbers are
+// decoded as json.Number to preserve their precision.
+type JSONDecoder struct {
+	options JSONDecoderOptions
+	reader  *frameReader
+	skipped int
+}
+
+// NewJSONDecoder creates a new JSONDecoder reading from the given io.Reader
+func NewJSONDecoder(r io.Reader, opts JSONDecoderOptions) *JSONDecoder {
+	return &JSONDecoder{
+		options: opts,
+		reader:  newFrameReader(r),
+	}
+}
+
+// Next decodes the next Entry, returning io.EOF once there are no more. An entry that cannot be decoded or fails
+// verification results in an error (wrapping ErrCorruptEntry or ErrCorruptSegment if corruption was detected), after
+// which Next may be called again to continue with the following entry, unless JSONDecoderOptions.SkipCorrupt is set.
+// Any other error reading the underlying io.Reader (e.g. io.ErrUnexpectedEOF from a truncated gzip segment) is always
+// returned, as it cannot be skipped.
+func (d *JSONDecoder) Next() (Entry, error) {
+	for {
+		line, err := d.reader.next()
+		if errors.Is(err, ErrCorruptEntry) || errors.Is(err, ErrCorruptSegment) {
+			if !d.options.SkipCorrupt {
+				return Entry{}, err
+			}
+			d.skipped++
+			continue
+		} else if err != nil {
+			return Entry{}, err
+		}
+
+		e, err := d.decode(line)
+		if err != nil && d.options.SkipCorrupt {
+			d.skipped++
+			continue
+		}
+		return e, err
+	}
+}
+
+// Skipped returns the number of corrupt or undecodable entries skipped, see JSONDecoderOptions.SkipCorrupt
+func (d *JSONDecoder) Skipped() int {
+	return d.skipped
+}
+
+func (d *JSONDecoder) decode(line []byte) (Entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode log entry: %w", err)
+	}
+
+	e := Entry{}
+	fields := map[string]interface{}{}
+	for k, v := range obj {
+		if k == d.options.LevelKey && k != "" {
+			// the numeric level is derived from the level and error, which are restored from the other fields
+			continue
+		}
+
+		var err error
+		switch k {
+		case d.options.TimestampKey:
+			e.Timestamp, err = d.options.TimestampDecoder(v)
+		case d.options.SeverityKey:
+			e.Severity = fmt.Sprint(v)
+		case d.options.NameKey:
+			e.Names = strings.Split(fmt.Sprint(v), d.options.NameSeparator)
+		case d.options.MessageKey:
+			e.Message = fmt.Sprint(v)
+		case d.options.ErrorKey:
+			if structured, ok := v.(map[string]interface{}); ok {
+				v = structured["message"]
+			}
+			e.Error = errors.New(fmt.Sprint(v))
+		case d.options.SequenceKey:
+			e.Sequence, err = strconv.ParseUint(fmt.Sprint(v), 10, 64)
+		case d.options.KVsKey:
+			if kvs, ok := v.(map[string]interface{}); ok {
+				for nestedK, nestedV := range kvs {
+					fields[nestedK] = nestedV
+				}
+				continue
+			}
+			fields[k] = v
+		default:
+			fields[k] = v
+		}
+		if err != nil {
+			return Entry{}, fmt.Errorf("failed to decode log entry field %q: %w", k, err)
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	e.KVs = make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		e.KVs = append(e.KVs, k, fields[k])
+	}
+
+	return e, nil
+}
+
+// JSONDecoderOptions configures the behaviour of a JSONDecoder, and should mirror the JSONLogSinkOptions used to
+// produce the decoded output
+type JSONDecoderOptions struct {
+	// SeverityKey determines the top level JSON object key the log severity name is stored in
+	SeverityKey string
+	// LevelKey, if specified, determines the top level JSON object key any numeric level is stored in, which is
+	// discarded when decoding
+	LevelKey string
+	// NameKey determines the top level JSON object key the logger name is stored in
+	NameKey string
+	// NameSeparator splits the logger name back into the series of Logger names
+	NameSeparator string
+	// MessageKey determines the top level JSON object key the log message is stored in
+	MessageKey string
+	// TimestampKey determines the top level JSON object key the timestamp is stored in
+	TimestampKey string
+	// TimestampDecoder parses timestamps from their encoded representations
+	TimestampDecoder func(v interface{}) (time.Time, error)
+	// ErrorKey determines the top level JSON object key any error messages are stored in
+	ErrorKey string
+	// SequenceKey determines the top level JSON object key any sequence number is stored in
+	SequenceKey string
+	// KVsKey, if specified, determines the top level JSON object key that key-value pairs are nested under
+	KVsKey string
+	// SkipCorrupt skips entries that cannot be decoded or fail verification (e.g. those only partially written before
+	// a crash) rather than returning an error, see JSONDecoder.Skipped
+	SkipCorrupt bool
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (j *JSONDecoderOptions) AssertDefaults() {
+	if j.SeverityKey == "" {
+		j.SeverityKey = DefaultSeverityKey
+	}
+
+	if j.NameKey == "" {
+		j.NameKey = DefaultNameKey
+	}
+	if j.NameSeparator == "" {
+		j.NameSeparator = DefaultNameSeparator
+	}
+
+	if j.MessageKey == "" {
+		j.MessageKey = DefaultMessageKey
+	}
+
+	if j.TimestampKey == "" {
+		j.TimestampKey = DefaultTimestampKey
+	}
+	if j.TimestampDecoder == nil {
+		j.TimestampDecoder = DefaultTimestampDecoder(DefaultTimestampFormat)
+	}
+
+	if j.ErrorKey == "" {
+		j.ErrorKey = DefaultErrorKey
+	}
+
+	if j.SequenceKey == "" {
+		j.SequenceKey = DefaultSequenceKey
+	}
+}
+
+// DefaultTimestampDecoder creates a timestamp decoder parsing strings using the given formatting string
+func DefaultTimestampDecoder(format string) func(v interface{}) (time.Time, error) {
+	return func(v interface{}) (time.Time, error) {
+		s, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("expected timestamp string, got %T", v)
+		}
+		return time.Parse(format, s)
+	}
+}
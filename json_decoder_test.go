@@ -0,0 +1,40 @@
+package simplelogr
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJSONDecoderSkipCorruptReturnsReadErrors(t *testing.T) {
+	opts := JSONDecoderOptions{SkipCorrupt: true}
+	opts.AssertDefaults()
+
+	// a truncated gzip segment keeps returning io.ErrUnexpectedEOF however many times it is read
+	reader := io.MultiReader(
+		strings.NewReader("{\"msg\":\"intact\"}\nnot json\n{\"msg\":\"partial"),
+		&failingReader{err: io.ErrUnexpectedEOF},
+	)
+	decoder := NewJSONDecoder(reader, opts)
+
+	e, err := decoder.Next()
+	if err != nil || e.Message != "intact" {
+		t.Fatalf("expected the intact entry, got %v (%v)", e, err)
+	}
+
+	if _, err := decoder.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected the read error to be returned, got %v", err)
+	}
+	if decoder.Skipped() != 2 {
+		t.Errorf("expected the undecodable and truncated entries to be skipped, got %d", decoder.Skipped())
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read([]byte) (int, error) {
+	return 0, f.err
+}
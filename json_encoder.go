@@ -0,0 +1,215 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The append-style encoding methods below produce output identical to encoding/json (including its escaping of HTML
+// characters, unless disabled) for the most commonly logged types without allocating, falling back to a marshal
+// function for the rest.
+
+const hexDigits = "0123456789abcdef"
+
+// jsonEncoder holds the options that affect how values are encoded as JSON
+type jsonEncoder struct {
+	// escapeHTML escapes <, > and & in strings, as encoding/json does by default
+	escapeHTML bool
+	// marshal encodes values that are not encoded directly
+	marshal func(v interface{}) ([]byte, error)
+}
+
+// defaultJSONEncoder encodes values exactly as encoding/json does
+var defaultJSONEncoder = jsonEncoder{
+	escapeHTML: true,
+	marshal:    json.Marshal,
+}
+
+// newJSONEncoder creates a jsonEncoder, defaulting to encoding/json (with or without HTML escaping) if no marshal
+// function is given
+func newJSONEncoder(escapeHTML bool, marshal func(v interface{}) ([]byte, error)) jsonEncoder {
+	if marshal == nil {
+		marshal = json.Marshal
+		if !escapeHTML {
+			marshal = marshalJSONWithoutHTMLEscaping
+		}
+	}
+
+	return jsonEncoder{
+		escapeHTML: escapeHTML,
+		marshal:    marshal,
+	}
+}
+
+// marshalJSONWithoutHTMLEscaping is json.Marshal without the escaping of <, > and & in strings
+func marshalJSONWithoutHTMLEscaping(v interface{}) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'}), nil
+}
+
+// appendString appends the JSON encoding of a string, escaped as encoding/json does
+func (e jsonEncoder) appendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && (!e.escapeHTML || c != '<' && c != '>' && c != '&') {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// appendJSONFloat appends the JSON encoding of a finite float, formatted as encoding/json does
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// appendValue appends the JSON encoding of any value
+func (e jsonEncoder) appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case string:
+		return e.appendString(buf, value), nil
+	case bool:
+		return strconv.AppendBool(buf, value), nil
+	case int:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int8:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int16:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int32:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int64:
+		return strconv.AppendInt(buf, value, 10), nil
+	case uint:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint8:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint16:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint32:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, value, 10), nil
+	case float32:
+		if f := float64(value); !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return appendJSONFloat(buf, f, 32), nil
+		}
+	case float64:
+		if !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return appendJSONFloat(buf, value, 64), nil
+		}
+	case *jsonObject:
+		return e.appendObject(buf, value)
+	case EncodedError:
+		return e.appendError(buf, value), nil
+	case *placeholderValue:
+		return e.appendString(buf, value.placeholder), value
+	}
+
+	b, err := e.marshalSafely(v)
+	if err != nil {
+		placeholder, ok := err.(*placeholderValue)
+		if !ok {
+			placeholder = errorPlaceholder(v, err)
+		}
+		return e.appendString(buf, placeholder.placeholder), placeholder
+	}
+	return append(buf, b...), nil
+}
+
+// marshalSafely encodes the value using the marshal function, returning a placeholderValue as the error if it panics
+func (e jsonEncoder) marshalSafely(v interface{}) (b []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			b, err = nil, panicPlaceholder(v, recovered)
+		}
+	}()
+	return e.marshal(v)
+}
+
+// appendObject appends the JSON encoding of an object, with its fields in order. Values replaced by placeholders are
+// returned as placeholderErrors once the object is complete
+func (e jsonEncoder) appendObject(buf []byte, o *jsonObject) ([]byte, error) {
+	var placeholders placeholderErrors
+	buf = append(buf, '{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = e.appendString(buf, key)
+		buf = append(buf, ':')
+
+		var err error
+		if buf, err = e.appendValue(buf, o.values[key]); err != nil && !placeholders.collect(err) {
+			return buf, err
+		}
+	}
+	return append(buf, '}'), placeholders.err()
+}
@@ -0,0 +1,37 @@
+package simplelogr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONEncoderAppendStringMatchesEncodingJSON(t *testing.T) {
+	strs := []string{
+		"",
+		"plain",
+		"quote \" backslash \\ slash /",
+		"control \n\r\t\b\f\x00\x1f",
+		"html <b>&amp;</b>",
+		"unicode é ✓ 😀",
+		"separators    ",
+		"invalid \xff utf-8 \xc3",
+		"\xed\xa0\x80 surrogate",
+	}
+
+	for _, escapeHTML := range []bool{true, false} {
+		encoder := newJSONEncoder(escapeHTML, nil)
+		for _, s := range strs {
+			expected, err := encoder.marshal(s)
+			if err != nil {
+				t.Fatalf("failed to marshal %q: %v", s, err)
+			}
+			// encoding/json escapes invalid UTF-8 as \ufffd, unless built on encoding/json/v2 (GOEXPERIMENT=jsonv2),
+			// which writes the replacement character unescaped
+			normalised := strings.ReplaceAll(string(expected), "\ufffd", `\ufffd`)
+
+			if actual := encoder.appendString(nil, s); string(actual) != normalised {
+				t.Errorf("expected %q to be encoded as %s (escapeHTML=%v), got %s", s, normalised, escapeHTML, actual)
+			}
+		}
+	}
+}
@@ -1,75 +1,598 @@
 package simplelogr
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
 	"time"
-
-	"github.com/pkg/errors"
+	"unicode/utf8"
 )
 
+// maxStreamedKVs is the number of key-value pairs above which checking for duplicate keys while streaming becomes more
+// expensive than building a jsonObject
+const maxStreamedKVs = 32
+
+// jsonLogSinks counts the JSONLogSink objects created, to identify the values each pre-encodes
+var jsonLogSinks uint64
+
 // JSONLogSink emits structured JSON representations of log Entry objects
 type JSONLogSink struct {
 	options JSONLogSinkOptions
+	// staticKeys are the keys of the StaticFields, sorted
+	staticKeys []string
+	encoder    jsonEncoder
+	// preEncodedFormat identifies the values pre-encoded by this sink, as comma separated JSON object members, which
+	// depend on its options
+	preEncodedFormat string
 }
 
 // NewJSONLogSink creates a new JSONLogSink with the provided options
 func NewJSONLogSink(options JSONLogSinkOptions) *JSONLogSink {
+	staticKeys := make([]string, 0, len(options.StaticFields))
+	for k := range options.StaticFields {
+		staticKeys = append(staticKeys, k)
+	}
+	sort.Strings(staticKeys)
+
 	return &JSONLogSink{
-		options: options,
+		options:          options,
+		staticKeys:       staticKeys,
+		encoder:          newJSONEncoder(!options.DisableHTMLEscaping, options.Marshal),
+		preEncodedFormat: fmt.Sprintf("json/%d", atomic.AddUint64(&jsonLogSinks, 1)),
 	}
 }
 
 // Log implements LogSink, encoding the given Entry as JSON before writing it to the configured io.Writer
 func (j JSONLogSink) Log(e Entry) error {
-	obj := map[string]interface{}{}
+	return j.Encode(j.options.Output, e)
+}
+
+// Encode implements EntryEncoder, writing the JSON encoding of the given Entry to the given io.Writer
+func (j JSONLogSink) Encode(w io.Writer, e Entry) error {
+	var buf *[]byte
+	if j.options.Arena != nil {
+		buf = j.options.Arena.get()
+		defer j.options.Arena.put(buf)
+	} else {
+		buf = getBuffer()
+		defer putBuffer(buf)
+	}
+
+	var err error
+	if *buf, err = j.appendEntry(*buf, e); e.reportPlaceholders(err) != nil {
+		return err
+	}
+	if j.options.Indent != "" {
+		indented := bytes.Buffer{}
+		if err := json.Indent(&indented, *buf, "", j.options.Indent); err != nil {
+			return fmt.Errorf("failed to indent log entry: %w", err)
+		}
+		*buf = append((*buf)[:0], indented.Bytes()...)
+	}
+	*buf = append(*buf, '\n')
+
+	_, err = w.Write(*buf)
+	return err
+}
+
+// EntryDelimiter implements DelimitedEncoder, returning the newline ending each encoded Entry
+func (j JSONLogSink) EntryDelimiter() string {
+	return "\n"
+}
+
+// appendEntry appends the JSON encoding of the given Entry, streaming the fields straight into the buffer where
+// possible, and otherwise laying them out in a jsonObject first to resolve duplicate keys, sort them or expand them.
+// Values replaced by placeholders are returned as placeholderErrors once the entry is complete
+func (j JSONLogSink) appendEntry(buf []byte, e Entry) ([]byte, error) {
+	start := len(buf)
+	if !j.options.SortKeys && !j.options.ExpandDottedKeys && len(e.KVs) <= 2*maxStreamedKVs {
+		var ok bool
+		var err error
+		if buf, ok, err = j.streamEntry(buf, e); ok || (err != nil && !isPlaceholderError(err)) {
+			return buf, err
+		}
+		buf = buf[:start]
+	}
+
+	obj, err := j.fields(e)
+	if err != nil {
+		return buf, err
+	}
+
+	if buf, err = j.encoder.appendObject(buf, obj); err != nil && !isPlaceholderError(err) {
+		return buf[:start], fmt.Errorf("failed to encode log entry as JSON: %w", err)
+	}
+
+	return buf, err
+}
 
+// streamEntry appends the JSON encoding of the given Entry directly, in the same layout as fields, returning false if
+// any of the keys are duplicated (other than static fields, which are skipped as they are by fields)
+func (j JSONLogSink) streamEntry(buf []byte, e Entry) ([]byte, bool, error) {
+	var keysArray [16 + 2*maxStreamedKVs]string
+	keys := keysArray[:0]
+	has := func(key string) bool {
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	}
+	collided := false
+	appendKey := func(key string) {
+		collided = collided || has(key)
+		if buf[len(buf)-1] != '{' {
+			buf = append(buf, ',')
+		}
+		keys = append(keys, key)
+		buf = j.encoder.appendString(buf, key)
+		buf = append(buf, ':')
+	}
+	appendString := func(key, value string) {
+		appendKey(key)
+		buf = j.encoder.appendString(buf, value)
+	}
+
+	buf = append(buf, '{')
+
+	var err error
+	var placeholders placeholderErrors
 	if j.options.TimestampKey != "" {
-		obj[j.options.TimestampKey] = j.options.TimestampEncoder(e.Timestamp)
+		if epoch, ok := j.options.TimestampMode.epoch(e.Timestamp); ok {
+			appendKey(j.options.TimestampKey)
+			if buf, err = j.encoder.appendValue(buf, epoch); err != nil {
+				return buf, false, fmt.Errorf("failed to encode log entry as JSON: %w", err)
+			}
+		} else {
+			appendKey(j.options.TimestampKey)
+			buf = j.appendFormattedTimestamp(buf, e.Timestamp)
+		}
 	}
 
 	if j.options.SeverityKey != "" {
-		obj[j.options.SeverityKey] = j.options.SeverityEncoder(e.Level, e.Error)
+		appendString(j.options.SeverityKey, e.severity(j.options.SeverityEncoder))
+	}
+
+	if j.options.LevelKey != "" {
+		appendKey(j.options.LevelKey)
+		buf = strconv.AppendInt(buf, int64(j.options.LevelEncoder(e.Level, e.Error)), 10)
 	}
 
 	if len(e.Names) > 0 && j.options.NameKey != "" {
-		obj[j.options.NameKey] = j.options.NameEncoder(e.Names)
+		appendString(j.options.NameKey, j.options.NameEncoder(e.Names))
 	}
 
+	truncated := false
 	if e.Message != "" && j.options.MessageKey != "" {
-		obj[j.options.MessageKey] = e.Message
+		message, t := truncateString(e.Message, j.options.MaxMessageLength)
+		truncated = truncated || t
+		appendString(j.options.MessageKey, message)
 	}
 
 	if e.Error != nil && (j.options.ErrorKey != "" || j.options.StackTraceKey != "") {
-		encodedErr := j.options.ErrorEncoder(e.Error)
-		if j.options.ErrorKey != "" && encodedErr.Message != "" {
-			obj[j.options.ErrorKey] = encodedErr.Message
+		encodedErr := e.encodeError(j.options.ErrorEncoder)
+		if j.options.ErrorKey != "" && encodedErr.structured() {
+			appendKey(j.options.ErrorKey)
+			buf = j.encoder.appendError(buf, encodedErr)
+		} else {
+			if j.options.ErrorKey != "" && encodedErr.Message != "" {
+				message, t := truncateString(encodedErr.Message, j.options.MaxValueLength)
+				truncated = truncated || t
+				appendString(j.options.ErrorKey, message)
+			}
+			if j.options.StackTraceKey != "" && encodedErr.StackTrace != "" {
+				appendString(j.options.StackTraceKey, encodedErr.StackTrace)
+			}
+		}
+	}
+
+	if e.Caller != nil && j.options.CallerKey != "" {
+		appendKey(j.options.CallerKey)
+		if buf, err = j.encoder.appendValue(buf, j.options.CallerEncoder(*e.Caller)); err != nil && !placeholders.collect(err) {
+			return buf, false, fmt.Errorf("failed to encode log entry as JSON: %w", err)
+		}
+	}
+
+	if e.Sequence != 0 && j.options.SequenceKey != "" {
+		appendKey(j.options.SequenceKey)
+		buf = strconv.AppendUint(buf, e.Sequence, 10)
+	}
+
+	for _, k := range j.staticKeys {
+		if has(k) {
+			continue
+		}
+		appendKey(k)
+		if buf, err = j.encoder.appendValue(buf, j.options.StaticFields[k]); err != nil && !placeholders.collect(err) {
+			return buf, false, fmt.Errorf("failed to encode log entry as JSON: %w", err)
 		}
-		if j.options.StackTraceKey != "" && encodedErr.StackTrace != "" {
-			obj[j.options.StackTraceKey] = encodedErr.StackTrace
+	}
+
+	kvsKey := j.options.KVsKey
+	if kvsKey == "" && j.options.DuplicateKeys == DuplicateKeysNest {
+		kvsKey = DefaultKVsKey
+	}
+	nested := kvsKey != "" && len(e.KVs) > 0
+	if nested {
+		appendKey(kvsKey)
+		buf = append(buf, '{')
+		keys = keys[:0]
+	}
+
+	kvs := e.KVs
+	if e.PreEncoded.valid(j.preEncodedFormat, kvs) {
+		if buf[len(buf)-1] != '{' {
+			buf = append(buf, ',')
+		}
+		for _, k := range e.PreEncoded.Keys {
+			if collided = collided || has(k); collided {
+				return buf, false, nil
+			}
+			keys = append(keys, k)
+		}
+		buf = append(buf, e.PreEncoded.Data...)
+		kvs = kvs[e.PreEncoded.Count:]
+	}
+
+	// groups are closed at the end of the entry, or removed if they are empty
+	type openGroup struct {
+		start   int
+		content int
+	}
+	var groupsArray [8]openGroup
+	groups := groupsArray[:0]
+
+	for i := 0; i < len(kvs); i += 2 {
+		k, ok := kvs[i].(string)
+		if !ok {
+			return buf, false, fmt.Errorf("logging keys must be strings, got %T: %v", kvs[i], kvs[i])
+		}
+		start := len(buf)
+		if appendKey(k); collided {
+			return buf, false, nil
+		}
+		if isGroup(kvs[i+1]) {
+			buf = append(buf, '{')
+			groups = append(groups, openGroup{start: start, content: len(buf)})
+			keys = keys[:0]
+			continue
+		}
+		var t bool
+		if buf, t, err = j.appendTruncatedValue(buf, j.value(kvs[i+1])); err != nil && !placeholders.collect(err) {
+			return buf, false, fmt.Errorf("failed to encode log entry as JSON: %w", err)
+		}
+		truncated = truncated || t
+	}
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		if len(buf) == groups[i].content {
+			buf = buf[:groups[i].start]
+		} else {
+			buf = append(buf, '}')
+		}
+	}
+
+	if nested {
+		buf = append(buf, '}')
+	}
+
+	if truncated && j.options.TruncatedKey != "" {
+		appendKey(j.options.TruncatedKey)
+		buf = append(buf, "true"...)
+	}
+
+	return append(buf, '}'), !collided, placeholders.err()
+}
+
+// PreEncode implements PreEncoder, encoding the key-value pairs as JSON object members so that they can be spliced
+// into entries that do not need their keys sorted or duplicates resolved
+func (j JSONLogSink) PreEncode(keysAndValues []interface{}) (*PreEncodedValues, error) {
+	preEncoded := &PreEncodedValues{
+		Format: j.preEncodedFormat,
+		Count:  len(keysAndValues),
+		Keys:   make([]string, 0, len(keysAndValues)/2),
+	}
+
+	var err error
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		k, ok := keysAndValues[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("logging keys must be strings, got %T: %v", keysAndValues[i], keysAndValues[i])
+		}
+		v := keysAndValues[i+1]
+		if isLazyValue(v) || isGroup(v) {
+			// groups must be closed at the end of the entry, after key-value pairs which are not known yet
+			return nil, nil
+		}
+
+		if i > 0 {
+			preEncoded.Data = append(preEncoded.Data, ',')
+		}
+		preEncoded.Keys = append(preEncoded.Keys, k)
+		preEncoded.Data = j.encoder.appendString(preEncoded.Data, k)
+		preEncoded.Data = append(preEncoded.Data, ':')
+		var truncated bool
+		if preEncoded.Data, truncated, err = j.appendTruncatedValue(preEncoded.Data, j.value(v)); err != nil {
+			return nil, fmt.Errorf("failed to encode log entry as JSON: %w", err)
+		}
+		if truncated {
+			// entries must be marked as truncated, so are left to be encoded in full
+			return nil, nil
 		}
 	}
 
+	return preEncoded, nil
+}
+
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (j JSONLogSink) Flush() error {
+	return flushWriter(j.options.Output)
+}
+
+// timestamp represents the timestamp according to the TimestampMode, TimestampLocation and TimestampFormat
+func (j JSONLogSink) timestamp(t time.Time) interface{} {
+	if epoch, ok := j.options.TimestampMode.epoch(t); ok {
+		return epoch
+	}
+	if j.options.TimestampFormat != "" {
+		if j.options.TimestampLocation != nil {
+			t = t.In(j.options.TimestampLocation)
+		}
+		return t.Format(j.options.TimestampFormat)
+	}
+	return formatTimestamp(t, j.options.TimestampLocation, j.options.TimestampEncoder)
+}
+
+// appendFormattedTimestamp appends the formatted timestamp as a JSON string. With a TimestampFormat it is formatted
+// straight into the buffer, escaping it afterwards in the unlikely event that the format produced characters that
+// need it
+func (j JSONLogSink) appendFormattedTimestamp(buf []byte, t time.Time) []byte {
+	if j.options.TimestampFormat == "" {
+		return j.encoder.appendString(buf, formatTimestamp(t, j.options.TimestampLocation, j.options.TimestampEncoder))
+	}
+
+	if j.options.TimestampLocation != nil {
+		t = t.In(j.options.TimestampLocation)
+	}
+	start := len(buf)
+	buf = append(buf, '"')
+	buf = t.AppendFormat(buf, j.options.TimestampFormat)
+	for _, c := range buf[start+1:] {
+		if c < ' ' || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			formatted := string(buf[start+1:])
+			return j.encoder.appendString(buf[:start], formatted)
+		}
+	}
+	return append(buf, '"')
+}
+
+// fields lays out the given Entry as the fields of a JSON object, in a consistent order: the fields added by the
+// sink, then any static fields (sorted by key), then the key-value pairs in the order they were given
+func (j JSONLogSink) fields(e Entry) (*jsonObject, error) {
+	obj := newJSONObject(6 + len(j.options.StaticFields) + len(e.KVs)/2)
+
+	if j.options.TimestampKey != "" {
+		obj.set(j.options.TimestampKey, j.timestamp(e.Timestamp))
+	}
+
+	if j.options.SeverityKey != "" {
+		obj.set(j.options.SeverityKey, e.severity(j.options.SeverityEncoder))
+	}
+
+	if j.options.LevelKey != "" {
+		obj.set(j.options.LevelKey, j.options.LevelEncoder(e.Level, e.Error))
+	}
+
+	if len(e.Names) > 0 && j.options.NameKey != "" {
+		obj.set(j.options.NameKey, j.options.NameEncoder(e.Names))
+	}
+
+	truncated := false
+	if e.Message != "" && j.options.MessageKey != "" {
+		message, t := truncateString(e.Message, j.options.MaxMessageLength)
+		truncated = truncated || t
+		obj.set(j.options.MessageKey, message)
+	}
+
+	if e.Error != nil && (j.options.ErrorKey != "" || j.options.StackTraceKey != "") {
+		encodedErr := e.encodeError(j.options.ErrorEncoder)
+		if j.options.ErrorKey != "" && encodedErr.structured() {
+			obj.set(j.options.ErrorKey, encodedErr)
+		} else {
+			if j.options.ErrorKey != "" && encodedErr.Message != "" {
+				message, t := truncateString(encodedErr.Message, j.options.MaxValueLength)
+				truncated = truncated || t
+				obj.set(j.options.ErrorKey, message)
+			}
+			if j.options.StackTraceKey != "" && encodedErr.StackTrace != "" {
+				obj.set(j.options.StackTraceKey, encodedErr.StackTrace)
+			}
+		}
+	}
+
+	if e.Caller != nil && j.options.CallerKey != "" {
+		obj.set(j.options.CallerKey, j.options.CallerEncoder(*e.Caller))
+	}
+
+	if e.Sequence != 0 && j.options.SequenceKey != "" {
+		obj.set(j.options.SequenceKey, e.Sequence)
+	}
+
+	for _, k := range j.staticKeys {
+		if !obj.has(k) {
+			obj.set(k, j.options.StaticFields[k])
+		}
+	}
+
+	kvs := obj
+	kvsKey := j.options.KVsKey
+	if kvsKey == "" && j.options.DuplicateKeys == DuplicateKeysNest {
+		kvsKey = DefaultKVsKey
+	}
+	if kvsKey != "" && len(e.KVs) > 0 {
+		kvs = newJSONObject(len(e.KVs) / 2)
+		obj.set(kvsKey, kvs)
+	}
+	top, kvsStart := kvs, len(kvs.keys)
+
+	type group struct {
+		parent *jsonObject
+		key    string
+		object *jsonObject
+	}
+	var groups []group
+
 	for i := 0; i < len(e.KVs); i += 2 {
 		k := e.KVs[i]
 		v := e.KVs[i+1]
 
 		kStr, ok := k.(string)
 		if !ok {
-			return errors.Errorf("logging keys must be strings, got %T: %v", k, k)
+			return nil, fmt.Errorf("logging keys must be strings, got %T: %v", k, k)
 		}
 
-		obj[kStr] = v
+		if isGroup(v) {
+			if kvs.has(kStr) {
+				kStr = uniqueKey(kvs, kStr)
+			}
+			g := group{parent: kvs, key: kStr, object: newJSONObject(len(e.KVs)/2 - i/2)}
+			kvs.set(kStr, g.object)
+			groups = append(groups, g)
+			kvs = g.object
+			continue
+		}
+
+		if kvs.has(kStr) {
+			switch j.options.DuplicateKeys {
+			case DuplicateKeysFirstWins:
+				continue
+			case DuplicateKeysSuffix:
+				kStr = uniqueKey(kvs, kStr)
+			}
+		}
+
+		value, t := j.truncatedValue(j.value(v))
+		truncated = truncated || t
+		kvs.set(kStr, value)
 	}
 
-	if err := json.NewEncoder(j.options.Output).Encode(obj); err != nil {
-		return errors.Wrap(err, "failed to encode log entry as JSON")
+	for i := len(groups) - 1; i >= 0; i-- {
+		if len(groups[i].object.keys) == 0 {
+			groups[i].parent.delete(groups[i].key)
+		}
 	}
 
-	return nil
+	if j.options.SortKeys {
+		sort.Strings(top.keys[kvsStart:])
+		for _, g := range groups {
+			sort.Strings(g.object.keys)
+		}
+	}
+
+	if truncated && j.options.TruncatedKey != "" && !obj.has(j.options.TruncatedKey) {
+		obj.set(j.options.TruncatedKey, true)
+	}
+
+	if j.options.ExpandDottedKeys {
+		obj = expandDottedKeys(obj, j.options.SortKeys)
+	}
+
+	return obj, nil
+}
+
+// value resolves the value of a key-value pair, encoding []byte, time.Time and time.Duration values according to the
+// options
+func (j JSONLogSink) value(v interface{}) interface{} {
+	v = resolveValue(v)
+	switch value := v.(type) {
+	case time.Time:
+		if j.options.FormatTimeValues {
+			return j.timestamp(value)
+		}
+	case time.Duration:
+		if j.options.DurationEncoder != nil {
+			return j.options.DurationEncoder(value)
+		}
+	}
+	return encodeBytes(v, j.options.BytesEncoder)
 }
 
+// appendTruncatedValue appends the JSON encoding of the value, truncated to MaxValueLength. Strings are truncated
+// before being encoded, while values encoding to anything longer are replaced by a string of their truncated encoding
+func (j JSONLogSink) appendTruncatedValue(buf []byte, v interface{}) ([]byte, bool, error) {
+	if j.options.MaxValueLength <= 0 {
+		buf, err := j.encoder.appendValue(buf, v)
+		return buf, false, err
+	}
+
+	if s, ok := v.(string); ok {
+		s, truncated := truncateString(s, j.options.MaxValueLength)
+		return j.encoder.appendString(buf, s), truncated, nil
+	}
+
+	start := len(buf)
+	buf, err := j.encoder.appendValue(buf, v)
+	if err != nil || len(buf)-start <= j.options.MaxValueLength {
+		return buf, false, err
+	}
+	encoded, _ := truncateString(string(buf[start:]), j.options.MaxValueLength)
+	return j.encoder.appendString(buf[:start], encoded), true, nil
+}
+
+// truncatedValue truncates the value in the same way as appendTruncatedValue, for values laid out by fields
+func (j JSONLogSink) truncatedValue(v interface{}) (interface{}, bool) {
+	if j.options.MaxValueLength <= 0 {
+		return v, false
+	}
+
+	if s, ok := v.(string); ok {
+		return truncateString(s, j.options.MaxValueLength)
+	}
+
+	pooled := getBuffer()
+	defer putBuffer(pooled)
+	buf, err := j.encoder.appendValue(*pooled, v)
+	*pooled = buf
+	if err != nil || len(buf) <= j.options.MaxValueLength {
+		// values that fail to encode are left for the error to be reported when the entry is encoded
+		return v, false
+	}
+	return truncateString(string(buf), j.options.MaxValueLength)
+}
+
+// uniqueKey finds the first of key_2, key_3, ... that is not already present in the given object
+func uniqueKey(obj *jsonObject, key string) string {
+	for i := 2; ; i++ {
+		candidate := key + "_" + strconv.Itoa(i)
+		if !obj.has(candidate) {
+			return candidate
+		}
+	}
+}
+
+// DuplicateKeyPolicy determines how a JSONLogSink handles key-value pairs whose keys collide with earlier key-value
+// pairs (e.g. those added by Logger.WithValues) or the keys of the fields it adds itself (e.g. the message)
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysLastWins overwrites earlier values with later ones (in the earlier position), including the fields
+	// added by the sink
+	DuplicateKeysLastWins DuplicateKeyPolicy = iota
+	// DuplicateKeysFirstWins discards later values, so the fields added by the sink are never overwritten
+	DuplicateKeysFirstWins
+	// DuplicateKeysSuffix keeps every value, renaming later keys with a numeric suffix, e.g. key, key_2, key_3
+	DuplicateKeysSuffix
+	// DuplicateKeysNest nests key-value pairs under the KVsKey (or DefaultKVsKey if none is specified), isolating them
+	// from the fields added by the sink, with later values overwriting earlier ones
+	DuplicateKeysNest
+)
+
 // JSONLogSinkOptions configures the behaviour of a JSONLogSink
 type JSONLogSinkOptions struct {
 	// Output configures where to write structured JSON logs to
@@ -78,6 +601,12 @@ type JSONLogSinkOptions struct {
 	SeverityKey string
 	// SeverityEncoder identifies the severity name based on the verbosity level and the presence of any errors
 	SeverityEncoder func(level int, err error) string
+	// LevelKey, if specified, determines the top level JSON object key to store a numeric level in, alongside the
+	// severity name, so that downstream systems can filter entries numerically
+	LevelKey string
+	// LevelEncoder maps the verbosity level and the presence of any errors to the numeric level, e.g.
+	// DefaultSeverityNumberEncoder (OpenTelemetry) or DefaultSyslogPriorityEncoder (RFC 5424)
+	LevelEncoder func(level int, err error) int
 	// NameKey determines the top level JSON object key to store the logger name in
 	NameKey string
 	// NameEncoder collapses the series of Logger names down into one string for logging
@@ -88,12 +617,75 @@ type JSONLogSinkOptions struct {
 	TimestampKey string
 	// TimestampEncoder formats timestamps into string representations
 	TimestampEncoder func(t time.Time) string
-	// ErrorKey determines the top level JSON object key to store any error messages in
+	// TimestampFormat, if specified, is the layout timestamps are formatted with (see time.Time.Format) instead of
+	// using the TimestampEncoder, formatting them straight into the encoding buffer to avoid an allocation per entry
+	TimestampFormat string
+	// TimestampMode determines whether timestamps are formatted using the TimestampEncoder, or are numbers relative
+	// to the Unix epoch
+	TimestampMode TimestampMode
+	// TimestampLocation, if specified, is the time zone timestamps are converted to before being formatted, otherwise
+	// they are formatted as logged, which is in UTC for entries logged by a Logger
+	TimestampLocation *time.Location
+	// ErrorKey determines the top level JSON object key to store any error messages in. Errors encoded with a type,
+	// frames or causes (see StructuredErrorEncoder) are stored as a nested object under this key instead, including
+	// the stack trace
 	ErrorKey string
 	// StackTraceKey determines the top level JSON object key to store any stack trace information in
 	StackTraceKey string
-	// ErrorEncoder  extracts loggable EncodedError information from an error
+	// ErrorEncoder  extracts loggable EncodedError information from an error, e.g. StructuredErrorEncoder
 	ErrorEncoder func(err error) EncodedError
+	// SequenceKey determines the top level JSON object key to store the entry's sequence number in, when it has one
+	SequenceKey string
+	// CallerKey determines the top level JSON object key to store the caller information in, see Options.CaptureCaller
+	CallerKey string
+	// CallerEncoder converts caller information into a value for logging
+	CallerEncoder func(c Caller) interface{}
+	// KVsKey, if specified, determines the top level JSON object key that key-value pairs are nested under, rather
+	// than being stored at the top level
+	KVsKey string
+	// StaticFields are added to every JSON object, e.g. to identify the schema version
+	StaticFields map[string]interface{}
+	// DuplicateKeys determines how key-value pairs with duplicate keys are handled
+	DuplicateKeys DuplicateKeyPolicy
+	// SortKeys emits key-value pairs sorted by key, rather than in the order they were given
+	SortKeys bool
+	// ExpandDottedKeys nests fields with dotted keys in objects, e.g. "http.method" and "http.status" become
+	// {"http":{"method":...,"status":...}}, for schemas that expect nested documents (e.g. ECS or Datadog). This
+	// applies to every field, including those added by the sink (e.g. a SeverityKey of "log.level") and StaticFields.
+	// A field whose key is taken by an object created this way is suffixed with a number, as with DuplicateKeysSuffix.
+	// As with SortKeys, entries are laid out in full before being encoded, which is slower
+	ExpandDottedKeys bool
+	// DisableHTMLEscaping stops <, > and & in strings being escaped (as \u003c, \u003e and \u0026), which encoding/json
+	// does by default so that JSON can be embedded in HTML, but which makes URLs and the like harder to read
+	DisableHTMLEscaping bool
+	// MaxValueLength, if positive, limits the length in bytes of key-value pair values and error messages. Longer
+	// strings are truncated and followed by DefaultTruncationSuffix, while other values whose encoding is longer are
+	// replaced by a string of their truncated encoding. Entries with truncated values are marked using TruncatedKey
+	MaxValueLength int
+	// MaxMessageLength, if positive, limits the length in bytes of messages in the same way as MaxValueLength
+	MaxMessageLength int
+	// TruncatedKey determines the top level JSON object key used to mark entries in which a value or the message was
+	// truncated, with the value true
+	TruncatedKey string
+	// BytesEncoder converts []byte values into strings, e.g. HexBytesEncoder or LengthBytesEncoder. It applies only to
+	// the values of key-value pairs, not to []byte fields of maps, slices or structs, which are encoded as usual
+	BytesEncoder func(b []byte) string
+	// FormatTimeValues formats time.Time values of key-value pairs in the same way as the entry's timestamp, according
+	// to the TimestampMode, TimestampLocation and TimestampFormat or TimestampEncoder, rather than as RFC 3339
+	FormatTimeValues bool
+	// DurationEncoder, if specified, converts time.Duration values of key-value pairs into values for logging, e.g.
+	// StringDurationEncoder or UnitDurationEncoder(time.Millisecond), rather than integer numbers of nanoseconds
+	DurationEncoder func(d time.Duration) interface{}
+	// Indent, if specified, pretty-prints each entry over multiple lines using this indentation (e.g. "  ") for human
+	// inspection. Indented output cannot be read back using a JSONDecoder
+	Indent string
+	// Arena, if specified, provides the buffers entries are encoded into instead of the shared buffer pool, see
+	// EncoderArena. This is experimental
+	Arena *EncoderArena
+	// Marshal encodes the values that the sink does not encode itself, such as maps, slices and structs, defaulting to
+	// encoding/json. It can be replaced with a compatible but faster implementation, e.g.
+	// jsoniter.ConfigCompatibleWithStandardLibrary.Marshal, which is then responsible for any escaping of HTML
+	Marshal func(v interface{}) ([]byte, error)
 }
 
 // AssertDefaults replaces all uninitialised options with reasonable defaults
@@ -108,6 +700,9 @@ func (j *JSONLogSinkOptions) AssertDefaults() {
 	if j.SeverityEncoder == nil {
 		j.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
 	}
+	if j.LevelEncoder == nil {
+		j.LevelEncoder = DefaultSeverityNumberEncoder
+	}
 
 	if j.NameKey == "" {
 		j.NameKey = DefaultNameKey
@@ -136,4 +731,29 @@ func (j *JSONLogSinkOptions) AssertDefaults() {
 	if j.ErrorEncoder == nil {
 		j.ErrorEncoder = DefaultErrorEncoder
 	}
+
+	if j.TruncatedKey == "" {
+		j.TruncatedKey = DefaultTruncatedKey
+	}
+
+	if j.BytesEncoder == nil {
+		j.BytesEncoder = Base64BytesEncoder
+	}
+
+	if j.SequenceKey == "" {
+		j.SequenceKey = DefaultSequenceKey
+	}
+
+	if j.CallerKey == "" {
+		j.CallerKey = DefaultCallerKey
+	}
+	if j.CallerEncoder == nil {
+		j.CallerEncoder = DefaultCallerEncoder
+	}
 }
+
+var _ LogSink = (*JSONLogSink)(nil)
+var _ EntryEncoder = (*JSONLogSink)(nil)
+var _ DelimitedEncoder = (*JSONLogSink)(nil)
+var _ FlushSink = (*JSONLogSink)(nil)
+var _ PreEncoder = (*JSONLogSink)(nil)
@@ -0,0 +1,110 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// jsonObject is a JSON object that encodes its fields in the order they were first set
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject(size int) *jsonObject {
+	return &jsonObject{
+		keys:   make([]string, 0, size),
+		values: make(map[string]interface{}, size),
+	}
+}
+
+func (o *jsonObject) has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// set adds a field to the end of the object, or replaces the value of an existing field in place
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// delete removes a field from the object
+func (o *jsonObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// expandDottedKeys creates a copy of the object in which fields with dotted keys, e.g. "http.method", are nested in
+// objects, e.g. {"http":{"method":...}}, recursing into the values of fields that are themselves objects. Keys that
+// start or end with a dot are left as they are, as are the remaining segments of keys that would nest under a field
+// that is not an object. A field whose key is taken by an object created for dotted keys is suffixed with a number,
+// as with DuplicateKeysSuffix. If sortKeys is set, the fields of the objects created are sorted by key.
+func expandDottedKeys(o *jsonObject, sortKeys bool) *jsonObject {
+	expanded := newJSONObject(len(o.keys))
+	var created []*jsonObject
+
+	for _, key := range o.keys {
+		value := o.values[key]
+		if nested, ok := value.(*jsonObject); ok {
+			value = expandDottedKeys(nested, sortKeys)
+		}
+
+		target := expanded
+		path := key
+		for {
+			dot := strings.IndexByte(path, '.')
+			if dot <= 0 || dot == len(path)-1 {
+				break
+			}
+
+			existing, exists := target.values[path[:dot]]
+			child, ok := existing.(*jsonObject)
+			if exists && !ok {
+				break
+			}
+			if !exists {
+				child = newJSONObject(1)
+				created = append(created, child)
+				target.set(path[:dot], child)
+			}
+			target = child
+			path = path[dot+1:]
+		}
+
+		if target.has(path) {
+			path = uniqueKey(target, path)
+		}
+		target.set(path, value)
+	}
+
+	if sortKeys {
+		for _, child := range created {
+			sort.Strings(child.keys)
+		}
+	}
+
+	return expanded
+}
+
+// MarshalJSON implements json.Marshaler, encoding any values replaced by placeholders without reporting them
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	b, err := defaultJSONEncoder.appendObject(nil, o)
+	if isPlaceholderError(err) {
+		err = nil
+	}
+	return b, err
+}
+
+var _ json.Marshaler = (*jsonObject)(nil)
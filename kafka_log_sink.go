@@ -0,0 +1,337 @@
+package simplelogr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultKafkaBatchSize is the number of entries a KafkaLogSink batches before publishing them
+	DefaultKafkaBatchSize = 100
+	// DefaultKafkaLinger is how often a running KafkaLogSink publishes incomplete batches
+	DefaultKafkaLinger = 100 * time.Millisecond
+	// DefaultKafkaProduceTimeout bounds how long a KafkaLogSink waits for a batch to be delivered
+	DefaultKafkaProduceTimeout = 10 * time.Second
+)
+
+// KafkaMessage is an encoded Entry to be published to a Kafka topic
+type KafkaMessage struct {
+	// Topic is the topic to publish the message to
+	Topic string
+	// Key determines the partition the message is published to, so that messages with the same key keep their order.
+	// It is nil if the entry had no key, leaving the producer to choose the partition
+	Key []byte
+	// Value is the encoded Entry, without the trailing newline of text encodings
+	Value []byte
+	// Timestamp is when the Entry was logged
+	Timestamp time.Time
+}
+
+// KafkaProducer publishes batches of messages to Kafka. To avoid depending on any particular Kafka client, it is
+// implemented by adapting the client an application already uses, e.g. for github.com/segmentio/kafka-go:
+//
+//	type kafkaGoProducer struct{ writer *kafka.Writer }
+//
+//	func (p kafkaGoProducer) Produce(ctx context.Context, messages []simplelogr.KafkaMessage) error {
+//	    converted := make([]kafka.Message, len(messages))
+//	    for i, m := range messages {
+//	        converted[i] = kafka.Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Time: m.Timestamp}
+//	    }
+//	    return p.writer.WriteMessages(ctx, converted...)
+//	}
+type KafkaProducer interface {
+	// Produce publishes the messages, returning once they have been delivered or delivery has failed
+	Produce(ctx context.Context, messages []KafkaMessage) error
+}
+
+// KafkaLogSink batches encoded log Entry objects and publishes them to a Kafka topic using a KafkaProducer, so that
+// services can ship logs straight onto a log topic. Full batches are published in the background, so that logging
+// does not wait for Kafka, as are incomplete batches every Linger once the sink is running. Remaining entries are
+// published when Flush or Close are called. Entries in a batch that fails to be delivered are logged to the Fallback
+// sink, if specified, and the failure is reported to the ErrorHandler, or returned by Flush and Close.
+//
+// The first full batch starts the sink if Start has not been called, so Close should be called once the sink is no
+// longer needed. Until then incomplete batches wait for Flush or Close rather than Linger.
+type KafkaLogSink struct {
+	options KafkaLogSinkOptions
+
+	lock  sync.Mutex
+	batch []kafkaBatchEntry
+	// full wakes the background publisher when a batch is full
+	full chan struct{}
+
+	// sendLock ensures batches are published in the order they were produced
+	sendLock sync.Mutex
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+	closed        bool
+}
+
+// kafkaBatchEntry is an encoded message waiting to be published, along with the Entry it encodes, which is logged to
+// the Fallback sink if publishing fails
+type kafkaBatchEntry struct {
+	message KafkaMessage
+	entry   Entry
+}
+
+// NewKafkaLogSink creates a new KafkaLogSink with the provided options
+func NewKafkaLogSink(opts KafkaLogSinkOptions) *KafkaLogSink {
+	return &KafkaLogSink{
+		options: opts,
+		full:    make(chan struct{}, 1),
+	}
+}
+
+// Log implements LogSink, adding the Entry to the current batch, and waking the background publisher if it is full
+func (k *KafkaLogSink) Log(e Entry) error {
+	buffer := bytes.Buffer{}
+	if err := k.options.Encoder.Encode(&buffer, e); err != nil {
+		return err
+	}
+
+	value := buffer.Bytes()
+	// delimiters separate entries in a stream, but are redundant in a message
+	if delimited, ok := k.options.Encoder.(DelimitedEncoder); ok {
+		value = bytes.TrimSuffix(value, []byte(delimited.EntryDelimiter()))
+	}
+
+	message := KafkaMessage{
+		Topic:     k.options.Topic,
+		Value:     value,
+		Timestamp: e.Timestamp,
+	}
+	if k.options.Key != nil {
+		message.Key = k.options.Key(e)
+	}
+
+	k.lock.Lock()
+	k.batch = append(k.batch, kafkaBatchEntry{message: message, entry: e})
+	full := len(k.batch) >= k.options.BatchSize
+	k.lock.Unlock()
+
+	if full {
+		k.publishInBackground()
+	}
+
+	return nil
+}
+
+// publishInBackground wakes the background publisher to publish a full batch, starting it first if necessary, unless
+// the sink has been closed
+func (k *KafkaLogSink) publishInBackground() {
+	k.lifecycleLock.Lock()
+	if k.done == nil && !k.closed {
+		k.startLocked(context.Background())
+	}
+	k.lifecycleLock.Unlock()
+
+	select {
+	case k.full <- struct{}{}:
+	default:
+	}
+}
+
+// Flush publishes any batched entries, in batches of at most BatchSize, logging them to the Fallback sink if they
+// cannot be delivered
+func (k *KafkaLogSink) Flush() error {
+	k.sendLock.Lock()
+	defer k.sendLock.Unlock()
+
+	k.lock.Lock()
+	pending := k.batch
+	k.batch = nil
+	k.lock.Unlock()
+
+	var errs multiError
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > k.options.BatchSize {
+			n = k.options.BatchSize
+		}
+		if err := k.publish(pending[:n]); err != nil {
+			errs = append(errs, err)
+		}
+		pending = pending[n:]
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// publish produces a batch, logging its entries to the Fallback sink if it cannot be delivered
+func (k *KafkaLogSink) publish(batch []kafkaBatchEntry) error {
+	messages := make([]KafkaMessage, len(batch))
+	for i, entry := range batch {
+		messages[i] = entry.message
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.ProduceTimeout)
+	defer cancel()
+
+	err := k.options.Producer.Produce(ctx, messages)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("failed to publish %d entries to Kafka topic %q: %w", len(batch), k.options.Topic, err)
+	if k.options.Fallback == nil {
+		return err
+	}
+
+	errs := multiError{err}
+	for _, entry := range batch {
+		if fallbackErr := k.options.Fallback.Log(entry.entry); fallbackErr != nil {
+			errs = append(errs, fallbackErr)
+		}
+	}
+	return errs
+}
+
+// Start begins publishing full batches, and incomplete batches every Linger, in the background, until the context is
+// cancelled or Close is called
+func (k *KafkaLogSink) Start(ctx context.Context) {
+	k.lifecycleLock.Lock()
+	defer k.lifecycleLock.Unlock()
+
+	if k.done != nil {
+		return
+	}
+	k.startLocked(ctx)
+}
+
+// startLocked starts the background publisher
+func (k *KafkaLogSink) startLocked(ctx context.Context) {
+	ctx, k.cancel = context.WithCancel(ctx)
+	k.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(k.options.Linger)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.Flush(); err != nil {
+					k.options.ErrorHandler(err)
+				}
+			case <-k.full:
+				if err := k.Flush(); err != nil {
+					k.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(k.done)
+}
+
+// Close implements CloserSink, stopping any background publishing and publishing any remaining
+// batched entries. The KafkaProducer is not closed, as it is owned by the application
+func (k *KafkaLogSink) Close() error {
+	k.lifecycleLock.Lock()
+	k.closed = true
+	if k.done != nil {
+		k.cancel()
+		<-k.done
+		k.done = nil
+	}
+	k.lifecycleLock.Unlock()
+
+	return k.Flush()
+}
+
+// Unwrap implements WrapperSink, returning the Fallback sink, if any
+func (k *KafkaLogSink) Unwrap() []LogSink {
+	if k.options.Fallback != nil {
+		return []LogSink{k.options.Fallback}
+	}
+	return nil
+}
+
+var _ LogSink = (*KafkaLogSink)(nil)
+var _ FlushSink = (*KafkaLogSink)(nil)
+var _ CloserSink = (*KafkaLogSink)(nil)
+var _ WrapperSink = (*KafkaLogSink)(nil)
+
+// KafkaKeyFromValues produces a KafkaLogSinkOptions.Key function using the value of the first of the given keys that
+// the Entry has a key-value pair for, e.g. a tenant or request ID, so that related entries share a partition and keep
+// their order. Entries with none of the keys have no key
+func KafkaKeyFromValues(keys ...string) func(e Entry) []byte {
+	return func(e Entry) []byte {
+		for _, key := range keys {
+			if v, ok := e.Value(key); ok {
+				switch value := resolveValue(v).(type) {
+				case string:
+					return []byte(value)
+				case []byte:
+					return value
+				default:
+					return []byte(fmt.Sprint(value))
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// KafkaLogSinkOptions configures the behaviour of a KafkaLogSink
+type KafkaLogSinkOptions struct {
+	// Producer publishes batches of messages to Kafka, and must be specified
+	Producer KafkaProducer
+	// Topic is the topic entries are published to
+	Topic string
+	// Encoder encodes each Entry as the value of its message, e.g. a JSONLogSink or BinaryLogSink. The delimiter of
+	// encoders implementing DelimitedEncoder, such as the newline of text encodings, is omitted
+	Encoder EntryEncoder
+	// Key, if specified, derives the key of each Entry's message, which determines its partition, see
+	// KafkaKeyFromValues. Otherwise messages have no key
+	Key func(e Entry) []byte
+	// BatchSize is the number of entries batched before they are published
+	BatchSize int
+	// Linger is how often incomplete batches are published once the sink is running, bounding how long entries wait to
+	// be published. The sink runs once Start has been called or a batch has filled, until then incomplete batches
+	// are only published by Flush and Close
+	Linger time.Duration
+	// ProduceTimeout bounds how long publishing each batch may take
+	ProduceTimeout time.Duration
+	// Fallback, if specified, is where the entries of batches that fail to be delivered are logged instead, e.g. a sink
+	// writing to stderr or a local file
+	Fallback LogSink
+	// ErrorHandler is called with any errors encountered while publishing in the background, including batches that
+	// failed to be delivered
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (k *KafkaLogSinkOptions) AssertDefaults() {
+	if k.Encoder == nil {
+		encoderOpts := JSONLogSinkOptions{}
+		encoderOpts.AssertDefaults()
+		k.Encoder = NewJSONLogSink(encoderOpts)
+	}
+
+	if k.BatchSize <= 0 {
+		k.BatchSize = DefaultKafkaBatchSize
+	}
+
+	if k.Linger <= 0 {
+		k.Linger = DefaultKafkaLinger
+	}
+
+	if k.ProduceTimeout <= 0 {
+		k.ProduceTimeout = DefaultKafkaProduceTimeout
+	}
+
+	if k.ErrorHandler == nil {
+		k.ErrorHandler = DefaultErrorHandler
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"github.com/fatih/color"
+)
+
+// KeyColour is a rule highlighting key-value pairs with a given key in the output of a DevelopmentLogSink, so that
+// important values stand out, see DevelopmentLogSinkOptions.KeyColours
+type KeyColour struct {
+	// Key is the key of the key-value pairs the rule applies to
+	Key string
+	// Colour is used for both the key and value of matching key-value pairs
+	Colour *color.Color
+	// When, if specified, limits the rule to key-value pairs whose value it returns true for, e.g. ValueAbove. Values
+	// are resolved first, so slog values are passed as their underlying value
+	When func(value interface{}) bool
+}
+
+// matches reports whether the rule applies to a key-value pair
+func (k KeyColour) matches(key string, value interface{}) bool {
+	return k.Key == key && (k.When == nil || k.When(value))
+}
+
+// ValueAbove creates a predicate for KeyColour.When matching numeric values greater than the threshold, e.g. to
+// highlight slow requests
+func ValueAbove(threshold float64) func(value interface{}) bool {
+	return func(value interface{}) bool {
+		n, ok := toFloat64(value)
+		return ok && n > threshold
+	}
+}
+
+// kvColours determines the colours of a key-value pair, using the first rule matching it if any, and otherwise the
+// secondary colour for the key and the primary colour for the value
+func (d DevelopmentLogSink) kvColours(key string, value interface{}) (keyColour *color.Color, valueColour *color.Color) {
+	for _, rule := range d.options.KeyColours {
+		if rule.matches(key, value) {
+			return rule.Colour, rule.Colour
+		}
+	}
+	return d.options.SecondaryColour, d.options.PrimaryColour
+}
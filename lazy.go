@@ -0,0 +1,68 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// logMarshaler is implemented by values that provide a different value to be logged in their place, as with
+// logr.Marshaler in later versions of logr, which its funcr implementation calls when logging
+type logMarshaler interface {
+	MarshalLog() interface{}
+}
+
+// LazyValue is a value computed only when it is logged, see Lazy
+type LazyValue struct {
+	evaluate func() interface{}
+}
+
+// Lazy wraps a function computing a value to be logged, e.g. an expensive diagnostic, so that it is only called if the
+// entry is actually written by a sink, rather than when the entry is disabled by its verbosity, sampled away, or
+// filtered out. The function is called each time the value is logged, including once per entry when it is added
+// using WithValues, and may be called by more than one sink.
+func Lazy(f func() interface{}) LazyValue {
+	return LazyValue{evaluate: f}
+}
+
+// MarshalLog implements logr.Marshaler, computing the value
+func (l LazyValue) MarshalLog() interface{} {
+	return l.evaluate()
+}
+
+// MarshalJSON implements json.Marshaler, computing the value, so that it is encoded correctly even when passed to
+// encoding/json without first being resolved
+func (l LazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(resolveValue(l))
+}
+
+// String implements fmt.Stringer, computing the value, so that it is formatted correctly when printed using the fmt
+// package
+func (l LazyValue) String() string {
+	return fmt.Sprint(resolveValue(l))
+}
+
+// resolveValue converts values that are computed when they are logged into the plain Go values they represent, i.e.
+// LazyValue and other logr.Marshaler implementations, and those originating from log/slog (see resolveSlogValue),
+// before applying any encoder registered for the resulting type (see RegisterValueEncoder). Values that panic while
+// being resolved are replaced by a placeholder.
+func resolveValue(v interface{}) (resolved interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			resolved = panicPlaceholder(v, recovered)
+		}
+	}()
+
+	if marshaler, ok := v.(logMarshaler); ok {
+		v = marshaler.MarshalLog()
+	}
+	return encodeRegisteredValue(resolveSlogValue(v))
+}
+
+// isLazyValue reports whether a value is resolved when it is logged, i.e. it is a LazyValue or another
+// logr.Marshaler, or is or contains a slog.LogValuer, and so must not be encoded ahead of time
+func isLazyValue(v interface{}) bool {
+	if _, ok := v.(logMarshaler); ok {
+		return true
+	}
+	return isLazySlogAny(v)
+}
@@ -0,0 +1,195 @@
+package simplelogr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultLevelPollInterval is how often a LevelPoller polls its LevelSource when no interval is specified
+	DefaultLevelPollInterval = 30 * time.Second
+)
+
+// LevelConfig describes verbosity settings obtained from a LevelSource, typically encoded as JSON, e.g.
+// {"verbosity": 1, "names": {"payments": 4}}
+type LevelConfig struct {
+	// Verbosity is the overall verbosity, if nil the current overall verbosity is left unchanged
+	Verbosity *int `json:"verbosity,omitempty"`
+	// Names maps logger name prefixes to verbosity levels, see VerbosityController.SetNameVerbosity. These replace any
+	// existing per-name verbosity levels
+	Names map[string]int `json:"names,omitempty"`
+}
+
+// LevelSource is somewhere a LevelPoller can fetch LevelConfig from, e.g. an HTTP endpoint or a mounted file. Other
+// sources such as etcd or Consul keys can be supported by implementing this interface.
+type LevelSource interface {
+	FetchLevels(ctx context.Context) (LevelConfig, error)
+}
+
+// LevelSourceFunc adapts a function into a LevelSource
+type LevelSourceFunc func(ctx context.Context) (LevelConfig, error)
+
+// FetchLevels implements LevelSource by calling the function
+func (f LevelSourceFunc) FetchLevels(ctx context.Context) (LevelConfig, error) {
+	return f(ctx)
+}
+
+// HTTPLevelSource fetches JSON encoded LevelConfig from the given URL using the given client, or
+// http.DefaultClient if nil
+func HTTPLevelSource(url string, client *http.Client) LevelSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return LevelSourceFunc(func(ctx context.Context) (LevelConfig, error) {
+		config := LevelConfig{}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return config, fmt.Errorf("failed to create level config request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return config, fmt.Errorf("failed to fetch level config: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode != http.StatusOK {
+			return config, fmt.Errorf("failed to fetch level config: unexpected status %s", resp.Status)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to decode level config: %w", err)
+		}
+
+		return config, nil
+	})
+}
+
+// FileLevelSource reads JSON encoded LevelConfig from the file at the given path, e.g. a Kubernetes ConfigMap mounted
+// into the container
+func FileLevelSource(path string) LevelSource {
+	return LevelSourceFunc(func(ctx context.Context) (LevelConfig, error) {
+		config := LevelConfig{}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("failed to read level config: %w", err)
+		}
+
+		if err := json.Unmarshal(b, &config); err != nil {
+			return config, fmt.Errorf("failed to decode level config: %w", err)
+		}
+
+		return config, nil
+	})
+}
+
+// LevelPollerOptions configures a LevelPoller
+type LevelPollerOptions struct {
+	// Source is where LevelConfig is fetched from
+	Source LevelSource
+	// Controller has fetched LevelConfig applied to it
+	Controller *VerbosityController
+	// Interval is how often the Source is polled, defaults to DefaultLevelPollInterval
+	Interval time.Duration
+	// ErrorHandler is called with any errors encountered while polling, defaults to DefaultErrorHandler
+	ErrorHandler func(err error)
+}
+
+// LevelPoller periodically fetches LevelConfig from a LevelSource and applies it to a VerbosityController, allowing
+// log levels to be managed centrally across many processes
+type LevelPoller struct {
+	options LevelPollerOptions
+	lock    sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewLevelPoller creates a new LevelPoller using the provided options, applying reasonable defaults where options
+// aren't specified. Polling does not begin until Start is called.
+func NewLevelPoller(opts LevelPollerOptions) *LevelPoller {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultLevelPollInterval
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	return &LevelPoller{
+		options: opts,
+	}
+}
+
+// Poll fetches LevelConfig from the source once and applies it to the controller
+func (p *LevelPoller) Poll(ctx context.Context) error {
+	config, err := p.options.Source.FetchLevels(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.Verbosity != nil {
+		p.options.Controller.SetVerbosity(*config.Verbosity)
+	}
+	p.options.Controller.SetNameVerbosities(config.Names)
+
+	return nil
+}
+
+// Start begins polling in the background until the context is cancelled or Close is called. An initial poll is made
+// immediately.
+func (p *LevelPoller) Start(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.done != nil {
+		return
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(p.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := p.Poll(ctx); err != nil && ctx.Err() == nil {
+				p.options.ErrorHandler(fmt.Errorf("failed to poll level config: %w", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}(p.done)
+}
+
+// Close stops polling, waiting for any in-progress poll to finish
+func (p *LevelPoller) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.done == nil {
+		return nil
+	}
+
+	p.cancel()
+	<-p.done
+	p.done = nil
+
+	return nil
+}
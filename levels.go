@@ -0,0 +1,146 @@
+package simplelogr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// Level is a verbosity level, naming the levels used with logr.Logger.V so that call sites need not use magic numbers.
+// Negative levels, such as LevelWarn, are more severe than LevelInfo without being errors.
+type Level int
+
+const (
+	// LevelWarn is the level of warnings, with the "WARN" severity in DefaultSeverityThresholds. As logr has no warning
+	// level, entries are logged at it using V or Warn rather than logr.Logger.V
+	LevelWarn Level = -1
+	// LevelInfo is the verbosity level of non-verbose entries, with the DefaultSeverity
+	LevelInfo Level = 0
+	// LevelDebug is the verbosity level of debugging entries, with the "DEBUG" severity in DefaultSeverityThresholds
+	LevelDebug Level = 1
+	// LevelTrace is the verbosity level of tracing entries, with the "TRACE" severity in DefaultSeverityThresholds
+	LevelTrace Level = 2
+)
+
+var (
+	// levelsLock guards registrations of named levels, which update DefaultSeverityThresholds
+	levelsLock sync.RWMutex
+)
+
+// String returns the severity name of the level, if it has one (see RegisterLevel), or else the level as a number
+func (l Level) String() string {
+	if l == LevelInfo {
+		return DefaultSeverity
+	}
+
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	for _, threshold := range DefaultSeverityThresholds {
+		if threshold.Level == int(l) {
+			return threshold.Severity
+		}
+	}
+	return strconv.Itoa(int(l))
+}
+
+// V returns a logr.Logger logging at the given level. Verbose levels are equivalent to logger.V(int(level)), while
+// negative levels such as LevelWarn, which logr.Logger.V cannot represent, are applied by the Logger itself to its
+// non-verbose entries, and so have no effect on loggers backed by another logr.LogSink. Entries at negative levels are
+// enabled when the verbosity is at least that level, so a verbosity of -1 (or "warn") leaves only warnings and errors.
+func V(logger logr.Logger, level Level) logr.Logger {
+	if level >= LevelInfo {
+		return logger.V(int(level))
+	}
+
+	if l, ok := logger.GetSink().(*Logger); ok {
+		return logger.WithSink(l.withLevel(int(level)))
+	}
+	return logger
+}
+
+// Warn returns a logr.Logger logging at LevelWarn, for conditions that operators should notice but are not errors,
+// e.g.:
+//
+//	simplelogr.Warn(logger).Info("disk space low", "free", free)
+func Warn(logger logr.Logger) logr.Logger {
+	return V(logger, LevelWarn)
+}
+
+// Debug returns a logr.Logger logging at LevelDebug
+func Debug(logger logr.Logger) logr.Logger {
+	return logger.V(int(LevelDebug))
+}
+
+// Trace returns a logr.Logger logging at LevelTrace
+func Trace(logger logr.Logger) logr.Logger {
+	return logger.V(int(LevelTrace))
+}
+
+// RegisterLevel names an additional verbosity level, adding it to DefaultSeverityThresholds so that sinks using the
+// default severity encoder give entries at that level (or more verbose, up to the next named level) its severity name,
+// and so that LookupLevel recognises it. Negative levels name entries at that level or more severe, up to the next
+// named level, and are logged at using V. Registering a level that is already named renames it. Levels should be
+// registered before any sinks are created, typically in an init function, e.g.:
+//
+//	const LevelVerbose simplelogr.Level = 3
+//
+//	func init() {
+//	    _ = simplelogr.RegisterLevel("VERBOSE", LevelVerbose)
+//	}
+func RegisterLevel(name string, level Level) error {
+	if name == "" {
+		return fmt.Errorf("level %d must have a name", level)
+	}
+	if level == LevelInfo {
+		return fmt.Errorf("level %q cannot rename %d, which is named by DefaultSeverity", name, LevelInfo)
+	}
+
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+
+	// a new slice is created, so that encoders created with the previous thresholds are unaffected
+	thresholds := make([]SeverityThreshold, 0, len(DefaultSeverityThresholds)+1)
+	for _, threshold := range DefaultSeverityThresholds {
+		if threshold.Level != int(level) {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	thresholds = append(thresholds, SeverityThreshold{Level: int(level), Severity: name})
+
+	// DefaultSeverityEncoder uses the first threshold satisfied, so verbose levels are ordered most verbose first,
+	// followed by negative levels ordered most severe first
+	sort.SliceStable(thresholds, func(i, j int) bool {
+		a, b := thresholds[i].Level, thresholds[j].Level
+		if (a > 0) != (b > 0) {
+			return a > 0
+		}
+		if a > 0 {
+			return a > b
+		}
+		return a < b
+	})
+	DefaultSeverityThresholds = thresholds
+
+	return nil
+}
+
+// LookupLevel retrieves the level with the given severity name, ignoring case, including DefaultSeverity for LevelInfo
+// and the levels added using RegisterLevel
+func LookupLevel(name string) (Level, bool) {
+	if strings.EqualFold(name, DefaultSeverity) {
+		return LevelInfo, true
+	}
+
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	for _, threshold := range DefaultSeverityThresholds {
+		if strings.EqualFold(name, threshold.Severity) {
+			return Level(threshold.Level), true
+		}
+	}
+	return 0, false
+}
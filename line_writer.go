@@ -0,0 +1,84 @@
+package simplelogr
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultLineWriterMaxLength is the length in bytes beyond which a LineWriter logs an incomplete line rather than
+	// waiting for the rest of it, so that output without newlines cannot grow its buffer indefinitely
+	DefaultLineWriterMaxLength = 64 * 1024
+)
+
+// LineWriter is a thread-safe io.Writer that splits the bytes written to it into lines, logging each line (without its
+// line ending) as the message of an Info entry, so that the logger can be used with APIs that only accept an
+// io.Writer, e.g. the Stdout and Stderr of an exec.Cmd. Empty lines are ignored.
+type LineWriter struct {
+	logger  logr.Logger
+	level   int
+	lock    sync.Mutex
+	pending []byte
+}
+
+// WriterAt creates a LineWriter logging each line written to it at the given verbosity level. Flush should be called
+// once everything has been written, to log the final line if it did not end with a newline
+func WriterAt(logger logr.Logger, level int) *LineWriter {
+	return &LineWriter{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Write implements io.Writer, logging every complete line and keeping any incomplete line until the rest of it is
+// written
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	data := p
+	if len(l.pending) > 0 {
+		l.pending = append(l.pending, p...)
+		data = l.pending
+	}
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(data[:i])
+		data = data[i+1:]
+	}
+
+	if len(data) > DefaultLineWriterMaxLength {
+		l.log(data)
+		data = nil
+	}
+	l.pending = append(l.pending[:0], data...)
+
+	return len(p), nil
+}
+
+// Flush logs any incomplete line written so far
+func (l *LineWriter) Flush() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.log(l.pending)
+	l.pending = l.pending[:0]
+	return nil
+}
+
+func (l *LineWriter) log(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		return
+	}
+	l.logger.V(l.level).Info(string(line))
+}
+
+var _ io.Writer = (*LineWriter)(nil)
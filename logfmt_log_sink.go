@@ -0,0 +1,342 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logfmtPreEncodedFormat identifies values pre-encoded by a LogfmtLogSink, as space separated key=value pairs
+const logfmtPreEncodedFormat = "logfmt"
+
+// LogfmtLogSink emits logfmt (key=value) representations of log Entry objects, a line-oriented structured format
+// that remains easy for humans to read
+type LogfmtLogSink struct {
+	options LogfmtLogSinkOptions
+}
+
+// NewLogfmtLogSink creates a new LogfmtLogSink with the provided options
+func NewLogfmtLogSink(options LogfmtLogSinkOptions) *LogfmtLogSink {
+	return &LogfmtLogSink{
+		options: options,
+	}
+}
+
+// Log implements LogSink, encoding the given Entry as logfmt before writing it to the configured io.Writer
+func (l LogfmtLogSink) Log(e Entry) error {
+	return l.Encode(l.options.Output, e)
+}
+
+// Encode implements EntryEncoder, writing the logfmt encoding of the given Entry to the given io.Writer
+func (l LogfmtLogSink) Encode(w io.Writer, e Entry) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	b, err := l.appendEntry(*buffer, e)
+	if e.reportPlaceholders(err) != nil {
+		return err
+	}
+	b = append(b, '\n')
+	*buffer = b
+
+	_, err = w.Write(b)
+	return err
+}
+
+// EntryDelimiter implements DelimitedEncoder, returning the newline ending each encoded Entry
+func (l LogfmtLogSink) EntryDelimiter() string {
+	return "\n"
+}
+
+// appendEntry appends the logfmt encoding of the given Entry to buf, without a trailing newline. Values replaced by
+// placeholders are returned as placeholderErrors once the entry is complete
+func (l LogfmtLogSink) appendEntry(buf []byte, e Entry) ([]byte, error) {
+	var err error
+	var placeholders placeholderErrors
+	appendPair := func(k string, v interface{}) {
+		if err != nil {
+			return
+		}
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, logfmtKey(k)...)
+		buf = append(buf, '=')
+		if buf, err = appendLogfmtValue(buf, v); err != nil && placeholders.collect(err) {
+			err = nil
+		}
+	}
+
+	if l.options.TimestampKey != "" {
+		appendPair(l.options.TimestampKey, l.options.TimestampMode.text(e.Timestamp, l.options.TimestampLocation, l.options.TimestampEncoder))
+	}
+
+	if l.options.SeverityKey != "" {
+		appendPair(l.options.SeverityKey, e.severity(l.options.SeverityEncoder))
+	}
+
+	if l.options.LevelKey != "" {
+		appendPair(l.options.LevelKey, l.options.LevelEncoder(e.Level, e.Error))
+	}
+
+	if e.Sequence != 0 && l.options.SequenceKey != "" {
+		appendPair(l.options.SequenceKey, e.Sequence)
+	}
+
+	if len(e.Names) > 0 && l.options.NameKey != "" {
+		appendPair(l.options.NameKey, l.options.NameEncoder(e.Names))
+	}
+
+	if l.options.MessageKey != "" {
+		appendPair(l.options.MessageKey, e.Message)
+	}
+
+	if e.Error != nil && l.options.ErrorKey != "" {
+		appendPair(l.options.ErrorKey, e.encodeError(l.options.ErrorEncoder).Message)
+	}
+
+	kvs := flattenGroups(e.KVs)
+	if e.PreEncoded.valid(logfmtPreEncodedFormat, kvs) {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, e.PreEncoded.Data...)
+		kvs = kvs[e.PreEncoded.Count:]
+	}
+
+	for i := 0; i < len(kvs); i += 2 {
+		k := kvs[i]
+		v := kvs[i+1]
+
+		kStr, ok := k.(string)
+		if !ok {
+			return buf, fmt.Errorf("logging keys must be strings, got %T: %v", k, k)
+		}
+
+		appendPair(kStr, l.value(v))
+	}
+
+	if err != nil {
+		return buf, err
+	}
+	return buf, placeholders.err()
+}
+
+// PreEncode implements PreEncoder, encoding the key-value pairs as logfmt so that they can be spliced into entries
+func (l LogfmtLogSink) PreEncode(keysAndValues []interface{}) (*PreEncodedValues, error) {
+	preEncoded := &PreEncodedValues{
+		Format: logfmtPreEncodedFormat,
+		Count:  len(keysAndValues),
+		Keys:   make([]string, 0, len(keysAndValues)/2),
+	}
+
+	var err error
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		k, ok := keysAndValues[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("logging keys must be strings, got %T: %v", keysAndValues[i], keysAndValues[i])
+		}
+		v := keysAndValues[i+1]
+		if isLazyValue(v) || isGroup(v) {
+			// groups prefix the keys of the key-value pairs that follow them, which are not known yet
+			return nil, nil
+		}
+
+		if i > 0 {
+			preEncoded.Data = append(preEncoded.Data, ' ')
+		}
+		preEncoded.Keys = append(preEncoded.Keys, k)
+		preEncoded.Data = append(preEncoded.Data, logfmtKey(k)...)
+		preEncoded.Data = append(preEncoded.Data, '=')
+		if preEncoded.Data, err = appendLogfmtValue(preEncoded.Data, l.value(v)); err != nil {
+			return nil, err
+		}
+	}
+
+	return preEncoded, nil
+}
+
+// value resolves the value of a key-value pair, encoding []byte values using the BytesEncoder
+func (l LogfmtLogSink) value(v interface{}) interface{} {
+	return encodeBytes(resolveValue(v), l.options.BytesEncoder)
+}
+
+// Flush implements FlushSink, flushing the configured io.Writer if it buffers writes
+func (l LogfmtLogSink) Flush() error {
+	return flushWriter(l.options.Output)
+}
+
+// logfmtKey replaces characters that are not permitted in logfmt keys
+func logfmtKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+// appendLogfmtValue appends simple values directly, and anything else as JSON, quoting the result where necessary.
+// Values that panic or fail to be encoded are replaced by a placeholder, which is returned as the error
+func appendLogfmtValue(buf []byte, v interface{}) (result []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			placeholder := panicPlaceholder(v, recovered)
+			result, err = appendLogfmtString(buf, placeholder.placeholder), placeholder
+		}
+	}()
+
+	switch value := v.(type) {
+	case *placeholderValue:
+		return appendLogfmtString(buf, value.placeholder), value
+	case string:
+		return appendLogfmtString(buf, value), nil
+	case nil:
+		return append(buf, "null"...), nil
+	case bool:
+		return strconv.AppendBool(buf, value), nil
+	case int:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int8:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int16:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int32:
+		return strconv.AppendInt(buf, int64(value), 10), nil
+	case int64:
+		return strconv.AppendInt(buf, value, 10), nil
+	case uint:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint8:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint16:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint32:
+		return strconv.AppendUint(buf, uint64(value), 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, value, 10), nil
+	case float32:
+		return strconv.AppendFloat(buf, float64(value), 'g', -1, 32), nil
+	case float64:
+		return strconv.AppendFloat(buf, value, 'g', -1, 64), nil
+	case error:
+		return appendLogfmtString(buf, value.Error()), nil
+	case fmt.Stringer:
+		return appendLogfmtString(buf, value.String()), nil
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			placeholder := errorPlaceholder(v, err)
+			return appendLogfmtString(buf, placeholder.placeholder), placeholder
+		}
+		return appendLogfmtString(buf, string(b)), nil
+	}
+}
+
+// appendLogfmtString appends a string value, quoting it if it is empty or contains characters significant to logfmt
+func appendLogfmtString(buf []byte, s string) []byte {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n\\") {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+var _ LogSink = (*LogfmtLogSink)(nil)
+var _ EntryEncoder = (*LogfmtLogSink)(nil)
+var _ DelimitedEncoder = (*LogfmtLogSink)(nil)
+var _ FlushSink = (*LogfmtLogSink)(nil)
+var _ PreEncoder = (*LogfmtLogSink)(nil)
+
+// LogfmtLogSinkOptions configures the behaviour of a LogfmtLogSink
+type LogfmtLogSinkOptions struct {
+	// Output configures where to write logfmt logs to
+	Output io.Writer
+	// SeverityKey determines the key to store the log severity name in
+	SeverityKey string
+	// SeverityEncoder identifies the severity name based on the verbosity level and the presence of any errors
+	SeverityEncoder func(level int, err error) string
+	// LevelKey, if specified, determines the key to store a numeric level in, alongside the severity name
+	LevelKey string
+	// LevelEncoder maps the verbosity level and the presence of any errors to the numeric level, e.g.
+	// DefaultSeverityNumberEncoder (OpenTelemetry) or DefaultSyslogPriorityEncoder (RFC 5424)
+	LevelEncoder func(level int, err error) int
+	// NameKey determines the key to store the logger name in
+	NameKey string
+	// NameEncoder collapses the series of Logger names down into one string for logging
+	NameEncoder func(names []string) string
+	// MessageKey determines the key to store the log message in
+	MessageKey string
+	// TimestampKey determines the key to store the timestamp in
+	TimestampKey string
+	// TimestampEncoder formats timestamps into string representations
+	TimestampEncoder func(t time.Time) string
+	// TimestampMode determines whether timestamps are formatted using the TimestampEncoder, or are numbers relative
+	// to the Unix epoch
+	TimestampMode TimestampMode
+	// TimestampLocation, if specified, is the time zone timestamps are converted to before being formatted, otherwise
+	// they are formatted as logged, which is in UTC for entries logged by a Logger
+	TimestampLocation *time.Location
+	// ErrorKey determines the key to store any error messages in
+	ErrorKey string
+	// SequenceKey determines the key to store the entry's sequence number in, when it has one
+	SequenceKey string
+	// ErrorEncoder extracts loggable EncodedError information from an error
+	ErrorEncoder func(err error) EncodedError
+	// BytesEncoder converts []byte values into strings, e.g. HexBytesEncoder or LengthBytesEncoder. It applies only to
+	// the values of key-value pairs, not to []byte fields of maps, slices or structs, which are encoded as JSON
+	BytesEncoder func(b []byte) string
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (l *LogfmtLogSinkOptions) AssertDefaults() {
+	if l.Output == nil {
+		l.Output = os.Stderr
+	}
+
+	if l.SeverityKey == "" {
+		l.SeverityKey = DefaultSeverityKey
+	}
+	if l.SeverityEncoder == nil {
+		l.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+	if l.LevelEncoder == nil {
+		l.LevelEncoder = DefaultSeverityNumberEncoder
+	}
+
+	if l.NameKey == "" {
+		l.NameKey = DefaultNameKey
+	}
+	if l.NameEncoder == nil {
+		l.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+
+	if l.MessageKey == "" {
+		l.MessageKey = DefaultMessageKey
+	}
+
+	if l.TimestampKey == "" {
+		l.TimestampKey = DefaultTimestampKey
+	}
+	if l.TimestampEncoder == nil {
+		l.TimestampEncoder = DefaultTimestampEncoder(DefaultTimestampFormat)
+	}
+
+	if l.ErrorKey == "" {
+		l.ErrorKey = DefaultErrorKey
+	}
+	if l.ErrorEncoder == nil {
+		l.ErrorEncoder = DefaultErrorEncoder
+	}
+
+	if l.BytesEncoder == nil {
+		l.BytesEncoder = Base64BytesEncoder
+	}
+
+	if l.SequenceKey == "" {
+		l.SequenceKey = DefaultSequenceKey
+	}
+}
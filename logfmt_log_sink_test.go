@@ -0,0 +1,34 @@
+package simplelogr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestLogfmtBytesEncoder(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		encoder  func(b []byte) string
+		expected string
+	}{
+		{"Default", nil, `context="aGk=" value=AAH/`},
+		{"Hex", HexBytesEncoder, `context=6869 value=0001ff`},
+		{"Length", LengthBytesEncoder, `context="[2 bytes]" value="[3 bytes]"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			opts := LogfmtLogSinkOptions{Output: &buffer, BytesEncoder: test.encoder}
+			opts.AssertDefaults()
+			logger := logr.New(New(Options{Sink: NewLogfmtLogSink(opts)})).WithValues("context", []byte("hi"))
+
+			logger.Info("message", "value", []byte{0, 1, 255})
+
+			if output := strings.TrimSpace(buffer.String()); !strings.HasSuffix(output, test.expected) {
+				t.Errorf("expected output ending with %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
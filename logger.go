@@ -1,10 +1,12 @@
 package simplelogr
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/pkg/errors"
 )
 
 // Logger implements the logr.LogSink interface
@@ -13,6 +15,12 @@ type Logger struct {
 	options Options
 	names   []string
 	values  []interface{}
+	// preEncoded is the encoding of values prepared by the sink, if it is a PreEncoder
+	preEncoded *PreEncodedValues
+	callDepth  int
+	// level, if negative, replaces level 0 for Info entries and verbosity checks, for levels such as LevelWarn that
+	// logr.Logger.V cannot represent, see V
+	level int
 }
 
 // LogSink is a system that accepts log Entry objects and handles them, typically by encoding them and emitting them
@@ -23,11 +31,46 @@ type LogSink interface {
 
 // Options controls the configuration of a new Logger, see New
 type Options struct {
-	Sink         LogSink
-	Verbosity    int
+	Sink      LogSink
+	Verbosity int
+	// ErrorHandler is called with any problems encountered while logging. Entries the Sink fails to log are reported
+	// as a SinkError, once for each sink that failed, which can be retrieved using errors.As
 	ErrorHandler func(err error)
+	// Controller, if specified, determines the verbosity at runtime instead of Verbosity, see VerbosityController
+	Controller *VerbosityController
+	// CaptureCaller causes the file, line and function of the code calling the logger to be captured in Entry.Caller
+	CaptureCaller bool
+	// CaptureStackTrace causes the stack of the code calling Logger.Error to be captured in Entry.Stack when the error
+	// has no stack trace of its own (e.g. those created using fmt.Errorf), so that sinks can include it in place of one
+	CaptureStackTrace bool
+	// StackTrace trims the stacks captured for CaptureStackTrace, see TrimmedErrorEncoder to trim the stack traces
+	// built into errors
+	StackTrace StackTraceOptions
+	// ContextHooks enrich loggers retrieved using FromContext with key-value pairs extracted from the context.Context
+	ContextHooks []ContextHook
+	// NameMode determines how names passed to Logger.WithName combine with any existing names, defaults to
+	// NameModeAppend
+	NameMode NameMode
+	// MaxNameDepth caps the number of name segments a Logger accumulates, once reached further calls to
+	// Logger.WithName are ignored. Zero means there is no cap
+	MaxNameDepth int
+	// Hooks, if specified, are run by Start and Shutdown
+	Hooks *Hooks
+	// Fields, if specified, stamp every entry with metadata describing the process, such as the hostname or service
+	// version, ahead of any key-value pairs added using WithValues. They are evaluated once, by New
+	Fields []FieldProvider
 }
 
+// NameMode controls how Logger.WithName treats the names already accumulated by a Logger
+type NameMode int
+
+const (
+	// NameModeAppend adds each new name as an additional segment after the existing names
+	NameModeAppend NameMode = iota
+	// NameModeReplace discards any existing names, leaving only the newest name
+	NameModeReplace
+)
+
 // New creates a new Logger using the provided Options, applying reasonable defaults where options aren't specified
 func New(opts Options) *Logger {
 	if opts.Sink == nil {
@@ -40,9 +83,12 @@ func New(opts Options) *Logger {
 		opts.ErrorHandler = DefaultErrorHandler
 	}
 
-	return &Logger{
+	l := &Logger{
 		options: opts,
+		values:  evaluateFieldProviders(opts.Fields, opts.ErrorHandler),
 	}
+	l.preEncoded = l.preEncode()
+	return l
 }
 
 // Init accepts runtime information from the parent logr.Logger
@@ -52,11 +98,20 @@ func (l *Logger) Init(info logr.RuntimeInfo) {
 
 // Enabled determines whether this logger would emit Info messages at the specified verbosity level
 func (l Logger) Enabled(level int) bool {
+	if level == 0 && l.level < 0 {
+		level = l.level
+	}
+	if l.options.Controller != nil {
+		return l.options.Controller.Enabled(l.names, l.values, level)
+	}
 	return l.options.Verbosity >= level
 }
 
 // Info emits an info level log message
 func (l Logger) Info(level int, msg string, keysAndValues ...interface{}) {
+	if level == 0 && l.level < 0 {
+		level = l.level
+	}
 	l.log(level, nil, msg, keysAndValues...)
 }
 
@@ -70,13 +125,11 @@ func (l Logger) log(level int, err error, msg string, keysAndValues ...interface
 
 	kvsLen := len(l.values) + len(keysAndValues)
 	if kvsLen%2 != 0 {
-		if err := l.options.Sink.Log(Entry{
+		l.emit(Entry{
 			Names:     l.names,
 			Timestamp: now,
 			Error:     errors.New("odd number of arguments passed as key-value pairs for logging"),
-		}); err != nil {
-			l.options.ErrorHandler(err)
-		}
+		})
 		return
 	}
 
@@ -84,35 +137,161 @@ func (l Logger) log(level int, err error, msg string, keysAndValues ...interface
 	copy(kvs[:len(l.values)], l.values)
 	copy(kvs[len(l.values):], keysAndValues)
 
-	if err := l.options.Sink.Log(Entry{
-		Level:     level,
-		Names:     l.names,
-		Timestamp: now,
-		Message:   msg,
-		KVs:       kvs,
-		Error:     err,
-	}); err != nil {
-		l.options.ErrorHandler(err)
+	var caller *Caller
+	if l.options.CaptureCaller {
+		caller = l.caller()
+	}
+
+	var stack []StackFrame
+	if err != nil && l.options.CaptureStackTrace && !pkgErrorsHasStackTrace(err) {
+		stack = l.stack()
+	}
+
+	l.emit(Entry{
+		Level:      level,
+		Names:      l.names,
+		Timestamp:  now,
+		Message:    msg,
+		KVs:        kvs,
+		Error:      err,
+		Caller:     caller,
+		Stack:      stack,
+		PreEncoded: l.preEncoded,
+	})
+}
+
+// emit passes the Entry to the sink, reporting any error it returns, or any panic (e.g. from an error whose Error
+// method panics), to the ErrorHandler rather than crashing the caller
+func (l Logger) emit(e Entry) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			l.options.ErrorHandler(fmt.Errorf("recovered from panic while logging: %v", recovered))
+		}
+	}()
+
+	e.errorHandler = l.options.ErrorHandler
+	if err := l.options.Sink.Log(e); err != nil {
+		reportSinkErrors(l.options.Sink, e, err, l.options.ErrorHandler)
+	}
+}
+
+// caller identifies the code that called the logr.Logger, accounting for the frames added by logr itself, Logger.Info
+// or Logger.Error, Logger.log and this function
+func (l Logger) caller() *Caller {
+	pc, file, line, ok := runtime.Caller(3 + l.info.CallDepth + l.callDepth)
+	if !ok {
+		return nil
+	}
+
+	caller := &Caller{
+		File: file,
+		Line: line,
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		caller.Function = fn.Name()
+	}
+
+	return caller
+}
+
+// stack captures the stack of the code that called the logr.Logger, innermost first, skipping the same frames as
+// caller
+func (l Logger) stack() []StackFrame {
+	size := maxCapturedStackFrames
+	if l.options.StackTrace.MaxFrames > size {
+		size = l.options.StackTrace.MaxFrames
+	}
+	pcs := make([]uintptr, size)
+	// runtime.Callers counts itself as a frame, unlike runtime.Caller
+	n := runtime.Callers(4+l.info.CallDepth+l.callDepth, pcs)
+	if n == 0 {
+		return nil
+	}
+
+	stack := make([]StackFrame, 0, n)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			break
+		}
 	}
+
+	return l.options.StackTrace.trim(stack)
+}
+
+// WithCallDepth produces a new logger which attributes log messages to code the given number of call frames further up
+// the stack, see Options.CaptureCaller
+func (l Logger) WithCallDepth(depth int) logr.LogSink {
+	l.callDepth += depth
+	return &l
+}
+
+// withLevel produces a new logger which logs its non-verbose entries at the given negative level, see V
+func (l Logger) withLevel(level int) *Logger {
+	l.level = level
+	return &l
 }
 
 // WithValues produces a new logger containing additional key value pairs
 func (l Logger) WithValues(keysAndValues ...interface{}) logr.LogSink {
-	l.values = append(l.values, keysAndValues...)
+	values := make([]interface{}, len(l.values), len(l.values)+len(keysAndValues))
+	copy(values, l.values)
+	l.values = append(values, keysAndValues...)
+	l.preEncoded = l.preEncode()
 	return &l
 }
 
-// WithName produces a new logger with an additional name segment
+// preEncode encodes the accumulated values ahead of time if the sink is a PreEncoder, any problems with the values
+// (including panics) are instead reported when entries are logged
+func (l Logger) preEncode() (preEncoded *PreEncodedValues) {
+	defer func() {
+		if recover() != nil {
+			preEncoded = nil
+		}
+	}()
+
+	encoder, ok := l.options.Sink.(PreEncoder)
+	if !ok || len(l.values) == 0 || len(l.values)%2 != 0 {
+		return nil
+	}
+
+	preEncoded, err := encoder.PreEncode(l.values)
+	if err != nil {
+		return nil
+	}
+	return preEncoded
+}
+
+// WithName produces a new logger with an additional name segment, subject to the configured NameMode and
+// MaxNameDepth
 func (l Logger) WithName(name string) logr.LogSink {
-	l.names = append(l.names, name)
+	switch l.options.NameMode {
+	case NameModeReplace:
+		l.names = []string{name}
+	default:
+		if l.options.MaxNameDepth > 0 && len(l.names) >= l.options.MaxNameDepth {
+			return &l
+		}
+		names := make([]string, len(l.names), len(l.names)+1)
+		copy(names, l.names)
+		l.names = append(names, name)
+	}
 	return &l
 }
 
 var _ logr.LogSink = (*Logger)(nil)
+var _ logr.CallDepthLogSink = (*Logger)(nil)
 
 // Entry represents a log entry prepared by Logger, ready for a LogSink to emit (typically by writing to stdout/stderr)
 type Entry struct {
-	// Level is the verbosity level of this log event, 0 being "least verbose", and larger numbers being more verbose
+	// Level is the verbosity level of this log event, 0 being "least verbose", and larger numbers being more verbose.
+	// Negative levels, such as LevelWarn, are more severe than 0 without being errors
 	Level int
 	// Names is the list of names accumulated by chained calls to Logger.WithName
 	Names []string
@@ -126,4 +305,61 @@ type Entry struct {
 	KVs []interface{}
 	// Error is the error passed to Logger.Error, and may be nil.
 	Error error
+	// Caller identifies the code that logged this entry, and is nil unless Options.CaptureCaller is enabled
+	Caller *Caller
+	// Stack is the stack of the code that logged this entry, innermost first, and is nil unless
+	// Options.CaptureStackTrace is enabled and the Error has no stack trace of its own
+	Stack []StackFrame
+	// Severity, if specified, overrides the severity name that sinks would otherwise derive from the Level and Error
+	// using their severity encoder, e.g. as set by a Processor
+	Severity string
+	// Sequence orders entries consistently across destinations, and is zero unless assigned by an ordered MultiSink,
+	// see NewOrderedMultiSink
+	Sequence uint64
+	// PreEncoded, if specified, is an encoding of the key-value pairs at the start of KVs prepared by the sink the
+	// Logger was configured with, see PreEncoder
+	PreEncoded *PreEncodedValues
+
+	// errorHandler is the ErrorHandler of the Logger that created the entry, see reportError
+	errorHandler func(err error)
+}
+
+// reportError reports a problem that did not prevent the entry being written, such as a value replaced by a
+// placeholder because it panicked, to the ErrorHandler of the Logger that created the entry, or to DefaultErrorHandler
+// for entries created elsewhere
+func (e Entry) reportError(err error) {
+	if e.errorHandler != nil {
+		e.errorHandler(err)
+	} else {
+		DefaultErrorHandler(err)
+	}
+}
+
+// severity determines the severity name of the entry, using the encoder unless overridden by Entry.Severity
+func (e Entry) severity(encoder func(level int, err error) string) string {
+	if e.Severity != "" {
+		return e.Severity
+	}
+	return encoder(e.Level, e.Error)
+}
+
+// Value returns the value of the key-value pair with the given key, and false if there is none. If the key occurs more
+// than once, the last value is returned
+func (e Entry) Value(key string) (interface{}, bool) {
+	for i := len(e.KVs) - 2; i >= 0; i -= 2 {
+		if k, ok := e.KVs[i].(string); ok && k == key {
+			return e.KVs[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// Caller identifies a location in the source code
+type Caller struct {
+	// Function is the fully qualified name of the function
+	Function string
+	// File is the full path of the source file
+	File string
+	// Line is the line number within the source file
+	Line int
 }
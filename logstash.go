@@ -0,0 +1,25 @@
+package simplelogr
+
+var (
+	// LogstashTimestampFormat is the timestamp format used by LogstashJSONLogSinkOptions, with millisecond precision
+	LogstashTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// LogstashJSONLogSinkOptions produces JSONLogSinkOptions emitting the classic Logstash JSON event schema, as produced
+// by the Logback encoders: @timestamp, @version, level, logger_name, message and stack_trace. This allows services
+// migrating from Logback to keep a compatible schema. The remaining options (e.g. Output) can be customised before
+// calling AssertDefaults as usual.
+func LogstashJSONLogSinkOptions() JSONLogSinkOptions {
+	return JSONLogSinkOptions{
+		TimestampKey:     "@timestamp",
+		TimestampEncoder: DefaultTimestampEncoder(LogstashTimestampFormat),
+		SeverityKey:      "level",
+		NameKey:          "logger_name",
+		MessageKey:       "message",
+		ErrorKey:         "error",
+		StackTraceKey:    "stack_trace",
+		StaticFields: map[string]interface{}{
+			"@version": "1",
+		},
+	}
+}
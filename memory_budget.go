@@ -0,0 +1,237 @@
+package simplelogr
+
+import (
+	"sync"
+)
+
+var (
+	// DefaultMemoryBudgetLimit is the number of bytes a MemoryBudget allows when no limit is specified
+	DefaultMemoryBudgetLimit int64 = 64 * 1024 * 1024
+)
+
+// entryOverhead approximates the memory used by an Entry itself, excluding the data it refers to
+const entryOverhead = 160
+
+// MemoryPolicy determines what a buffering component does when buffering another entry would exceed its MemoryBudget
+type MemoryPolicy int
+
+const (
+	// MemoryPolicyDrop drops the new entry, keeping the entries already buffered
+	MemoryPolicyDrop MemoryPolicy = iota
+	// MemoryPolicyShrink discards the oldest buffered entries until there is room for the new entry, preferring
+	// recent entries to old ones. If discarding all of the component's entries would still not make room, because
+	// other components are using the rest of the budget, the new entry is dropped instead
+	MemoryPolicyShrink
+)
+
+// MemoryBudget limits the total memory used by the components that buffer entries, such as AsyncSink queues,
+// DebugOnErrorSink ring buffers and SplunkHECLogSink batches, so that enabling several of them cannot unexpectedly
+// consume a large amount of memory. Components share a budget by being given the same MemoryBudget in their Memory
+// options, and account for the approximate size of each entry they buffer, see EstimateEntrySize. It is safe for
+// concurrent use.
+type MemoryBudget struct {
+	options MemoryBudgetOptions
+
+	lock       sync.Mutex
+	used       int64
+	components map[string]int64
+	dropped    map[string]uint64
+}
+
+// MemoryUsage is a snapshot of the accounting of a MemoryBudget
+type MemoryUsage struct {
+	// Limit is the number of bytes the budget allows
+	Limit int64
+	// Used is the number of bytes currently reserved
+	Used int64
+	// Components is the number of bytes currently reserved, by component name
+	Components map[string]int64
+	// Dropped is the number of entries rejected or discarded to keep within the budget, by component name
+	Dropped map[string]uint64
+}
+
+// MemoryBudgetOptions configures the behaviour of a MemoryBudget
+type MemoryBudgetOptions struct {
+	// Limit is the total number of bytes that may be reserved
+	Limit int64
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (m *MemoryBudgetOptions) AssertDefaults() {
+	if m.Limit <= 0 {
+		m.Limit = DefaultMemoryBudgetLimit
+	}
+}
+
+// NewMemoryBudget creates a new MemoryBudget with the provided options
+func NewMemoryBudget(opts MemoryBudgetOptions) *MemoryBudget {
+	return &MemoryBudget{
+		options:    opts,
+		components: map[string]int64{},
+		dropped:    map[string]uint64{},
+	}
+}
+
+// Reserve accounts for size bytes buffered by the named component, reporting false and reserving nothing if that
+// would exceed the limit, in which case the component should drop the entry. Reserved bytes must be returned using
+// Release once the entry is no longer buffered.
+func (m *MemoryBudget) Reserve(component string, size int64) bool {
+	if m.tryReserve(component, size) {
+		return true
+	}
+	m.drop(component)
+	return false
+}
+
+// Release returns size bytes previously reserved by the named component
+func (m *MemoryBudget) Release(component string, size int64) {
+	if size == 0 {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.used -= size
+	m.components[component] -= size
+}
+
+// Usage returns a snapshot of the budget's accounting
+func (m *MemoryBudget) Usage() MemoryUsage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	usage := MemoryUsage{
+		Limit:      m.options.Limit,
+		Used:       m.used,
+		Components: make(map[string]int64, len(m.components)),
+		Dropped:    make(map[string]uint64, len(m.dropped)),
+	}
+	for component, used := range m.components {
+		usage.Components[component] = used
+	}
+	for component, dropped := range m.dropped {
+		usage.Dropped[component] = dropped
+	}
+
+	return usage
+}
+
+func (m *MemoryBudget) tryReserve(component string, size int64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.used+size > m.options.Limit {
+		return false
+	}
+	m.used += size
+	m.components[component] += size
+	return true
+}
+
+// fitsWithout reports whether size bytes would fit within the limit if all of the named component's reserved bytes were
+// released, i.e. whether the component can make room for them by discarding its own entries
+func (m *MemoryBudget) fitsWithout(component string, size int64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.used-m.components[component]+size <= m.options.Limit
+}
+
+func (m *MemoryBudget) drop(component string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.dropped[component]++
+}
+
+// MemoryLimit attaches a buffering component to a MemoryBudget
+type MemoryLimit struct {
+	// Budget is the MemoryBudget the component's buffered entries are accounted against, if nil the component's
+	// memory usage is only limited by its own options (e.g. AsyncSinkOptions.QueueSize)
+	Budget *MemoryBudget
+	// Component names the component in the budget's accounting, defaulting to a name describing the type of component
+	Component string
+	// Policy determines what happens when buffering another entry would exceed the budget
+	Policy MemoryPolicy
+}
+
+// assertDefaults names the component if it has not been named
+func (l *MemoryLimit) assertDefaults(component string) {
+	if l.Component == "" {
+		l.Component = component
+	}
+}
+
+// reserve reserves size bytes for a new entry, following the policy if the budget is exceeded. With
+// MemoryPolicyShrink, evict is called to discard the component's oldest buffered entry, returning its size, or false if
+// there are no entries left to discard. Entries are only evicted while doing so can make room, so a new entry that
+// would not fit even once all of the component's entries were discarded, because other components sharing the budget
+// are using it, is dropped without evicting anything. Evicted entries count as dropped, as does the new entry if there
+// is no room.
+func (l MemoryLimit) reserve(size int64, evict func() (int64, bool)) bool {
+	if l.Budget == nil {
+		return true
+	}
+
+	for !l.Budget.tryReserve(l.Component, size) {
+		if l.Policy != MemoryPolicyShrink || !l.Budget.fitsWithout(l.Component, size) {
+			l.Budget.drop(l.Component)
+			return false
+		}
+
+		released, ok := evict()
+		if !ok {
+			l.Budget.drop(l.Component)
+			return false
+		}
+		l.Budget.drop(l.Component)
+		l.Budget.Release(l.Component, released)
+	}
+
+	return true
+}
+
+// release returns size bytes reserved using reserve
+func (l MemoryLimit) release(size int64) {
+	if l.Budget != nil {
+		l.Budget.Release(l.Component, size)
+	}
+}
+
+// size estimates the memory used by buffering an Entry, or zero if the component is not attached to a budget
+func (l MemoryLimit) size(e Entry) int64 {
+	if l.Budget == nil {
+		return 0
+	}
+	return EstimateEntrySize(e)
+}
+
+// EstimateEntrySize approximates the memory retained by buffering an Entry, for accounting against a MemoryBudget.
+// Strings and byte slices are measured, while other values are assumed to be small.
+func EstimateEntrySize(e Entry) int64 {
+	size := int64(entryOverhead + len(e.Message) + len(e.Severity))
+	for _, name := range e.Names {
+		size += int64(16 + len(name))
+	}
+	for _, kv := range e.KVs {
+		size += estimateValueSize(kv)
+	}
+	if e.Error != nil {
+		size += 64
+	}
+	if e.Caller != nil {
+		size += int64(40 + len(e.Caller.Function) + len(e.Caller.File))
+	}
+	return size
+}
+
+func estimateValueSize(v interface{}) int64 {
+	switch value := v.(type) {
+	case string:
+		return int64(32 + len(value))
+	case []byte:
+		return int64(40 + len(value))
+	case nil:
+		return 16
+	default:
+		return 32
+	}
+}
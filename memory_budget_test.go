@@ -0,0 +1,78 @@
+package simplelogr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sizedEntry creates a verbose Entry that EstimateEntrySize estimates as size bytes
+func sizedEntry(message string, size int) Entry {
+	return Entry{
+		Level:   1,
+		Message: message + strings.Repeat(".", size-entryOverhead-len(message)),
+	}
+}
+
+func TestMemoryPolicyShrinkSharedBudget(t *testing.T) {
+	budget := NewMemoryBudget(MemoryBudgetOptions{Limit: 1000})
+
+	newSink := func(component string, policy MemoryPolicy) (*DebugOnErrorSink, *recordingSink) {
+		recorded := &recordingSink{}
+		opts := DebugOnErrorSinkOptions{
+			Sink:   recorded,
+			Memory: MemoryLimit{Budget: budget, Component: component, Policy: policy},
+		}
+		opts.AssertDefaults()
+		return NewDebugOnErrorSink(opts), recorded
+	}
+	shrinking, shrinkingRecorded := newSink("shrinking", MemoryPolicyShrink)
+	other, _ := newSink("other", MemoryPolicyDrop)
+
+	for _, message := range []string{"first", "second", "third"} {
+		if err := shrinking.Log(sizedEntry(message, 200)); err != nil {
+			t.Fatalf("failed to log: %v", err)
+		}
+	}
+	for _, message := range []string{"fourth", "fifth"} {
+		if err := other.Log(sizedEntry(message, 200)); err != nil {
+			t.Fatalf("failed to log: %v", err)
+		}
+	}
+
+	// discarding every entry of the shrinking component would only free 600 of the 700 bytes needed, so nothing should
+	// be evicted to make room
+	if err := shrinking.Log(sizedEntry("too large", 700)); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	usage := budget.Usage()
+	if usage.Components["shrinking"] != 600 || usage.Dropped["shrinking"] != 1 {
+		t.Errorf("expected 600 bytes used and 1 entry dropped, got %d bytes used and %d dropped",
+			usage.Components["shrinking"], usage.Dropped["shrinking"])
+	}
+
+	// discarding the two oldest entries makes room
+	if err := shrinking.Log(sizedEntry("sixth", 300)); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	usage = budget.Usage()
+	if usage.Components["shrinking"] != 500 || usage.Dropped["shrinking"] != 3 {
+		t.Errorf("expected 500 bytes used and 3 entries dropped, got %d bytes used and %d dropped",
+			usage.Components["shrinking"], usage.Dropped["shrinking"])
+	}
+	if usage.Components["other"] != 400 || usage.Dropped["other"] != 0 {
+		t.Errorf("expected the other component to be unaffected, got %d bytes used and %d dropped",
+			usage.Components["other"], usage.Dropped["other"])
+	}
+
+	if err := shrinking.Log(Entry{Message: "failed", Error: errors.New("something went wrong")}); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	var messages []string
+	for _, e := range shrinkingRecorded.entries {
+		messages = append(messages, strings.TrimRight(e.Message, "."))
+	}
+	if strings.Join(messages, ",") != "third,sixth,failed" {
+		t.Errorf("expected the newest entries to be kept, got %v", messages)
+	}
+}
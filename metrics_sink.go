@@ -0,0 +1,243 @@
+package simplelogr
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// DefaultMetricsNamespace prefixes the names of the metrics exposed by a MetricsSink
+	DefaultMetricsNamespace = "simplelogr"
+)
+
+// Metrics is a snapshot of the counters maintained by a MetricsSink
+type Metrics struct {
+	// Entries is the number of entries logged, by severity name and logger name
+	Entries []EntryCount
+	// SinkErrors is the number of errors reported by the wrapped sink, and by any error handlers wrapped using
+	// MetricsSink.ErrorHandler
+	SinkErrors uint64
+	// Dropped is the number of entries discarded, by the name of the DropCounter reporting them
+	Dropped map[string]uint64
+	// Memory is the accounting of the MemoryBudget, if one was specified
+	Memory *MemoryUsage
+}
+
+// EntryCount is the number of entries logged with a given severity by a given logger
+type EntryCount struct {
+	Severity string
+	Name     string
+	Count    uint64
+}
+
+// MetricsSink passes entries on to another LogSink while counting them by severity and logger name, along with any
+// errors the sink reports and the entries dropped by asynchronous or rate limited sinks, turning log noise into a
+// signal that can be alerted on.
+//
+// The counters can be registered on a prometheus.Registerer using the simplelogrprom module, which keeps the Prometheus
+// client dependency out of this one, scraped directly in the Prometheus text format by serving the sink as an
+// http.Handler, or read with Metrics.
+type MetricsSink struct {
+	options MetricsSinkOptions
+
+	lock       sync.Mutex
+	entries    map[EntryCount]uint64
+	sinkErrors uint64
+}
+
+// NewMetricsSink creates a new MetricsSink with the provided options
+func NewMetricsSink(opts MetricsSinkOptions) *MetricsSink {
+	return &MetricsSink{
+		options: opts,
+		entries: map[EntryCount]uint64{},
+	}
+}
+
+// Log implements LogSink, counting the Entry before passing it on to the wrapped sink
+func (m *MetricsSink) Log(e Entry) error {
+	key := EntryCount{
+		Severity: e.severity(m.options.SeverityEncoder),
+		Name:     m.options.NameEncoder(e.Names),
+	}
+
+	err := m.options.Sink.Log(e)
+
+	m.lock.Lock()
+	m.entries[key]++
+	if err != nil {
+		m.sinkErrors++
+	}
+	m.lock.Unlock()
+
+	return err
+}
+
+// ErrorHandler wraps an error handler so that the errors it handles are also counted, e.g. for the ErrorHandler
+// options of sinks that emit entries in the background
+func (m *MetricsSink) ErrorHandler(handler func(err error)) func(err error) {
+	return func(err error) {
+		m.lock.Lock()
+		m.sinkErrors++
+		m.lock.Unlock()
+
+		handler(err)
+	}
+}
+
+// Namespace returns the prefix of the names of the exposed metrics, see MetricsSinkOptions.Namespace
+func (m *MetricsSink) Namespace() string {
+	return m.options.Namespace
+}
+
+// Metrics returns a snapshot of the counters
+func (m *MetricsSink) Metrics() Metrics {
+	m.lock.Lock()
+	metrics := Metrics{
+		Entries:    make([]EntryCount, 0, len(m.entries)),
+		SinkErrors: m.sinkErrors,
+		Dropped:    make(map[string]uint64, len(m.options.DropCounters)),
+	}
+	for key, count := range m.entries {
+		key.Count = count
+		metrics.Entries = append(metrics.Entries, key)
+	}
+	m.lock.Unlock()
+
+	sort.Slice(metrics.Entries, func(i, j int) bool {
+		if metrics.Entries[i].Name != metrics.Entries[j].Name {
+			return metrics.Entries[i].Name < metrics.Entries[j].Name
+		}
+		return metrics.Entries[i].Severity < metrics.Entries[j].Severity
+	})
+
+	for name, counter := range m.options.DropCounters {
+		metrics.Dropped[name] = counter.Dropped()
+	}
+
+	if m.options.MemoryBudget != nil {
+		usage := m.options.MemoryBudget.Usage()
+		metrics.Memory = &usage
+	}
+
+	return metrics
+}
+
+// ServeHTTP implements http.Handler, writing the counters in the Prometheus text exposition format
+func (m *MetricsSink) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	metrics := m.Metrics()
+	ns := m.options.Namespace
+	buffer := bytes.Buffer{}
+
+	fmt.Fprintf(&buffer, "# HELP %s_entries_total Number of log entries, by severity and logger name.\n", ns)
+	fmt.Fprintf(&buffer, "# TYPE %s_entries_total counter\n", ns)
+	for _, entry := range metrics.Entries {
+		fmt.Fprintf(&buffer, "%s_entries_total{name=%s,severity=%s} %d\n",
+			ns, prometheusLabel(entry.Name), prometheusLabel(entry.Severity), entry.Count)
+	}
+
+	fmt.Fprintf(&buffer, "# HELP %s_sink_errors_total Number of errors emitting log entries.\n", ns)
+	fmt.Fprintf(&buffer, "# TYPE %s_sink_errors_total counter\n", ns)
+	fmt.Fprintf(&buffer, "%s_sink_errors_total %d\n", ns, metrics.SinkErrors)
+
+	names := make([]string, 0, len(metrics.Dropped))
+	for name := range metrics.Dropped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&buffer, "# HELP %s_dropped_entries_total Number of log entries discarded, by sink.\n", ns)
+	fmt.Fprintf(&buffer, "# TYPE %s_dropped_entries_total counter\n", ns)
+	for _, name := range names {
+		fmt.Fprintf(&buffer, "%s_dropped_entries_total{sink=%s} %d\n", ns, prometheusLabel(name), metrics.Dropped[name])
+	}
+
+	if metrics.Memory != nil {
+		writeMemoryMetrics(&buffer, ns, *metrics.Memory)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buffer.Bytes())
+}
+
+// writeMemoryMetrics writes the accounting of a MemoryBudget in the Prometheus text exposition format
+func writeMemoryMetrics(buffer *bytes.Buffer, ns string, usage MemoryUsage) {
+	fmt.Fprintf(buffer, "# HELP %s_memory_limit_bytes Memory available for buffering log entries.\n", ns)
+	fmt.Fprintf(buffer, "# TYPE %s_memory_limit_bytes gauge\n", ns)
+	fmt.Fprintf(buffer, "%s_memory_limit_bytes %d\n", ns, usage.Limit)
+
+	components := make([]string, 0, len(usage.Components))
+	for component := range usage.Components {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	fmt.Fprintf(buffer, "# HELP %s_memory_used_bytes Memory used by buffered log entries, by component.\n", ns)
+	fmt.Fprintf(buffer, "# TYPE %s_memory_used_bytes gauge\n", ns)
+	for _, component := range components {
+		fmt.Fprintf(buffer, "%s_memory_used_bytes{component=%s} %d\n",
+			ns, prometheusLabel(component), usage.Components[component])
+	}
+
+	components = components[:0]
+	for component := range usage.Dropped {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	fmt.Fprintf(buffer, "# HELP %s_memory_dropped_entries_total Number of log entries discarded to stay within the memory budget, by component.\n", ns)
+	fmt.Fprintf(buffer, "# TYPE %s_memory_dropped_entries_total counter\n", ns)
+	for _, component := range components {
+		fmt.Fprintf(buffer, "%s_memory_dropped_entries_total{component=%s} %d\n",
+			ns, prometheusLabel(component), usage.Dropped[component])
+	}
+}
+
+// prometheusLabel quotes a label value for the Prometheus text exposition format
+func prometheusLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v) + `"`
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (m *MetricsSink) Unwrap() []LogSink {
+	return []LogSink{m.options.Sink}
+}
+
+var _ LogSink = (*MetricsSink)(nil)
+var _ WrapperSink = (*MetricsSink)(nil)
+var _ http.Handler = (*MetricsSink)(nil)
+
+// MetricsSinkOptions configures the behaviour of a MetricsSink
+type MetricsSinkOptions struct {
+	// Sink is the LogSink that entries are passed on to
+	Sink LogSink
+	// DropCounters are the sources of dropped entry counts to report, by name, e.g. AsyncSink or NetworkLogSink
+	DropCounters map[string]DropCounter
+	// MemoryBudget, if specified, has its accounting reported alongside the counters
+	MemoryBudget *MemoryBudget
+	// Namespace prefixes the names of the exposed metrics
+	Namespace string
+	// SeverityEncoder identifies the severity name used to count entries
+	SeverityEncoder func(level int, err error) string
+	// NameEncoder collapses the series of Logger names down into the name used to count entries, which should be
+	// limited to a small number of distinct values
+	NameEncoder func(names []string) string
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (m *MetricsSinkOptions) AssertDefaults() {
+	if m.Namespace == "" {
+		m.Namespace = DefaultMetricsNamespace
+	}
+
+	if m.SeverityEncoder == nil {
+		m.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if m.NameEncoder == nil {
+		m.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+}
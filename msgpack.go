@@ -0,0 +1,363 @@
+package simplelogr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// appendMsgpack appends the MessagePack encoding of the given value to the buffer. Common types are encoded
+// directly, while anything else (e.g. structs) is encoded via its JSON representation so that it honours
+// json.Marshaler implementations and struct tags. Values that cannot be encoded are replaced by placeholders, which
+// are returned as placeholderErrors once the value is complete.
+func appendMsgpack(buf []byte, v interface{}) ([]byte, error) {
+	var placeholders placeholderErrors
+	switch value := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if value {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case int:
+		return appendMsgpackInt(buf, int64(value)), nil
+	case int8:
+		return appendMsgpackInt(buf, int64(value)), nil
+	case int16:
+		return appendMsgpackInt(buf, int64(value)), nil
+	case int32:
+		return appendMsgpackInt(buf, int64(value)), nil
+	case int64:
+		return appendMsgpackInt(buf, value), nil
+	case uint:
+		return appendMsgpackUint(buf, uint64(value)), nil
+	case uint8:
+		return appendMsgpackUint(buf, uint64(value)), nil
+	case uint16:
+		return appendMsgpackUint(buf, uint64(value)), nil
+	case uint32:
+		return appendMsgpackUint(buf, uint64(value)), nil
+	case uint64:
+		return appendMsgpackUint(buf, value), nil
+	case float32:
+		buf = append(buf, 0xca)
+		return appendUint32(buf, math.Float32bits(value)), nil
+	case float64:
+		buf = append(buf, 0xcb)
+		return appendUint64(buf, math.Float64bits(value)), nil
+	case string:
+		return appendMsgpackString(buf, value), nil
+	case []byte:
+		return appendMsgpackBinary(buf, value), nil
+	case time.Time:
+		return appendMsgpackString(buf, value.Format(time.RFC3339Nano)), nil
+	case *placeholderValue:
+		return appendMsgpackString(buf, value.placeholder), value
+	case error:
+		return appendMsgpackString(buf, value.Error()), nil
+	case []interface{}:
+		buf = appendMsgpackArrayHeader(buf, len(value))
+		for _, item := range value {
+			var err error
+			if buf, err = appendMsgpack(buf, item); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	case *jsonObject:
+		buf = appendMsgpackMapHeader(buf, len(value.keys))
+		for _, k := range value.keys {
+			buf = appendMsgpackString(buf, k)
+			var err error
+			if buf, err = appendMsgpack(buf, value.values[k]); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	case map[string]interface{}:
+		buf = appendMsgpackMapHeader(buf, len(value))
+		for k, item := range value {
+			buf = appendMsgpackString(buf, k)
+			var err error
+			if buf, err = appendMsgpack(buf, item); err != nil && !placeholders.collect(err) {
+				return buf, err
+			}
+		}
+		return buf, placeholders.err()
+	}
+
+	// round trip anything else through JSON to reduce it to the basic types handled above
+	generic, err := jsonGeneric(v)
+	if err != nil {
+		placeholder, ok := err.(*placeholderValue)
+		if !ok {
+			placeholder = errorPlaceholder(v, err)
+		}
+		return appendMsgpackString(buf, placeholder.placeholder), placeholder
+	}
+	return appendMsgpack(buf, generic)
+}
+
+// jsonGeneric reduces a value to the generic types produced by decoding its JSON representation (with numbers
+// converted to int64 or float64), for binary encodings of values they do not encode directly. Values that panic while
+// being encoded are returned as a placeholderValue error
+func jsonGeneric(v interface{}) (interface{}, error) {
+	b, err := defaultJSONEncoder.marshalSafely(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return normaliseJSONNumbers(generic), nil
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return appendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return appendUint32(append(buf, 0xd2), uint32(v))
+	default:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(buf []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return appendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return appendUint32(append(buf, 0xce), uint32(v))
+	default:
+		return appendUint64(append(buf, 0xcf), v)
+	}
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendMsgpackBinary(buf []byte, b []byte) []byte {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xc5), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, b...)
+}
+
+func appendMsgpackArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return appendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return appendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackExt appends a MessagePack extension value with the given type and data
+func appendMsgpackExt(buf []byte, extType int8, data []byte) []byte {
+	switch n := len(data); n {
+	case 1:
+		buf = append(buf, 0xd4)
+	case 2:
+		buf = append(buf, 0xd5)
+	case 4:
+		buf = append(buf, 0xd6)
+	case 8:
+		buf = append(buf, 0xd7)
+	case 16:
+		buf = append(buf, 0xd8)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xc7, byte(n))
+		case n <= math.MaxUint16:
+			buf = appendUint16(append(buf, 0xc8), uint16(n))
+		default:
+			buf = appendUint32(append(buf, 0xc9), uint32(n))
+		}
+	}
+	buf = append(buf, byte(extType))
+	return append(buf, data...)
+}
+
+// readMsgpackStringMap decodes a MessagePack map of strings to strings (ignoring entries with other value types) from
+// the given data, as used by small protocol responses
+func readMsgpackStringMap(data []byte) (map[string]string, error) {
+	r := msgpackReader{data: data}
+
+	n, err := r.mapHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		result[k] = v
+	}
+
+	return result, nil
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if r.pos+n > len(r.data) {
+		return nil, errMsgpackShort
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) mapHeader() (int, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b[0]&0xf0 == 0x80:
+		return int(b[0] & 0x0f), nil
+	case b[0] == 0xde:
+		n, err := r.next(2)
+		if err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint16(n)), nil
+	case b[0] == 0xdf:
+		n, err := r.next(4)
+		if err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint32(n)), nil
+	}
+	return 0, fmt.Errorf("msgpack: expected map, got type byte 0x%02x", b[0])
+}
+
+func (r *msgpackReader) string() (string, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return "", err
+	}
+
+	var n int
+	switch {
+	case b[0]&0xe0 == 0xa0:
+		n = int(b[0] & 0x1f)
+	case b[0] == 0xd9 || b[0] == 0xc4:
+		l, err := r.next(1)
+		if err != nil {
+			return "", err
+		}
+		n = int(l[0])
+	case b[0] == 0xda || b[0] == 0xc5:
+		l, err := r.next(2)
+		if err != nil {
+			return "", err
+		}
+		n = int(binary.BigEndian.Uint16(l))
+	case b[0] == 0xdb || b[0] == 0xc6:
+		l, err := r.next(4)
+		if err != nil {
+			return "", err
+		}
+		n = int(binary.BigEndian.Uint32(l))
+	default:
+		return "", fmt.Errorf("msgpack: expected string, got type byte 0x%02x", b[0])
+	}
+
+	s, err := r.next(n)
+	return string(s), err
+}
+
+// normaliseJSONNumbers converts json.Number values produced by decoding with UseNumber into int64 or float64
+func normaliseJSONNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case []interface{}:
+		for i := range value {
+			value[i] = normaliseJSONNumbers(value[i])
+		}
+	case map[string]interface{}:
+		for k := range value {
+			value[k] = normaliseJSONNumbers(value[k])
+		}
+	}
+	return v
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(v>>32)), uint32(v))
+}
@@ -0,0 +1,68 @@
+package simplelogr
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// MultiSink emits each Entry to several other LogSink objects, e.g. to log to both a file and the console
+type MultiSink struct {
+	sinks    []LogSink
+	sequence *uint64
+}
+
+// NewMultiSink creates a new MultiSink emitting to all the given sinks
+func NewMultiSink(sinks ...LogSink) *MultiSink {
+	return &MultiSink{
+		sinks: sinks,
+	}
+}
+
+// NewOrderedMultiSink creates a new MultiSink that assigns each Entry a shared sequence number before emitting it to
+// all the given sinks, so that destinations which buffer or emit asynchronously can still agree on the order entries
+// were logged in, see Entry.Sequence. Entries that already have a sequence number keep it.
+func NewOrderedMultiSink(sinks ...LogSink) *MultiSink {
+	return &MultiSink{
+		sinks:    sinks,
+		sequence: new(uint64),
+	}
+}
+
+// Log implements LogSink, emitting the Entry to every sink even if some of them fail
+func (m MultiSink) Log(e Entry) error {
+	if m.sequence != nil && e.Sequence == 0 {
+		e.Sequence = atomic.AddUint64(m.sequence, 1)
+	}
+
+	var errs multiError
+	for _, sink := range m.sinks {
+		if err := sink.Log(e); err != nil {
+			errs = append(errs, newSinkError(sink, e, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Unwrap implements WrapperSink, returning the sinks entries are emitted to
+func (m MultiSink) Unwrap() []LogSink {
+	return m.sinks
+}
+
+var _ LogSink = (*MultiSink)(nil)
+var _ WrapperSink = (*MultiSink)(nil)
+
+// multiError combines several errors into one
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,302 @@
+package simplelogr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultNetworkDialTimeout bounds how long a NetworkLogSink waits to connect
+	DefaultNetworkDialTimeout = 5 * time.Second
+	// DefaultNetworkWriteTimeout bounds how long a NetworkLogSink waits to write an entry
+	DefaultNetworkWriteTimeout = 5 * time.Second
+	// DefaultNetworkMinBackoff is how long a NetworkLogSink waits before its first reconnection attempt
+	DefaultNetworkMinBackoff = 100 * time.Millisecond
+	// DefaultNetworkMaxBackoff caps how long a NetworkLogSink waits between reconnection attempts
+	DefaultNetworkMaxBackoff = 30 * time.Second
+)
+
+// NetworkLogSink writes encoded log Entry objects to a TCP or UDP endpoint. When the connection fails it reconnects
+// with exponential backoff, and can buffer entries in a bounded in-memory spool while disconnected rather than
+// failing every log call. Only one Log call at a time waits to connect, entries logged meanwhile are spooled as if
+// disconnected. It is safe for concurrent use.
+type NetworkLogSink struct {
+	options NetworkLogSinkOptions
+
+	lock        sync.Mutex
+	conn        net.Conn
+	dialing     bool
+	backoff     time.Duration
+	nextAttempt time.Time
+	spool       []spooledEntry
+	dropped     uint64
+
+	// closing is cancelled by Close, abandoning any connection attempt in progress
+	closing context.Context
+	stop    context.CancelFunc
+	// dial connects to the endpoint, and is only replaced by tests
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// spooledEntry is an encoded entry waiting to be written, along with when it was logged
+type spooledEntry struct {
+	data      []byte
+	timestamp time.Time
+}
+
+// NewNetworkLogSink creates a new NetworkLogSink with the provided options, the endpoint is connected to lazily when
+// the first Entry is logged
+func NewNetworkLogSink(opts NetworkLogSinkOptions) *NetworkLogSink {
+	closing, stop := context.WithCancel(context.Background())
+	dialer := &net.Dialer{Timeout: opts.DialTimeout}
+	return &NetworkLogSink{
+		options: opts,
+		closing: closing,
+		stop:    stop,
+		dial:    dialer.DialContext,
+	}
+}
+
+// Log implements LogSink, encoding the Entry and writing it to the endpoint, or spooling it if disconnected
+func (n *NetworkLogSink) Log(e Entry) error {
+	buffer := bytes.Buffer{}
+	if err := n.options.Encoder.Encode(&buffer, e); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.closing.Err() != nil {
+		return os.ErrClosed
+	}
+
+	if err := n.connectLocked(); err != nil {
+		if n.closing.Err() != nil {
+			return os.ErrClosed
+		}
+		if n.spoolLocked(buffer.Bytes(), e.Timestamp) {
+			return nil
+		}
+		return err
+	}
+
+	n.expireLocked(time.Now())
+	for len(n.spool) > 0 {
+		if err := n.writeLocked(n.spool[0].data); err != nil {
+			if n.spoolLocked(buffer.Bytes(), e.Timestamp) {
+				return nil
+			}
+			return err
+		}
+		n.spool = n.spool[1:]
+	}
+
+	if err := n.writeLocked(buffer.Bytes()); err != nil {
+		if n.spoolLocked(buffer.Bytes(), e.Timestamp) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Dropped implements DropCounter, reporting how many entries were discarded because the spool was full or they
+// expired
+func (n *NetworkLogSink) Dropped() uint64 {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.dropped
+}
+
+// Close closes the connection to the endpoint, abandoning any connection attempt in progress, and any spooled entries
+// are discarded. Logging to the sink afterwards fails with os.ErrClosed
+func (n *NetworkLogSink) Close() error {
+	n.stop()
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.dropped += uint64(len(n.spool))
+	n.spool = nil
+
+	if n.conn == nil {
+		return nil
+	}
+
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+// connectLocked connects to the endpoint if not already connected, unless still backing off from a failed attempt or
+// another call is already connecting. The lock is released while connecting, so that concurrent calls are not held up
+// by an unreachable endpoint.
+func (n *NetworkLogSink) connectLocked() error {
+	if n.conn != nil {
+		return nil
+	}
+
+	if n.dialing {
+		return fmt.Errorf("not connected to %s %s, connecting", n.options.Network, n.options.Address)
+	}
+
+	now := time.Now()
+	if now.Before(n.nextAttempt) {
+		return fmt.Errorf("not connected to %s %s, next attempt in %s", n.options.Network, n.options.Address, n.nextAttempt.Sub(now))
+	}
+
+	n.dialing = true
+	n.lock.Unlock()
+	conn, err := n.dial(n.closing, n.options.Network, n.options.Address)
+	n.lock.Lock()
+	n.dialing = false
+
+	if err == nil && n.closing.Err() != nil {
+		_ = conn.Close()
+		err = os.ErrClosed
+	}
+	if err != nil {
+		n.failedLocked(time.Now())
+		return fmt.Errorf("failed to connect to %s %s: %w", n.options.Network, n.options.Address, err)
+	}
+
+	n.conn = conn
+	n.backoff = 0
+	return nil
+}
+
+func (n *NetworkLogSink) writeLocked(b []byte) error {
+	_ = n.conn.SetWriteDeadline(time.Now().Add(n.options.WriteTimeout))
+	if _, err := n.conn.Write(b); err != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+		n.failedLocked(time.Now())
+		return fmt.Errorf("failed to write to %s %s: %w", n.options.Network, n.options.Address, err)
+	}
+	return nil
+}
+
+// failedLocked schedules the next connection attempt, doubling the backoff each time
+func (n *NetworkLogSink) failedLocked(now time.Time) {
+	if n.backoff == 0 {
+		n.backoff = n.options.MinBackoff
+	} else {
+		n.backoff *= 2
+	}
+	if n.backoff > n.options.MaxBackoff {
+		n.backoff = n.options.MaxBackoff
+	}
+	n.nextAttempt = now.Add(n.backoff)
+}
+
+// spoolLocked buffers an encoded entry for later delivery, returning false if spooling is disabled. When the spool is
+// full the oldest entry is dropped.
+func (n *NetworkLogSink) spoolLocked(b []byte, timestamp time.Time) bool {
+	if n.options.SpoolSize <= 0 {
+		return false
+	}
+
+	if len(n.spool) >= n.options.SpoolSize {
+		n.spool = n.spool[1:]
+		n.dropped++
+	}
+
+	entry := make([]byte, len(b))
+	copy(entry, b)
+	n.spool = append(n.spool, spooledEntry{data: entry, timestamp: timestamp})
+
+	return true
+}
+
+// expireLocked removes spooled entries older than the Expiry policy allows, replacing them with a summary entry if
+// the policy is to summarize them
+func (n *NetworkLogSink) expireLocked(now time.Time) {
+	if n.options.Expiry.MaxAge <= 0 {
+		return
+	}
+
+	var expired expiredEntries
+	spool := n.spool[:0]
+	for _, spooled := range n.spool {
+		if n.options.Expiry.expired(spooled.timestamp, now) {
+			expired.add(spooled.timestamp)
+			continue
+		}
+		spool = append(spool, spooled)
+	}
+	n.spool = spool
+	n.dropped += expired.count
+
+	if summary, ok := n.options.Expiry.summary(expired, now); ok {
+		buffer := bytes.Buffer{}
+		if err := n.options.Encoder.Encode(&buffer, summary); err == nil {
+			n.spool = append([]spooledEntry{{data: buffer.Bytes(), timestamp: now}}, n.spool...)
+		}
+	}
+}
+
+var _ LogSink = (*NetworkLogSink)(nil)
+var _ CloserSink = (*NetworkLogSink)(nil)
+var _ DropCounter = (*NetworkLogSink)(nil)
+
+// NetworkLogSinkOptions configures the behaviour of a NetworkLogSink
+type NetworkLogSinkOptions struct {
+	// Network is "tcp" or "udp" (or any other network supported by net.Dial)
+	Network string
+	// Address is the host:port of the endpoint
+	Address string
+	// Encoder encodes each Entry, e.g. a JSONLogSink or LogfmtLogSink
+	Encoder EntryEncoder
+	// DialTimeout bounds how long connecting may take
+	DialTimeout time.Duration
+	// WriteTimeout bounds how long writing an entry may take
+	WriteTimeout time.Duration
+	// MinBackoff is how long to wait before the first reconnection attempt, doubling for each failed attempt
+	MinBackoff time.Duration
+	// MaxBackoff caps how long to wait between reconnection attempts
+	MaxBackoff time.Duration
+	// SpoolSize is the number of entries buffered while disconnected, zero disables spooling so that entries logged
+	// while disconnected fail
+	SpoolSize int
+	// Expiry determines whether spooled entries that are too old by the time the connection is restored are dropped
+	// or summarized
+	Expiry ExpiryPolicy
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (n *NetworkLogSinkOptions) AssertDefaults() {
+	if n.Network == "" {
+		n.Network = "tcp"
+	}
+
+	if n.Encoder == nil {
+		encoderOpts := JSONLogSinkOptions{}
+		encoderOpts.AssertDefaults()
+		n.Encoder = NewJSONLogSink(encoderOpts)
+	}
+
+	if n.DialTimeout <= 0 {
+		n.DialTimeout = DefaultNetworkDialTimeout
+	}
+
+	if n.WriteTimeout <= 0 {
+		n.WriteTimeout = DefaultNetworkWriteTimeout
+	}
+
+	if n.MinBackoff <= 0 {
+		n.MinBackoff = DefaultNetworkMinBackoff
+	}
+
+	if n.MaxBackoff <= 0 {
+		n.MaxBackoff = DefaultNetworkMaxBackoff
+	}
+
+	n.Expiry.AssertDefaults()
+}
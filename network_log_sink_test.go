@@ -0,0 +1,113 @@
+package simplelogr
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetworkLogSinkConnectsWithoutBlockingOtherCalls(t *testing.T) {
+	opts := NetworkLogSinkOptions{Address: "logs.example.com:514", SpoolSize: 10}
+	opts.AssertDefaults()
+	sink := NewNetworkLogSink(opts)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	dialing := make(chan struct{})
+	connect := make(chan struct{})
+	sink.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		close(dialing)
+		<-connect
+		return client, nil
+	}
+
+	lines := make(chan string, 3)
+	go func() {
+		scanner := bufio.NewScanner(server)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	first := make(chan error, 1)
+	go func() {
+		first <- sink.Log(Entry{Message: "first"})
+	}()
+	<-dialing
+
+	// entries logged while connecting are spooled rather than waiting for the connection
+	logged := make(chan error, 1)
+	go func() {
+		logged <- sink.Log(Entry{Message: "second"})
+	}()
+	select {
+	case err := <-logged:
+		if err != nil {
+			t.Fatalf("expected the entry to be spooled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected logging not to wait for the connection")
+	}
+
+	close(connect)
+	if err := <-first; err != nil {
+		t.Fatalf("failed to log once connected: %v", err)
+	}
+	if err := sink.Log(Entry{Message: "third"}); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+
+	// the concurrently logged entries may be written in either order
+	written := ""
+	for i := 0; i < 3; i++ {
+		select {
+		case line := <-lines:
+			written += line
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 3 entries to be written, got %s", written)
+		}
+	}
+	for _, expected := range []string{"first", "second", "third"} {
+		if !strings.Contains(written, `"msg":"`+expected+`"`) {
+			t.Errorf("expected %q to be written, got %s", expected, written)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Errorf("failed to close: %v", err)
+	}
+}
+
+func TestNetworkLogSinkCloseAbandonsConnecting(t *testing.T) {
+	opts := NetworkLogSinkOptions{Address: "logs.example.com:514"}
+	opts.AssertDefaults()
+	sink := NewNetworkLogSink(opts)
+
+	dialing := make(chan struct{})
+	sink.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		close(dialing)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	logged := make(chan error, 1)
+	go func() {
+		logged <- sink.Log(Entry{Message: "first"})
+	}()
+	<-dialing
+
+	_ = sink.Close()
+	select {
+	case err := <-logged:
+		if !errors.Is(err, os.ErrClosed) {
+			t.Errorf("expected logging to fail as the sink was closed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close to abandon connecting")
+	}
+}
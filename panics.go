@@ -0,0 +1,105 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// placeholderValue replaces a value that could not be resolved or encoded, e.g. because it panicked or is of a type
+// that cannot be encoded (such as a channel), so that the rest of the entry can still be logged. Encoders write the
+// placeholder in place of the value and return the placeholderValue as an error, which sinks report using
+// Entry.reportError rather than failing to write the entry.
+type placeholderValue struct {
+	placeholder string
+	err         error
+}
+
+// panicPlaceholder creates a placeholder for a value that panicked while being resolved or encoded
+func panicPlaceholder(v interface{}, recovered interface{}) *placeholderValue {
+	return &placeholderValue{
+		placeholder: fmt.Sprintf("<panic: %v>", recovered),
+		err:         fmt.Errorf("recovered from panic while encoding value of type %T: %v", v, recovered),
+	}
+}
+
+// errorPlaceholder creates a placeholder for a value that could not be encoded, e.g. "!ERROR(json: unsupported type:
+// chan int)"
+func errorPlaceholder(v interface{}, err error) *placeholderValue {
+	return &placeholderValue{
+		placeholder: fmt.Sprintf("!ERROR(%v)", err),
+		err:         fmt.Errorf("failed to encode value of type %T: %w", v, err),
+	}
+}
+
+// Error implements error, describing why the value was replaced
+func (p *placeholderValue) Error() string {
+	return p.err.Error()
+}
+
+// Unwrap returns the error describing why the value was replaced
+func (p *placeholderValue) Unwrap() error {
+	return p.err
+}
+
+// String implements fmt.Stringer, returning the placeholder
+func (p *placeholderValue) String() string {
+	return p.placeholder
+}
+
+// MarshalJSON implements json.Marshaler, encoding the placeholder as a string, for sinks that encode values using
+// encoding/json directly
+func (p *placeholderValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.placeholder)
+}
+
+// placeholderErrors are the errors of any values replaced by placeholders while encoding an entry, which did not
+// prevent the entry being written
+type placeholderErrors []error
+
+// Error implements error
+func (p placeholderErrors) Error() string {
+	return multiError(p).Error()
+}
+
+// collect records the error if it is a placeholderValue (or placeholderErrors), returning false otherwise, in which
+// case the entry could not be encoded
+func (p *placeholderErrors) collect(err error) bool {
+	switch e := err.(type) {
+	case *placeholderValue:
+		*p = append(*p, e)
+		return true
+	case placeholderErrors:
+		*p = append(*p, e...)
+		return true
+	default:
+		return false
+	}
+}
+
+// err returns the collected errors as a single error, or nil if there are none
+func (p placeholderErrors) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// reportPlaceholders reports the error using reportError if it only describes values replaced by placeholders,
+// returning nil so that the entry is still written, and returns any other error unchanged
+func (e Entry) reportPlaceholders(err error) error {
+	if isPlaceholderError(err) {
+		e.reportError(err)
+		return nil
+	}
+	return err
+}
+
+// isPlaceholderError reports whether the error only describes values replaced by placeholders
+func isPlaceholderError(err error) bool {
+	switch err.(type) {
+	case *placeholderValue, placeholderErrors:
+		return true
+	default:
+		return false
+	}
+}
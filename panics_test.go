@@ -0,0 +1,65 @@
+package simplelogr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// panickingError is an error whose Error method panics
+type panickingError struct{}
+
+func (panickingError) Error() string {
+	panic("broken error")
+}
+
+// panickingValue is a value whose encoding panics
+type panickingValue struct{}
+
+func (panickingValue) MarshalJSON() ([]byte, error) {
+	panic("broken value")
+}
+
+func TestJSONPlaceholders(t *testing.T) {
+	assertPlaceholders(t, func(buffer *bytes.Buffer) LogSink {
+		opts := JSONLogSinkOptions{Output: buffer}
+		opts.AssertDefaults()
+		return NewJSONLogSink(opts)
+	}, `"msg":"message"`, `"error":"\u003cpanic: broken error\u003e"`,
+		`"value":"\u003cpanic: broken value\u003e"`, `"other":1`)
+}
+
+func TestLogfmtPlaceholders(t *testing.T) {
+	assertPlaceholders(t, func(buffer *bytes.Buffer) LogSink {
+		opts := LogfmtLogSinkOptions{Output: buffer}
+		opts.AssertDefaults()
+		return NewLogfmtLogSink(opts)
+	}, `msg=message`, `error="<panic: broken error>"`, `value="<panic: broken value>"`, `other=1`)
+}
+
+// assertPlaceholders logs an error whose Error method panics and a value whose encoding panics to the sink, asserting
+// that the entry is still written, containing each of the expected strings, and that both panics are reported
+func assertPlaceholders(t *testing.T, newSink func(buffer *bytes.Buffer) LogSink, expected ...string) {
+	buffer := bytes.Buffer{}
+	var reported []error
+	logger := logr.New(New(Options{
+		Sink: newSink(&buffer),
+		ErrorHandler: func(err error) {
+			reported = append(reported, err)
+		},
+	}))
+
+	logger.Error(panickingError{}, "message", "value", panickingValue{}, "other", 1)
+
+	output := buffer.String()
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("expected output to contain %s, got %s", s, output)
+		}
+	}
+	if len(reported) != 2 {
+		t.Errorf("expected the error and the value to be reported, got %v", reported)
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// pkgErrorsStackTrace extracts the stack trace built into errors created using github.com/pkg/errors
+func pkgErrorsStackTrace(err error) (string, bool) {
+	type tracedError interface {
+		StackTrace() errors.StackTrace
+	}
+	if traced, ok := err.(tracedError); ok {
+		return fmt.Sprintf("%+v", traced.StackTrace()), true
+	}
+	return "", false
+}
+
+// pkgErrorsHasStackTrace reports whether the error has a stack trace built in by github.com/pkg/errors
+func pkgErrorsHasStackTrace(err error) bool {
+	type tracedError interface {
+		StackTrace() errors.StackTrace
+	}
+	_, ok := err.(tracedError)
+	return ok
+}
+
+// pkgErrorsFrames extracts the frames of the stack trace built into errors created using github.com/pkg/errors
+func pkgErrorsFrames(err error) ([]StackFrame, bool) {
+	type tracedError interface {
+		StackTrace() errors.StackTrace
+	}
+	traced, ok := err.(tracedError)
+	if !ok {
+		return nil, false
+	}
+
+	stackTrace := traced.StackTrace()
+	frames := make([]StackFrame, 0, len(stackTrace))
+	for _, frame := range stackTrace {
+		// a Frame is the program counter of the return address, so step back into the calling instruction
+		pc := uintptr(frame) - 1
+		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		frames = append(frames, StackFrame{
+			Function: fn.Name(),
+			File:     file,
+			Line:     line,
+		})
+	}
+	return frames, true
+}
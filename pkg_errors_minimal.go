@@ -0,0 +1,19 @@
+//go:build simplelogr_minimal
+// +build simplelogr_minimal
+
+package simplelogr
+
+// pkgErrorsStackTrace never finds a stack trace, as github.com/pkg/errors is omitted from minimal builds
+func pkgErrorsStackTrace(err error) (string, bool) {
+	return "", false
+}
+
+// pkgErrorsHasStackTrace never finds a stack trace, as github.com/pkg/errors is omitted from minimal builds
+func pkgErrorsHasStackTrace(err error) bool {
+	return false
+}
+
+// pkgErrorsFrames never finds a stack trace, as github.com/pkg/errors is omitted from minimal builds
+func pkgErrorsFrames(err error) ([]StackFrame, bool) {
+	return nil, false
+}
@@ -0,0 +1,31 @@
+package simplelogr
+
+// PreEncoder is implemented by LogSink objects that can encode key-value pairs ahead of time. A Logger configured with
+// such a sink encodes the values accumulated using WithValues once, when the derived logger is created, and attaches
+// the result to each Entry as Entry.PreEncoded so that the sink can splice it into its output rather than encoding the
+// same values for every Entry. JSONLogSink and LogfmtLogSink both implement it.
+//
+// As values are encoded when WithValues is called, later changes to them (e.g. through pointers) are not reflected in
+// the output of sinks using the pre-encoded values. Values that are resolved lazily, such as slog.LogValuer
+// implementations, are never pre-encoded.
+type PreEncoder interface {
+	// PreEncode encodes the given key-value pairs, returning nil if they cannot be encoded ahead of time
+	PreEncode(keysAndValues []interface{}) (*PreEncodedValues, error)
+}
+
+// PreEncodedValues is an encoding of the key-value pairs at the start of Entry.KVs, prepared by a PreEncoder
+type PreEncodedValues struct {
+	// Format identifies the encoding, sinks must ignore pre-encoded values in any format they do not produce
+	Format string
+	// Count is the number of elements at the start of Entry.KVs (keys and values) that are encoded
+	Count int
+	// Keys are the keys of the encoded key-value pairs, in order
+	Keys []string
+	// Data is the encoding of the key-value pairs
+	Data []byte
+}
+
+// valid reports whether the pre-encoded values are in the given format and describe the given key-value pairs
+func (p *PreEncodedValues) valid(format string, kvs []interface{}) bool {
+	return p != nil && p.Format == format && p.Count > 0 && p.Count <= len(kvs)
+}
@@ -0,0 +1,26 @@
+package simplelogr
+
+import (
+	"os"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultProductionVerbosity is the verbosity used by NewProduction, showing only non-verbose log messages
+	DefaultProductionVerbosity = 0
+)
+
+// NewProduction creates a ready to use logr.Logger which emits structured JSON logs to stderr using a JSONLogSink,
+// synchronising writes so that it is safe to use concurrently
+func NewProduction() logr.Logger {
+	sinkOpts := JSONLogSinkOptions{
+		Output: SynchronizeWritesTo(os.Stderr),
+	}
+	sinkOpts.AssertDefaults()
+
+	return logr.New(New(Options{
+		Sink:      NewJSONLogSink(sinkOpts),
+		Verbosity: DefaultProductionVerbosity,
+	}))
+}
@@ -0,0 +1,47 @@
+//go:build !simplelogr_minimal
+// +build !simplelogr_minimal
+
+package simplelogr
+
+import (
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mattn/go-colorable"
+)
+
+var (
+	// DefaultDevelopmentVerbosity is the verbosity used by NewDevelopment, high enough to show all log messages
+	DefaultDevelopmentVerbosity = 10
+)
+
+// NewDevelopment creates a ready to use logr.Logger which emits coloured, human-readable logs to stdout using a
+// DevelopmentLogSink, with a high verbosity so that all log messages are shown
+func NewDevelopment() logr.Logger {
+	sinkOpts := DevelopmentLogSinkOptions{
+		Output: colorable.NewColorableStdout(),
+	}
+	sinkOpts.AssertDefaults()
+
+	return logr.New(New(Options{
+		Sink:      NewDevelopmentLogSink(sinkOpts),
+		Verbosity: DefaultDevelopmentVerbosity,
+	}))
+}
+
+// NewCLI creates the outputs for a command line tool: a CLIOutput writing user-facing output to stdout, and a
+// logr.Logger writing human-readable diagnostic logs to stderr with the given verbosity
+func NewCLI(verbosity int) (*CLIOutput, logr.Logger) {
+	outputOpts := CLIOutputOptions{}
+	outputOpts.AssertDefaults()
+
+	sinkOpts := DevelopmentLogSinkOptions{
+		Output: os.Stderr,
+	}
+	sinkOpts.AssertDefaults()
+
+	return NewCLIOutput(outputOpts), logr.New(New(Options{
+		Sink:      NewDevelopmentLogSink(sinkOpts),
+		Verbosity: verbosity,
+	}))
+}
@@ -0,0 +1,90 @@
+package simplelogr
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// KubernetesDelegatingCallDepth is the number of call frames added by controller-runtime's delegating logger
+	// between logr.Logger and the sink it is given by ctrl.SetLogger, which NewKubernetes skips when identifying
+	// callers
+	KubernetesDelegatingCallDepth = 1
+)
+
+// KubernetesOptions configures the logr.Logger created by NewKubernetes
+type KubernetesOptions struct {
+	// Output configures where to write logs to, stderr (with writes synchronised) if unspecified
+	Output io.Writer
+	// Verbosity determines which verbose log messages are enabled, as with klog's -v flag, see BindFlags
+	Verbosity int
+	// Direct indicates the logger will be used directly, rather than through controller-runtime's ctrl.SetLogger or
+	// log.SetLogger, so that no call frames of its delegating logger need to be skipped
+	Direct bool
+	// verbosityFlag is a -v flag registered by klog, read when the logger is created, see BindFlags
+	verbosityFlag *flag.Flag
+}
+
+// BindFlags registers a klog-compatible -v flag setting the Verbosity. If the flag set already has a -v flag, e.g.
+// because klog.InitFlags registered it, that flag is used instead.
+func (k *KubernetesOptions) BindFlags(fs *flag.FlagSet) {
+	if f := fs.Lookup("v"); f != nil {
+		k.verbosityFlag = f
+		return
+	}
+	fs.IntVar(&k.Verbosity, "v", k.Verbosity, "number for the log level verbosity")
+}
+
+// NewKubernetes creates a ready to use logr.Logger for Kubernetes operators and controllers, which can be passed to
+// controller-runtime's ctrl.SetLogger as it is. It emits JSON logs to stderr keyed as Kubernetes components do using
+// a JSONLogSink, e.g. {"ts":1580306777.04728,"v":4,"logger":"controller","msg":"Pod status updated","caller":
+// "pkg/reconciler.go:70",...}, with the verbosity level as "v" and any error message as "err".
+func NewKubernetes(opts KubernetesOptions) logr.Logger {
+	if opts.Output == nil {
+		opts.Output = SynchronizeWritesTo(os.Stderr)
+	}
+
+	verbosity := opts.Verbosity
+	if opts.verbosityFlag != nil {
+		if v, err := strconv.Atoi(opts.verbosityFlag.Value.String()); err == nil {
+			verbosity = v
+		}
+	}
+
+	sinkOpts := JSONLogSinkOptions{
+		Output:        opts.Output,
+		TimestampKey:  "ts",
+		TimestampMode: TimestampUnixSeconds,
+		LevelKey:      "v",
+		LevelEncoder:  kubernetesLevelEncoder,
+		NameKey:       "logger",
+		ErrorKey:      "err",
+	}
+	sinkOpts.AssertDefaults()
+	// Kubernetes components identify severity by verbosity level and the presence of an error
+	sinkOpts.SeverityKey = ""
+
+	logger := logr.New(New(Options{
+		Sink:          NewJSONLogSink(sinkOpts),
+		Verbosity:     verbosity,
+		CaptureCaller: true,
+	}))
+
+	if !opts.Direct {
+		logger = logger.WithCallDepth(KubernetesDelegatingCallDepth)
+	}
+	return logger
+}
+
+// kubernetesLevelEncoder reports the verbosity level of entries unchanged, as klog does, reporting warnings at level 0
+// as klog has no negative levels
+func kubernetesLevelEncoder(level int, _ error) int {
+	if level < 0 {
+		return 0
+	}
+	return level
+}
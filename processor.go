@@ -0,0 +1,41 @@
+package simplelogr
+
+// Processor transforms an Entry before it is emitted, returning false to drop the Entry entirely. Processors must not
+// modify the KVs or Names slices of the Entry they are given in place, as they may be shared, and should instead
+// replace them with modified copies.
+type Processor func(e Entry) (Entry, bool)
+
+// ProcessorSink applies a series of Processor functions to each Entry, in order, before passing the result on to
+// another LogSink
+type ProcessorSink struct {
+	sink       LogSink
+	processors []Processor
+}
+
+// NewProcessorSink creates a new ProcessorSink applying the given processors before logging to the given sink
+func NewProcessorSink(sink LogSink, processors ...Processor) *ProcessorSink {
+	return &ProcessorSink{
+		sink:       sink,
+		processors: processors,
+	}
+}
+
+// Log implements LogSink, applying the processors before passing the Entry on to the wrapped sink
+func (p ProcessorSink) Log(e Entry) error {
+	for _, processor := range p.processors {
+		var keep bool
+		if e, keep = processor(e); !keep {
+			return nil
+		}
+	}
+
+	return p.sink.Log(e)
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (p ProcessorSink) Unwrap() []LogSink {
+	return []LogSink{p.sink}
+}
+
+var _ LogSink = (*ProcessorSink)(nil)
+var _ WrapperSink = (*ProcessorSink)(nil)
@@ -0,0 +1,106 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// segmentSlack allows for entries being timestamped shortly before they are written to a new segment
+const segmentSlack = time.Second
+
+// QuerySegments reads back the entries logged within [from, to) from segments written by a RotatingFileOutput using
+// a JSONLogSink, calling fn with each one in the order they were written, and stopping at the first error returned by
+// fn. A zero from or to leaves that end of the range unbounded. Segments that cannot contain entries in the range are
+// not read, so the segments should be listed in order, as returned by ListSegments.
+func QuerySegments(segments []Segment, from, to time.Time, opts JSONDecoderOptions, fn func(e Entry) error) error {
+	for i, segment := range segments {
+		if !from.IsZero() && i+1 < len(segments) && !segments[i+1].Start.After(from) {
+			continue
+		}
+		if !to.IsZero() && !segment.Start.Before(to.Add(segmentSlack)) {
+			break
+		}
+
+		if err := querySegment(segment, from, to, opts, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func querySegment(segment Segment, from, to time.Time, opts JSONDecoderOptions, fn func(e Entry) error) error {
+	reader, err := segment.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	decoder := NewJSONDecoder(reader, opts)
+	for {
+		e, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read log segment %s: %w", segment.Path, err)
+		}
+
+		if (!from.IsZero() && e.Timestamp.Before(from)) || (!to.IsZero() && !e.Timestamp.Before(to)) {
+			continue
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// SegmentVerification reports the integrity of a segment, see VerifySegment
+type SegmentVerification struct {
+	// Entries is the number of intact entries
+	Entries int
+	// Corrupt is the number of entries that were only partially written or have since been corrupted, along with
+	// any trailers that do not match the entries before them
+	Corrupt int
+	// Framed is true if the segment was written with checksums, see RotatingFileOutputOptions.Checksums
+	Framed bool
+	// Complete is true if the segment was written with checksums and ends with a valid trailer, it is false for
+	// segments still being written to and those that were not closed cleanly (e.g. due to a crash)
+	Complete bool
+}
+
+// VerifySegment checks the integrity of a segment, which is only possible for segments written with checksums, see
+// RotatingFileOutputOptions.Checksums. Use JSONDecoderOptions.SkipCorrupt to read back only the intact entries.
+func VerifySegment(segment Segment) (SegmentVerification, error) {
+	reader, err := segment.Open()
+	if err != nil {
+		return SegmentVerification{}, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	verification := SegmentVerification{}
+	frames := newFrameReader(reader)
+	for {
+		_, err := frames.next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if errors.Is(err, ErrCorruptEntry) || errors.Is(err, ErrCorruptSegment) {
+			verification.Corrupt++
+		} else if err != nil {
+			return verification, fmt.Errorf("failed to read log segment %s: %w", segment.Path, err)
+		} else {
+			verification.Entries++
+		}
+	}
+
+	verification.Framed = frames.framed
+	verification.Complete = frames.trailer
+
+	return verification, nil
+}
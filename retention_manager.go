@@ -0,0 +1,361 @@
+package simplelogr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultRetentionInterval is how often a started RetentionManager enforces its policy
+	DefaultRetentionInterval = time.Hour
+	// DefaultDownsampleSeverities are the severity names kept when downsampling, if none are specified
+	DefaultDownsampleSeverities = []string{DefaultErrorSeverity, DefaultWarnSeverity}
+)
+
+// RetentionManager keeps the disk usage of the segments written by a RotatingFileOutput in check, so that long
+// running processes don't fill their disks. Segments that are no longer being written to are downsampled (keeping
+// only the more severe entries) and compressed as they age, and deleted once they are too old or the segments take up
+// too much space, oldest first. The policy is enforced when Enforce is called, and periodically in the background once
+// Start has been called.
+type RetentionManager struct {
+	options RetentionManagerOptions
+
+	// enforceLock ensures only one enforcement runs at a time
+	enforceLock sync.Mutex
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewRetentionManager creates a new RetentionManager with the provided options
+func NewRetentionManager(opts RetentionManagerOptions) *RetentionManager {
+	return &RetentionManager{
+		options: opts,
+	}
+}
+
+// Enforce applies the retention policy to the output's segments, continuing past segments that cannot be processed
+func (r *RetentionManager) Enforce() error {
+	r.enforceLock.Lock()
+	defer r.enforceLock.Unlock()
+
+	segments, err := r.options.Output.Segments()
+	if err != nil {
+		return err
+	}
+
+	// never touch the segment being written to, or anything newer (which may belong to another output)
+	current := r.options.Output.Path()
+	managed := len(segments) - 1
+	for i, segment := range segments {
+		if segment.Path == current {
+			managed = i
+			break
+		}
+	}
+	if managed <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var errs multiError
+	var kept []Segment
+	for i, segment := range segments[:managed] {
+		// a segment's entries were all logged before the following segment was started
+		age := now.Sub(segments[i+1].Start)
+
+		if r.options.MaxAge > 0 && age > r.options.MaxAge {
+			if err := os.Remove(segment.Path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete log segment: %w", err))
+				kept = append(kept, segment)
+			}
+			continue
+		}
+
+		if r.options.DownsampleAfter > 0 && age > r.options.DownsampleAfter && !segment.Downsampled {
+			if err := r.rewrite(&segment, true, segment.Compressed); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if r.options.CompressAfter > 0 && age > r.options.CompressAfter && !segment.Compressed {
+			if err := r.rewrite(&segment, segment.Downsampled, true); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		kept = append(kept, segment)
+	}
+
+	if r.options.MaxTotalSize > 0 {
+		var total int64
+		for _, segment := range kept {
+			total += segment.Size
+		}
+		for _, segment := range kept {
+			if total <= r.options.MaxTotalSize {
+				break
+			}
+			if err := os.Remove(segment.Path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete log segment: %w", err))
+				continue
+			}
+			total -= segment.Size
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// rewrite replaces a segment with a copy that is downsampled and/or compressed, updating the given Segment to match
+func (r *RetentionManager) rewrite(segment *Segment, downsample, compress bool) error {
+	reader, err := segment.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var codec CompressionCodec
+	if compress {
+		if codec, err = LookupCompressionCodec(r.options.Compression); err != nil {
+			return err
+		}
+	}
+
+	path := segment.Path
+	if segment.Compressed {
+		if current, ok := compressionCodecForFile(path); ok {
+			path = strings.TrimSuffix(path, current.Extension)
+		}
+	}
+	extension := r.options.Output.options.Extension
+	path = strings.TrimSuffix(path, extension)
+	if downsample && !strings.HasSuffix(path, downsampledSegmentMarker) {
+		path += downsampledSegmentMarker
+	}
+	path += extension
+	if compress {
+		path += codec.Extension
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, r.options.Output.options.File.permissions())
+	if err != nil {
+		return fmt.Errorf("failed to create log segment: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	var w io.Writer = file
+	var compressor io.WriteCloser
+	if compress {
+		if compressor, err = codec.NewWriter(file); err != nil {
+			return fmt.Errorf("failed to compress log segment: %w", err)
+		}
+		w = compressor
+	}
+
+	if downsample && !segment.Downsampled {
+		err = r.downsample(w, reader)
+	} else {
+		_, err = io.Copy(w, reader)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite log segment: %w", err)
+	}
+
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return fmt.Errorf("failed to compress log segment: %w", err)
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write log segment: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to write log segment: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace log segment: %w", err)
+	}
+	if path != segment.Path {
+		if err := os.Remove(segment.Path); err != nil {
+			return fmt.Errorf("failed to delete log segment: %w", err)
+		}
+	}
+
+	segment.Path = path
+	segment.Size = info.Size()
+	segment.Downsampled = downsample
+	segment.Compressed = compress
+	segment.Codec = ""
+	if compress {
+		segment.Codec = codec.Name
+	}
+
+	return nil
+}
+
+// downsample copies only the entries with the severities being kept, along with any that cannot be decoded (as their
+// severity is unknown), framing them with a new trailer if the segment was written with checksums. Corrupt entries are
+// discarded.
+func (r *RetentionManager) downsample(w io.Writer, reader io.Reader) error {
+	decoder := JSONDecoder{options: r.options.Decoder}
+	frames := newFrameReader(reader)
+	var framer *frameWriter
+	for {
+		payload, err := frames.next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if errors.Is(err, ErrCorruptEntry) || errors.Is(err, ErrCorruptSegment) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if e, err := decoder.decode(payload); err == nil && !r.keeps(e.Severity) {
+			continue
+		}
+
+		if frames.framed && framer == nil {
+			framer = newFrameWriter(w)
+		}
+		line := append(payload, '\n')
+		if framer != nil {
+			_, err = framer.Write(line)
+		} else {
+			_, err = w.Write(line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if frames.framed {
+		if framer == nil {
+			framer = newFrameWriter(w)
+		}
+		return framer.WriteTrailer()
+	}
+
+	return nil
+}
+
+func (r *RetentionManager) keeps(severity string) bool {
+	for _, kept := range r.options.DownsampleSeverities {
+		if kept == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Start begins enforcing the retention policy every Interval in the background, until the context is cancelled or
+// Close is called
+func (r *RetentionManager) Start(ctx context.Context) {
+	r.lifecycleLock.Lock()
+	defer r.lifecycleLock.Unlock()
+
+	if r.done != nil {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Enforce(); err != nil {
+					r.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(r.done)
+}
+
+// Close stops any background enforcement started by Start
+func (r *RetentionManager) Close() error {
+	r.lifecycleLock.Lock()
+	defer r.lifecycleLock.Unlock()
+
+	if r.done != nil {
+		r.cancel()
+		<-r.done
+		r.done = nil
+	}
+
+	return nil
+}
+
+// RetentionManagerOptions configures the behaviour of a RetentionManager, any of the age and size limits may be left
+// unspecified to disable them
+type RetentionManagerOptions struct {
+	// Output is the RotatingFileOutput whose segments are managed
+	Output *RotatingFileOutput
+	// MaxAge is how long after a segment was last written to that it is deleted
+	MaxAge time.Duration
+	// MaxTotalSize is the total size in bytes that segments no longer being written to may take up, after which the
+	// oldest are deleted
+	MaxTotalSize int64
+	// DownsampleAfter is how long after a segment was last written to that it is downsampled
+	DownsampleAfter time.Duration
+	// DownsampleSeverities are the severity names of the entries kept when downsampling
+	DownsampleSeverities []string
+	// CompressAfter is how long after a segment was last written to that it is compressed
+	CompressAfter time.Duration
+	// Compression is the name of the CompressionCodec segments are compressed with, see RegisterCompressionCodec
+	Compression string
+	// Decoder configures how entries are decoded when downsampling, and should match the sink writing the segments
+	Decoder JSONDecoderOptions
+	// Interval is how often the policy is enforced once the manager has been started
+	Interval time.Duration
+	// ErrorHandler is called with any errors encountered while enforcing the policy in the background
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (r *RetentionManagerOptions) AssertDefaults() {
+	if len(r.DownsampleSeverities) == 0 {
+		r.DownsampleSeverities = DefaultDownsampleSeverities
+	}
+
+	if r.Compression == "" {
+		r.Compression = DefaultCompressionCodec
+	}
+
+	r.Decoder.AssertDefaults()
+
+	if r.Interval <= 0 {
+		r.Interval = DefaultRetentionInterval
+	}
+
+	if r.ErrorHandler == nil {
+		r.ErrorHandler = DefaultErrorHandler
+	}
+}
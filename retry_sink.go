@@ -0,0 +1,107 @@
+package simplelogr
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+var (
+	// DefaultRetryAttempts is the number of times a RetrySink tries to log each entry when none is specified
+	DefaultRetryAttempts = 3
+	// DefaultRetryBackoff is how long a RetrySink waits before its first retry when none is specified
+	DefaultRetryBackoff = 10 * time.Millisecond
+	// DefaultRetryMaxBackoff caps how long a RetrySink waits between retries when none is specified
+	DefaultRetryMaxBackoff = time.Second
+)
+
+// RetrySink retries entries that another LogSink fails to log, waiting between attempts with exponential backoff, so
+// that transient failures such as a briefly full pipe or an interrupted connection do not lose entries. Once it gives
+// up, it returns a SinkError recording the number of attempts.
+//
+// Retries block the caller, so for destinations that may be unavailable for long, consider combining it with an
+// AsyncSink, or using a FailoverSink or EscalatingSink. It should wrap individual sinks rather than a MultiSink, which
+// would log the entry again to the sinks that succeeded, and sinks whose failed writes may have been partially written
+// can duplicate part of an entry when retried.
+type RetrySink struct {
+	options RetrySinkOptions
+}
+
+// NewRetrySink creates a new RetrySink with the provided options
+func NewRetrySink(opts RetrySinkOptions) *RetrySink {
+	return &RetrySink{
+		options: opts,
+	}
+}
+
+// Log implements LogSink, logging the Entry to the wrapped sink, retrying it if it fails with a retryable error
+func (r *RetrySink) Log(e Entry) error {
+	backoff := r.options.Backoff
+	for attempt := 1; ; attempt++ {
+		err := r.options.Sink.Log(e)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= r.options.Attempts || !r.options.Retryable(err) {
+			return &SinkError{
+				Sink:     r.options.Sink,
+				Entry:    e,
+				Attempts: attempt,
+				Err:      err,
+			}
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > r.options.MaxBackoff {
+			backoff = r.options.MaxBackoff
+		}
+	}
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (r *RetrySink) Unwrap() []LogSink {
+	return []LogSink{r.options.Sink}
+}
+
+var _ LogSink = (*RetrySink)(nil)
+var _ WrapperSink = (*RetrySink)(nil)
+
+// DefaultRetryable considers all errors retryable, except those caused by the destination having been closed
+func DefaultRetryable(err error) bool {
+	return !errors.Is(err, os.ErrClosed)
+}
+
+// RetrySinkOptions configures the behaviour of a RetrySink
+type RetrySinkOptions struct {
+	// Sink is the LogSink that entries are passed on to
+	Sink LogSink
+	// Attempts is the number of times each entry is tried, including the first
+	Attempts int
+	// Backoff is how long to wait before the first retry, doubling for each further retry
+	Backoff time.Duration
+	// MaxBackoff caps how long to wait between retries
+	MaxBackoff time.Duration
+	// Retryable determines whether an error is worth retrying, or permanent
+	Retryable func(err error) bool
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (r *RetrySinkOptions) AssertDefaults() {
+	if r.Attempts <= 0 {
+		r.Attempts = DefaultRetryAttempts
+	}
+
+	if r.Backoff <= 0 {
+		r.Backoff = DefaultRetryBackoff
+	}
+
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = DefaultRetryMaxBackoff
+	}
+
+	if r.Retryable == nil {
+		r.Retryable = DefaultRetryable
+	}
+}
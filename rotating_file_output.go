@@ -0,0 +1,302 @@
+package simplelogr
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultSegmentExtension is the file extension given to segments by RotatingFileOutput when none is specified
+	DefaultSegmentExtension = ".log"
+	// DefaultDirectoryPermissions is the permissions given to directories created by RotatingFileOutput
+	DefaultDirectoryPermissions os.FileMode = 0755
+)
+
+const (
+	// downsampledSegmentMarker is inserted before the extension of segments downsampled by a RetentionManager
+	downsampledSegmentMarker = ".downsampled"
+)
+
+// segmentTimeFormat formats the start time of a segment in its file name, such that the file names of segments sort
+// in the order they were created
+const segmentTimeFormat = "20060102T150405.000000000Z"
+
+// RotatingFileOutputOptions configures the behaviour of a RotatingFileOutput
+type RotatingFileOutputOptions struct {
+	// Dir is the directory segment files are created in
+	Dir string
+	// Name prefixes the file name of every segment, which are named "<Name>-<start time><Extension>"
+	Name string
+	// Extension is the file extension of every segment
+	Extension string
+	// MaxSize, if specified, is the size in bytes after which a new segment is started
+	MaxSize int64
+	// RotationInterval, if specified, starts a new segment whenever a write crosses a multiple of this interval since
+	// the zero time, e.g. 24 hours for daily segments starting at midnight UTC
+	RotationInterval time.Duration
+	// Checksums frames each entry with its length and checksum, and ends each segment with a trailer, so that
+	// partially written entries and truncated segments can be detected when reading them back, see VerifySegment.
+	// Entries must not contain newlines other than a trailing one, as written by JSONLogSink and LogfmtLogSink.
+	Checksums bool
+	// File configures how each segment is written
+	File FileOutputOptions
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (r *RotatingFileOutputOptions) AssertDefaults() {
+	if r.Extension == "" {
+		r.Extension = DefaultSegmentExtension
+	}
+
+	r.File.AssertDefaults()
+}
+
+// Segment describes one of the files written by a RotatingFileOutput
+type Segment struct {
+	// Path is the path of the segment file
+	Path string
+	// Start is the time the segment was created, entries in the segment were logged no earlier than shortly before
+	// this time and before the Start of the following segment
+	Start time.Time
+	// Size is the size of the segment file in bytes
+	Size int64
+	// Downsampled is true if less severe entries have been removed from the segment by a RetentionManager
+	Downsampled bool
+	// Compressed is true if the segment has been compressed by a RetentionManager
+	Compressed bool
+	// Codec is the name of the CompressionCodec the segment was compressed with, identified by its file extension
+	Codec string
+}
+
+// Open opens the segment for reading, decompressing it if necessary
+func (s Segment) Open() (io.ReadCloser, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log segment: %w", err)
+	}
+
+	if !s.Compressed {
+		return file, nil
+	}
+
+	codecName := s.Codec
+	if codecName == "" {
+		codecName = DefaultCompressionCodec
+	}
+	codec, err := LookupCompressionCodec(codecName)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to decompress log segment: %w", err)
+	}
+
+	reader, err := codec.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to decompress log segment: %w", err)
+	}
+
+	return &segmentReader{ReadCloser: reader, file: file}, nil
+}
+
+// segmentReader closes both a decompressing reader and the file it reads from
+type segmentReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (s *segmentReader) Close() error {
+	_ = s.ReadCloser.Close()
+	return s.file.Close()
+}
+
+// RotatingFileOutput is a thread-safe io.Writer appending to a series of segment files in a directory, starting a new
+// segment when the current one grows too large or a rotation interval elapses. Each call to Write is kept within a
+// single segment, so sinks that write whole entries at a time never have entries split across segments.
+type RotatingFileOutput struct {
+	options RotatingFileOutputOptions
+
+	lock    sync.Mutex
+	current *FileOutput
+	framer  *frameWriter
+	start   time.Time
+	size    int64
+}
+
+// OpenRotatingFileOutput creates the directory if necessary, and starts a new segment
+func OpenRotatingFileOutput(opts RotatingFileOutputOptions) (*RotatingFileOutput, error) {
+	if err := os.MkdirAll(opts.Dir, DefaultDirectoryPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	r := &RotatingFileOutput{
+		options: opts,
+	}
+	if err := r.rotateLocked(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Path returns the path of the segment currently being written to
+func (r *RotatingFileOutput) Path() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.current.Path()
+}
+
+// Segments lists all the segments in the output's directory, oldest first
+func (r *RotatingFileOutput) Segments() ([]Segment, error) {
+	return ListSegments(r.options.Dir, r.options.Name, r.options.Extension)
+}
+
+// Write implements io.Writer, starting a new segment first if required
+func (r *RotatingFileOutput) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now().UTC()
+	if r.needsRotationLocked(now, len(p)) {
+		if err := r.rotateLocked(now); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.framer == nil {
+		n, err := r.current.Write(p)
+		r.size += int64(n)
+		return n, err
+	}
+
+	n, err := r.framer.Write(p)
+	r.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Rotate starts a new segment
+func (r *RotatingFileOutput) Rotate() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rotateLocked(time.Now().UTC())
+}
+
+// Sync flushes all data written to the current segment to stable storage
+func (r *RotatingFileOutput) Sync() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.current.Sync()
+}
+
+// Close flushes all written data to stable storage and closes the current segment
+func (r *RotatingFileOutput) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.closeLocked()
+}
+
+func (r *RotatingFileOutput) closeLocked() error {
+	if r.framer != nil {
+		if err := r.framer.WriteTrailer(); err != nil {
+			_ = r.current.Close()
+			return fmt.Errorf("failed to write log segment trailer: %w", err)
+		}
+	}
+
+	return r.current.Close()
+}
+
+func (r *RotatingFileOutput) needsRotationLocked(now time.Time, size int) bool {
+	if r.options.MaxSize > 0 && r.size > 0 && r.size+int64(size) > r.options.MaxSize {
+		return true
+	}
+
+	interval := r.options.RotationInterval
+	return interval > 0 && now.Truncate(interval).After(r.start.Truncate(interval))
+}
+
+func (r *RotatingFileOutput) rotateLocked(now time.Time) error {
+	name := fmt.Sprintf("%s-%s%s", r.options.Name, now.Format(segmentTimeFormat), r.options.Extension)
+	next, err := OpenFileOutput(filepath.Join(r.options.Dir, name), r.options.File)
+	if err != nil {
+		return err
+	}
+
+	if r.current != nil {
+		if err := r.closeLocked(); err != nil {
+			_ = next.Close()
+			return fmt.Errorf("failed to close log segment: %w", err)
+		}
+	}
+
+	r.current = next
+	if r.options.Checksums {
+		r.framer = newFrameWriter(next)
+	}
+	r.start = now
+	r.size = 0
+
+	return nil
+}
+
+var _ io.WriteCloser = (*RotatingFileOutput)(nil)
+
+// ListSegments lists the segments written by a RotatingFileOutput with the given name and extension to a directory,
+// oldest first, including any segments that have since been downsampled or compressed by a RetentionManager
+func ListSegments(dir, name, extension string) ([]Segment, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log segments: %w", err)
+	}
+
+	var segments []Segment
+	for _, file := range files {
+		fileName := file.Name()
+		if file.IsDir() || !strings.HasPrefix(fileName, name+"-") {
+			continue
+		}
+
+		segment := Segment{
+			Path: filepath.Join(dir, fileName),
+			Size: file.Size(),
+		}
+
+		fileName = strings.TrimPrefix(fileName, name+"-")
+		if codec, ok := compressionCodecForFile(fileName); ok {
+			fileName = strings.TrimSuffix(fileName, codec.Extension)
+			segment.Compressed = true
+			segment.Codec = codec.Name
+		}
+		if !strings.HasSuffix(fileName, extension) {
+			continue
+		}
+		fileName = strings.TrimSuffix(fileName, extension)
+		if strings.HasSuffix(fileName, downsampledSegmentMarker) {
+			fileName = strings.TrimSuffix(fileName, downsampledSegmentMarker)
+			segment.Downsampled = true
+		}
+
+		start, err := time.Parse(segmentTimeFormat, fileName)
+		if err != nil {
+			continue
+		}
+		segment.Start = start
+
+		segments = append(segments, segment)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Start.Before(segments[j].Start)
+	})
+
+	return segments, nil
+}
@@ -0,0 +1,204 @@
+package simplelogr
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultSamplingTick is the window within which a SamplingSink counts entries with the same key
+	DefaultSamplingTick = time.Second
+	// DefaultSamplingInitial is the number of entries with the same key a SamplingSink emits each tick before sampling
+	DefaultSamplingInitial = 100
+	// DefaultSamplingThereafter is how often a SamplingSink emits entries with the same key once sampling, e.g. 100
+	// emits every 100th entry
+	DefaultSamplingThereafter = 100
+)
+
+// SamplingReason explains a decision made by a SamplingSink
+type SamplingReason string
+
+const (
+	// SamplingReasonExempt means the entry was emitted because its severity is never sampled
+	SamplingReasonExempt SamplingReason = "exempt"
+	// SamplingReasonInitial means the entry was emitted because it was among the first with its key this tick
+	SamplingReasonInitial SamplingReason = "initial"
+	// SamplingReasonThereafter means the entry was emitted as one of every Thereafter entries with its key
+	SamplingReasonThereafter SamplingReason = "thereafter"
+	// SamplingReasonSampledOut means the entry was suppressed, as too many entries with its key were logged this tick
+	SamplingReasonSampledOut SamplingReason = "sampled_out"
+)
+
+// SamplingDecision describes whether a SamplingSink emitted or suppressed an Entry, and why
+type SamplingDecision struct {
+	// Emitted is true if the entry was passed on to the wrapped sink
+	Emitted bool
+	// Reason explains the decision
+	Reason SamplingReason
+	// Key is the key the entry was counted against, empty for exempt entries
+	Key string
+	// Count is the number of entries with the same key seen this tick, including this one
+	Count int
+}
+
+// SamplingObserver is notified of every decision made by a SamplingSink, e.g. simplelogrtest.Sink records them so that
+// tests can assert that important entries are never sampled away
+type SamplingObserver interface {
+	ObserveSampling(e Entry, decision SamplingDecision)
+}
+
+// SamplingSink limits the volume of repetitive entries passed on to another LogSink: within each tick, the first
+// Initial entries with the same key (by default, the same severity and message) are emitted, after which only every
+// Thereafter-th entry is. Entries are counted against the tick containing their Entry.Timestamp, so decisions are
+// deterministic for entries with fixed timestamps.
+type SamplingSink struct {
+	options SamplingSinkOptions
+
+	lock    sync.Mutex
+	tick    time.Time
+	counts  map[string]int
+	dropped uint64
+}
+
+// NewSamplingSink creates a new SamplingSink with the provided options
+func NewSamplingSink(opts SamplingSinkOptions) *SamplingSink {
+	return &SamplingSink{
+		options: opts,
+		counts:  map[string]int{},
+	}
+}
+
+// Log implements LogSink, emitting the Entry to the wrapped sink unless it is sampled out
+func (s *SamplingSink) Log(e Entry) error {
+	decision := s.decide(e)
+	if s.options.Observer != nil {
+		s.options.Observer.ObserveSampling(e, decision)
+	}
+
+	if !decision.Emitted {
+		return nil
+	}
+	return s.options.Sink.Log(e)
+}
+
+func (s *SamplingSink) decide(e Entry) SamplingDecision {
+	severity := e.severity(s.options.SeverityEncoder)
+	for _, exempt := range s.options.ExemptSeverities {
+		if severity == exempt {
+			return SamplingDecision{Emitted: true, Reason: SamplingReasonExempt}
+		}
+	}
+
+	timestamp := e.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	tick := timestamp.Truncate(s.options.Tick)
+	key := s.options.Key(e)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if tick.After(s.tick) {
+		s.tick = tick
+		s.counts = map[string]int{}
+	}
+	s.counts[key]++
+	count := s.counts[key]
+
+	decision := SamplingDecision{Key: key, Count: count}
+	switch {
+	case count <= s.options.Initial:
+		decision.Emitted = true
+		decision.Reason = SamplingReasonInitial
+	case s.options.Thereafter > 0 && (count-s.options.Initial)%s.options.Thereafter == 0:
+		decision.Emitted = true
+		decision.Reason = SamplingReasonThereafter
+	default:
+		decision.Reason = SamplingReasonSampledOut
+		s.dropped++
+	}
+
+	return decision
+}
+
+// Dropped implements DropCounter, reporting how many entries were sampled out
+func (s *SamplingSink) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (s *SamplingSink) Unwrap() []LogSink {
+	return []LogSink{s.options.Sink}
+}
+
+var _ LogSink = (*SamplingSink)(nil)
+var _ DropCounter = (*SamplingSink)(nil)
+var _ WrapperSink = (*SamplingSink)(nil)
+
+// DefaultSamplingKey identifies entries as repetitive if they have the same severity level and message
+func DefaultSamplingKey(e Entry) string {
+	if e.Error != nil {
+		return "error:" + e.Message
+	}
+	return strconv.Itoa(e.Level) + ":" + e.Message
+}
+
+// SamplingSinkOptions configures the behaviour of a SamplingSink
+type SamplingSinkOptions struct {
+	// Sink is the LogSink that entries which are not sampled out are passed on to
+	Sink LogSink
+	// Tick is the window within which entries with the same key are counted
+	Tick time.Duration
+	// Initial is the number of entries with the same key emitted each tick before sampling begins
+	Initial int
+	// Thereafter determines how often entries with the same key are emitted once sampling, e.g. 100 emits every 100th
+	// entry. A negative value suppresses every entry beyond Initial, rate limiting rather than sampling
+	Thereafter int
+	// Key identifies which entries count as repetitions of each other
+	Key func(e Entry) string
+	// SeverityEncoder identifies the severity name of each entry, for comparison with ExemptSeverities, unless the
+	// Entry's Severity is already set
+	SeverityEncoder func(level int, err error) string
+	// ExemptSeverities are the severity names of entries that are never sampled out, e.g. errors that drive alerts
+	ExemptSeverities []string
+	// Observer, if specified, is notified of every decision. If not specified and the Sink is a SamplingObserver
+	// (such as a simplelogrtest.Sink), the Sink is notified
+	Observer SamplingObserver
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (s *SamplingSinkOptions) AssertDefaults() {
+	if s.Tick <= 0 {
+		s.Tick = DefaultSamplingTick
+	}
+
+	if s.Initial <= 0 {
+		s.Initial = DefaultSamplingInitial
+	}
+
+	if s.Thereafter == 0 {
+		s.Thereafter = DefaultSamplingThereafter
+	}
+
+	if s.Key == nil {
+		s.Key = DefaultSamplingKey
+	}
+
+	if s.SeverityEncoder == nil {
+		s.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if s.ExemptSeverities == nil {
+		s.ExemptSeverities = []string{DefaultErrorSeverity}
+	}
+
+	if s.Observer == nil {
+		if observer, ok := s.Sink.(SamplingObserver); ok {
+			s.Observer = observer
+		}
+	}
+}
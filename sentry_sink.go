@@ -0,0 +1,458 @@
+package simplelogr
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultSentryMaxQueue is the number of events a SentrySink holds before dropping new ones
+	DefaultSentryMaxQueue = 100
+	// DefaultSentryFlushInterval is how often a started SentrySink sends queued events
+	DefaultSentryFlushInterval = time.Second
+)
+
+// SentrySink reports log Entry objects containing an error (and optionally entries of other severities) to Sentry,
+// with key-value pairs attached as tags and extra data, the logger name chain, and any stack trace extracted from the
+// error (e.g. by github.com/pkg/errors) converted into a proper Sentry stack trace. Other entries are ignored, so it is
+// typically combined with other sinks using MultiSink. Events are queued and sent when Flush or Close are called, and
+// periodically in the background once Start has been called.
+type SentrySink struct {
+	options  SentrySinkOptions
+	storeURL string
+	auth     string
+
+	lock    sync.Mutex
+	queue   [][]byte
+	dropped uint64
+
+	sendLock sync.Mutex
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewSentrySink creates a new SentrySink with the provided options, returning an error if the DSN is invalid
+func NewSentrySink(opts SentrySinkOptions) (*SentrySink, error) {
+	dsn, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sentry DSN: %w", err)
+	}
+	if dsn.User == nil || dsn.User.Username() == "" {
+		return nil, fmt.Errorf("sentry DSN is missing the public key")
+	}
+
+	path := strings.TrimSuffix(dsn.Path, "/")
+	i := strings.LastIndex(path, "/")
+	projectID := path[i+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("sentry DSN is missing the project ID")
+	}
+
+	return &SentrySink{
+		options:  opts,
+		storeURL: fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, path[:i], projectID),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=simplelogr/1.0, sentry_key=%s", dsn.User.Username()),
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Platform    string                 `json:"platform"`
+	Message     *sentryMessage         `json:"message,omitempty"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryMessage struct {
+	Formatted string `json:"formatted"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+// Log implements LogSink, queueing an event for the Entry if it should be reported
+func (s *SentrySink) Log(e Entry) error {
+	severity := e.severity(s.options.SeverityEncoder)
+	if e.Error == nil && !s.reportsSeverity(severity) {
+		return nil
+	}
+
+	if s.options.SampleRate < 1 && !sample(s.options.SampleRate) {
+		return nil
+	}
+
+	event := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   e.Timestamp.UTC().Format(time.RFC3339Nano),
+		Level:       sentryLevel(severity, e.Error),
+		Platform:    "go",
+		Environment: s.options.Environment,
+		Release:     s.options.Release,
+		ServerName:  s.options.ServerName,
+	}
+
+	if len(e.Names) > 0 {
+		event.Logger = s.options.NameEncoder(e.Names)
+	}
+
+	if e.Message != "" {
+		event.Message = &sentryMessage{Formatted: e.Message}
+	}
+
+	if e.Error != nil {
+		encodedErr := e.encodeError(s.options.ErrorEncoder)
+		exception := sentryException{
+			Type:  reflect.TypeOf(e.Error).String(),
+			Value: encodedErr.Message,
+		}
+		if frames := parseStackTrace(encodedErr.StackTrace); len(frames) > 0 {
+			exception.Stacktrace = &sentryStacktrace{Frames: frames}
+		}
+		event.Exception = &sentryExceptions{Values: []sentryException{exception}}
+	}
+
+	e.KVs = flattenGroups(e.KVs)
+	for i := 0; i+1 < len(e.KVs); i += 2 {
+		k, ok := e.KVs[i].(string)
+		if !ok {
+			return fmt.Errorf("logging keys must be strings, got %T: %v", e.KVs[i], e.KVs[i])
+		}
+		v := resolveValue(e.KVs[i+1])
+
+		if s.isTag(k) {
+			if event.Tags == nil {
+				event.Tags = map[string]string{}
+			}
+			event.Tags[k] = fmt.Sprint(v)
+			continue
+		}
+
+		if event.Extra == nil {
+			event.Extra = map[string]interface{}{}
+		}
+		event.Extra[k] = v
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode sentry event: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.queue) >= s.options.MaxQueue {
+		s.dropped++
+		return fmt.Errorf("sentry event queue is full")
+	}
+	s.queue = append(s.queue, b)
+
+	return nil
+}
+
+// Flush sends all queued events to Sentry
+func (s *SentrySink) Flush() error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	s.lock.Lock()
+	queue := s.queue
+	s.queue = nil
+	s.lock.Unlock()
+
+	var errs multiError
+	for _, event := range queue {
+		if err := s.send(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to send %d of %d events to sentry: %w", len(errs), len(queue), errs)
+	}
+
+	return nil
+}
+
+// Dropped implements DropCounter, reporting how many events were discarded because the queue was full
+func (s *SentrySink) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+// Start begins sending queued events every FlushInterval in the background, until the context is cancelled or Close
+// is called
+func (s *SentrySink) Start(ctx context.Context) {
+	s.lifecycleLock.Lock()
+	defer s.lifecycleLock.Unlock()
+
+	if s.done != nil {
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(s.options.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Flush(); err != nil {
+					s.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(s.done)
+}
+
+// Close stops any background sending started by Start, and sends any remaining queued events
+func (s *SentrySink) Close() error {
+	s.lifecycleLock.Lock()
+	if s.done != nil {
+		s.cancel()
+		<-s.done
+		s.done = nil
+	}
+	s.lifecycleLock.Unlock()
+
+	return s.Flush()
+}
+
+func (s *SentrySink) send(event []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.storeURL, bytes.NewReader(event))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	req.Header.Set("Content-Type", "application/json")
+
+	sent := time.Now()
+	resp, err := s.options.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if s.options.ClockSkew != nil {
+		s.options.ClockSkew.ObserveHTTP(resp, sent, time.Now())
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *SentrySink) reportsSeverity(severity string) bool {
+	for _, reported := range s.options.ReportSeverities {
+		if reported == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SentrySink) isTag(key string) bool {
+	for _, tag := range s.options.TagKeys {
+		if tag == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sentryLevel maps severity names onto the levels Sentry understands
+func sentryLevel(severity string, err error) string {
+	switch level := strings.ToLower(severity); level {
+	case "debug", "info", "warning", "error", "fatal":
+		return level
+	case "trace":
+		return "debug"
+	case "warn":
+		return "warning"
+	case "critical":
+		return "fatal"
+	}
+	if err != nil {
+		return "error"
+	}
+	return "info"
+}
+
+// parseStackTrace converts a stack trace formatted as by github.com/pkg/errors (alternating lines of function names
+// and tab-indented "file:line" locations, innermost first) into Sentry frames, outermost first
+func parseStackTrace(stackTrace string) []sentryFrame {
+	lines := strings.Split(strings.TrimSpace(stackTrace), "\n")
+
+	var frames []sentryFrame
+	for i := 0; i+1 < len(lines); i++ {
+		function := strings.TrimSpace(lines[i])
+		location := lines[i+1]
+		if !strings.HasPrefix(location, "\t") {
+			continue
+		}
+		i++
+
+		location = strings.TrimSpace(location)
+		colon := strings.LastIndex(location, ":")
+		if colon < 0 {
+			continue
+		}
+		line, err := strconv.Atoi(location[colon+1:])
+		if err != nil {
+			continue
+		}
+
+		frames = append(frames, sentryFrame{
+			Function: function,
+			AbsPath:  location[:colon],
+			Lineno:   line,
+		})
+	}
+
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+
+	return frames
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// sample randomly returns true with the given probability
+func sample(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	if err != nil {
+		return true
+	}
+	return float64(n.Int64())/float64(1<<53) < rate
+}
+
+var _ LogSink = (*SentrySink)(nil)
+var _ DropCounter = (*SentrySink)(nil)
+var _ CloserSink = (*SentrySink)(nil)
+
+// SentrySinkOptions configures the behaviour of a SentrySink
+type SentrySinkOptions struct {
+	// DSN is the Sentry project's client key (DSN), e.g. https://public@o0.ingest.sentry.io/0
+	DSN string
+	// Environment, Release and ServerName are optional metadata attached to every event
+	Environment string
+	Release     string
+	ServerName  string
+	// ReportSeverities are severity names reported even for entries without an error, e.g. []string{"WARN"}
+	ReportSeverities []string
+	// TagKeys are the keys of key-value pairs attached as (searchable) tags, all others are attached as extra data
+	TagKeys []string
+	// SampleRate is the proportion of events to report, between 0 and 1
+	SampleRate float64
+	// MaxQueue is the number of events held before new events are dropped
+	MaxQueue int
+	// FlushInterval is how often queued events are sent once the sink has been started
+	FlushInterval time.Duration
+	// Client is used to make requests to Sentry
+	Client *http.Client
+	// SeverityEncoder identifies the severity name based on the verbosity level and the presence of any errors
+	SeverityEncoder func(level int, err error) string
+	// NameEncoder collapses the series of Logger names down into one string for the event's logger
+	NameEncoder func(names []string) string
+	// ErrorEncoder extracts loggable EncodedError information from an error
+	ErrorEncoder func(err error) EncodedError
+	// ClockSkew, if specified, is used to monitor the clock of the remote end using the Date of its responses
+	ClockSkew *ClockSkewMonitor
+	// ErrorHandler is called with any errors encountered while sending in the background
+	ErrorHandler func(err error)
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (s *SentrySinkOptions) AssertDefaults() {
+	if s.SampleRate == 0 {
+		s.SampleRate = 1
+	}
+
+	if s.MaxQueue <= 0 {
+		s.MaxQueue = DefaultSentryMaxQueue
+	}
+
+	if s.FlushInterval <= 0 {
+		s.FlushInterval = DefaultSentryFlushInterval
+	}
+
+	if s.Client == nil {
+		s.Client = http.DefaultClient
+	}
+
+	if s.SeverityEncoder == nil {
+		s.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if s.NameEncoder == nil {
+		s.NameEncoder = DefaultNameEncoder(DefaultNameSeparator)
+	}
+
+	if s.ErrorEncoder == nil {
+		s.ErrorEncoder = DefaultErrorEncoder
+	}
+
+	if s.ErrorHandler == nil {
+		s.ErrorHandler = DefaultErrorHandler
+	}
+}
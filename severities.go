@@ -0,0 +1,68 @@
+package simplelogr
+
+var (
+	// SyslogSeverityEncoder produces the severity keywords of RFC 5424 syslog, consistently with
+	// DefaultSyslogPriorityEncoder: "err", "warning", "info" and "debug"
+	SyslogSeverityEncoder = DefaultSeverityEncoder("info", "err", []SeverityThreshold{
+		{Level: DefaultDebugVerbosity, Severity: "debug"},
+		{Level: int(LevelWarn), Severity: "warning"},
+	})
+	// OTelSeverityEncoder produces the short names of OpenTelemetry severities, for its SeverityText field,
+	// consistently with DefaultSeverityNumberEncoder: "ERROR", "WARN", "INFO", "DEBUG" and "TRACE". Unlike the default
+	// severity encoder, it is unaffected by levels added using RegisterLevel
+	OTelSeverityEncoder = DefaultSeverityEncoder("INFO", "ERROR", []SeverityThreshold{
+		{Level: DefaultTraceVerbosity, Severity: "TRACE"},
+		{Level: DefaultDebugVerbosity, Severity: "DEBUG"},
+		{Level: int(LevelWarn), Severity: "WARN"},
+	})
+)
+
+// SeverityTaxonomy pairs the severity names and numbers that a logging system uses to classify entries, so that sinks
+// can describe entries, including warnings, in the terms that the tooling consuming them expects
+type SeverityTaxonomy struct {
+	// SeverityEncoder maps the verbosity level and the presence of any errors to a severity name
+	SeverityEncoder func(level int, err error) string
+	// LevelEncoder, if specified, maps the verbosity level and the presence of any errors to a numeric severity
+	LevelEncoder func(level int, err error) int
+}
+
+// ApplyJSON configures JSONLogSinkOptions to describe entries using the taxonomy, before calling AssertDefaults as
+// usual. The numeric severity is only included if LevelKey is also specified
+func (t SeverityTaxonomy) ApplyJSON(opts *JSONLogSinkOptions) {
+	opts.SeverityEncoder = t.SeverityEncoder
+	if t.LevelEncoder != nil {
+		opts.LevelEncoder = t.LevelEncoder
+	}
+}
+
+// ApplyLogfmt configures LogfmtLogSinkOptions to describe entries using the taxonomy, before calling AssertDefaults as
+// usual. The numeric severity is only included if LevelKey is also specified
+func (t SeverityTaxonomy) ApplyLogfmt(opts *LogfmtLogSinkOptions) {
+	opts.SeverityEncoder = t.SeverityEncoder
+	if t.LevelEncoder != nil {
+		opts.LevelEncoder = t.LevelEncoder
+	}
+}
+
+// SeverityTaxonomies are the taxonomies that can be selected by name, e.g. using the Severities of a config.Sink, and
+// may be added to
+var SeverityTaxonomies = map[string]SeverityTaxonomy{
+	// otel follows the OpenTelemetry log data model, e.g. WARN (13)
+	"otel": {
+		SeverityEncoder: OTelSeverityEncoder,
+		LevelEncoder:    DefaultSeverityNumberEncoder,
+	},
+	// syslog follows RFC 5424, e.g. warning (4)
+	"syslog": {
+		SeverityEncoder: SyslogSeverityEncoder,
+		LevelEncoder:    DefaultSyslogPriorityEncoder,
+	},
+	// gcp uses the severity names of Google Cloud Logging, e.g. WARNING
+	"gcp": {
+		SeverityEncoder: GCPSeverityEncoder,
+	},
+	// ecs uses the lowercase severity names conventional in the Elastic Common Schema, e.g. warn
+	"ecs": {
+		SeverityEncoder: ECSSeverityEncoder,
+	},
+}
@@ -0,0 +1,57 @@
+// Package simplelogrcompress registers the zstd and snappy compression codecs when imported, so that they can be
+// selected by name wherever simplelogr compresses, e.g.:
+//
+//	import _ "github.com/omaskery/simple-logr/simplelogrcompress"
+//
+// It is a separate module so that only programs using these codecs depend on their implementations.
+package simplelogrcompress
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/omaskery/simple-logr"
+)
+
+var (
+	// Zstd compresses using Zstandard, which compresses log output far faster than gzip at similar ratios
+	Zstd = simplelogr.CompressionCodec{
+		Name:            "zstd",
+		ContentEncoding: "zstd",
+		Extension:       ".zst",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w)
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	}
+
+	// Snappy compresses using the snappy framing format, trading compression ratio for speed. It has no HTTP content
+	// encoding, so cannot be used to compress HTTP requests
+	Snappy = simplelogr.CompressionCodec{
+		Name:      "snappy",
+		Extension: ".sz",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return snappy.NewBufferedWriter(w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return ioutil.NopCloser(snappy.NewReader(r)), nil
+		},
+	}
+)
+
+func init() {
+	for _, codec := range []simplelogr.CompressionCodec{Zstd, Snappy} {
+		if err := simplelogr.RegisterCompressionCodec(codec); err != nil {
+			panic(err)
+		}
+	}
+}
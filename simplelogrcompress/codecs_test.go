@@ -0,0 +1,51 @@
+package simplelogrcompress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/omaskery/simple-logr"
+)
+
+func TestCodecsRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat(`{"level":"INFO","msg":"hello"}`+"\n", 1000))
+
+	for _, name := range []string{"zstd", "snappy"} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := simplelogr.LookupCompressionCodec(name)
+			if err != nil {
+				t.Fatalf("expected the codec to be registered: %v", err)
+			}
+
+			buffer := bytes.Buffer{}
+			w, err := codec.NewWriter(&buffer)
+			if err != nil {
+				t.Fatalf("failed to create writer: %v", err)
+			}
+			if _, err := w.Write(data); err != nil {
+				t.Fatalf("failed to compress: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("failed to close writer: %v", err)
+			}
+			if buffer.Len() >= len(data) {
+				t.Errorf("expected the data to be compressed, got %d bytes from %d", buffer.Len(), len(data))
+			}
+
+			r, err := codec.NewReader(&buffer)
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+			defer r.Close()
+			decompressed, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			if !bytes.Equal(decompressed, data) {
+				t.Error("expected the decompressed data to match the original")
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/omaskery/simple-logr/simplelogrcompress
+
+go 1.16
+
+replace github.com/omaskery/simple-logr => ../
+
+require (
+	github.com/klauspost/compress v1.13.6
+	github.com/omaskery/simple-logr v0.0.0-00010101000000-000000000000
+)
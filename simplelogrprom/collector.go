@@ -0,0 +1,87 @@
+// Package simplelogrprom registers the counters of a simplelogr.MetricsSink on a prometheus.Registerer. It is a
+// separate module so that only programs exporting their metrics this way depend on the Prometheus client.
+package simplelogrprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/omaskery/simple-logr"
+)
+
+// Collector implements prometheus.Collector, exposing the counters of a MetricsSink under the same names as
+// MetricsSink.ServeHTTP
+type Collector struct {
+	sink *simplelogr.MetricsSink
+
+	entries       *prometheus.Desc
+	sinkErrors    *prometheus.Desc
+	dropped       *prometheus.Desc
+	memoryLimit   *prometheus.Desc
+	memoryUsed    *prometheus.Desc
+	memoryDropped *prometheus.Desc
+}
+
+// NewCollector creates a new Collector for the given sink, naming the metrics using MetricsSink.Namespace
+func NewCollector(sink *simplelogr.MetricsSink) *Collector {
+	ns := sink.Namespace()
+	return &Collector{
+		sink: sink,
+		entries: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "entries_total"),
+			"Number of log entries, by severity and logger name.", []string{"severity", "name"}, nil),
+		sinkErrors: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "sink_errors_total"),
+			"Number of errors emitting log entries.", nil, nil),
+		dropped: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "dropped_entries_total"),
+			"Number of log entries discarded, by sink.", []string{"sink"}, nil),
+		memoryLimit: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "memory_limit_bytes"),
+			"Memory available for buffering log entries.", nil, nil),
+		memoryUsed: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "memory_used_bytes"),
+			"Memory used by buffered log entries, by component.", []string{"component"}, nil),
+		memoryDropped: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "memory_dropped_entries_total"),
+			"Number of log entries discarded to stay within the memory budget, by component.", []string{"component"}, nil),
+	}
+}
+
+// Register registers a Collector for the given sink on the given Registerer
+func Register(registerer prometheus.Registerer, sink *simplelogr.MetricsSink) error {
+	return registerer.Register(NewCollector(sink))
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.sinkErrors
+	ch <- c.dropped
+	ch <- c.memoryLimit
+	ch <- c.memoryUsed
+	ch <- c.memoryDropped
+}
+
+// Collect implements prometheus.Collector, taking a snapshot of the counters using MetricsSink.Metrics
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	metrics := c.sink.Metrics()
+
+	for _, entry := range metrics.Entries {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.CounterValue, float64(entry.Count),
+			entry.Severity, entry.Name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.sinkErrors, prometheus.CounterValue, float64(metrics.SinkErrors))
+
+	for name, count := range metrics.Dropped {
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(count), name)
+	}
+
+	if metrics.Memory == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.memoryLimit, prometheus.GaugeValue, float64(metrics.Memory.Limit))
+	for component, used := range metrics.Memory.Components {
+		ch <- prometheus.MustNewConstMetric(c.memoryUsed, prometheus.GaugeValue, float64(used), component)
+	}
+	for component, count := range metrics.Memory.Dropped {
+		ch <- prometheus.MustNewConstMetric(c.memoryDropped, prometheus.CounterValue, float64(count), component)
+	}
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
@@ -0,0 +1,58 @@
+package simplelogrprom
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/omaskery/simple-logr"
+)
+
+type failingSink struct{}
+
+func (failingSink) Log(e simplelogr.Entry) error {
+	if e.Error != nil {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+type droppingSink struct{}
+
+func (droppingSink) Dropped() uint64 {
+	return 3
+}
+
+func TestRegisterMatchesServeHTTP(t *testing.T) {
+	budgetOpts := simplelogr.MemoryBudgetOptions{Limit: 1024}
+	budgetOpts.AssertDefaults()
+	budget := simplelogr.NewMemoryBudget(budgetOpts)
+	budget.Reserve("queue", 512)
+	budget.Reserve("queue", 1024)
+
+	opts := simplelogr.MetricsSinkOptions{
+		Sink:         failingSink{},
+		DropCounters: map[string]simplelogr.DropCounter{"async": droppingSink{}},
+		MemoryBudget: budget,
+	}
+	opts.AssertDefaults()
+	sink := simplelogr.NewMetricsSink(opts)
+
+	_ = sink.Log(simplelogr.Entry{Names: []string{"app"}, Message: "hello"})
+	_ = sink.Log(simplelogr.Entry{Names: []string{"app", "db"}, Message: "oops", Error: errors.New("failed")})
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := Register(registry, sink); err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	sink.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if err := testutil.GatherAndCompare(registry, recorder.Body); err != nil {
+		t.Errorf("expected the registered metrics to match those served over HTTP: %v", err)
+	}
+}
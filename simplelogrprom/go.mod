@@ -0,0 +1,10 @@
+module github.com/omaskery/simple-logr/simplelogrprom
+
+go 1.16
+
+replace github.com/omaskery/simple-logr => ../
+
+require (
+	github.com/omaskery/simple-logr v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.11.0
+)
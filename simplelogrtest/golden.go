@@ -0,0 +1,105 @@
+package simplelogrtest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omaskery/simple-logr"
+)
+
+var (
+	// GoldenTimestamp is the frozen clock time used by GoldenEntries
+	GoldenTimestamp = time.Date(2021, time.October, 2, 12, 30, 45, 123456789, time.UTC)
+	// GoldenEntries is a fixed sequence of Entry objects covering the common shapes of log entry, used by AssertGolden
+	// when no entries are specified
+	GoldenEntries = []simplelogr.Entry{
+		{
+			Timestamp: GoldenTimestamp,
+			Message:   "a simple message",
+		},
+		{
+			Level:     1,
+			Names:     []string{"example", "component"},
+			Timestamp: GoldenTimestamp.Add(time.Millisecond),
+			Message:   "a more verbose message with values",
+			KVs:       []interface{}{"string", "hello", "int", 10, "bool", true, "list", []int{1, 2, 3}},
+		},
+		{
+			Level:     2,
+			Names:     []string{"example"},
+			Timestamp: GoldenTimestamp.Add(2 * time.Millisecond),
+			Message:   "a very verbose message",
+			KVs:       []interface{}{"map", map[string]interface{}{"a": 1, "b": "two"}},
+		},
+		{
+			Names:     []string{"example"},
+			Timestamp: GoldenTimestamp.Add(3 * time.Millisecond),
+			Message:   "an error occurred",
+			Error:     errors.New("something went wrong"),
+			KVs:       []interface{}{"attempt", 3},
+		},
+	}
+)
+
+// GoldenOptions configures AssertGolden
+type GoldenOptions struct {
+	// Path is the location of the golden file that the rendered output is compared against
+	Path string
+	// Entries are rendered through the LogSink, if unspecified GoldenEntries are used
+	Entries []simplelogr.Entry
+	// Update causes the golden file to be (re)written with the rendered output rather than compared against it.
+	// If false, the -update flag is honoured when the test binary defines one, e.g. using
+	// flag.Bool("update", false, "update golden files")
+	Update bool
+}
+
+// AssertGolden renders a fixed sequence of entries through the LogSink produced by newSink, and fails the test if the
+// output differs from the contents of the golden file. This allows custom encoders and formatting options to be
+// regression tested. Sinks with timestamps should be configured without any dependency on the current time, and
+// DevelopmentLogSink should be configured with ColourModeForceOff or ColourModeForceOn for reproducible output.
+func AssertGolden(t testing.TB, opts GoldenOptions, newSink func(w io.Writer) simplelogr.LogSink) {
+	t.Helper()
+
+	entries := opts.Entries
+	if entries == nil {
+		entries = GoldenEntries
+	}
+
+	buffer := bytes.Buffer{}
+	sink := newSink(&buffer)
+	for i, e := range entries {
+		if err := sink.Log(e); err != nil {
+			t.Fatalf("failed to log golden entry %d: %v", i, err)
+		}
+	}
+
+	if opts.Update || updateFlagSet() {
+		if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(opts.Path, buffer.Bytes(), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(opts.Path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+
+	if !bytes.Equal(expected, buffer.Bytes()) {
+		t.Errorf("output does not match golden file %s\n--- expected:\n%s\n--- actual:\n%s", opts.Path, expected, buffer.Bytes())
+	}
+}
+
+func updateFlagSet() bool {
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
@@ -0,0 +1,74 @@
+package simplelogrtest
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	// DefaultGoroutineLeakTimeout is how long CheckGoroutineLeaks waits for goroutines to stop before failing the test
+	DefaultGoroutineLeakTimeout = time.Second
+)
+
+// CheckGoroutineLeaks fails the test if any goroutines started during the test are still running once it finishes,
+// after allowing DefaultGoroutineLeakTimeout for them to stop. It is intended for verifying that background
+// components (such as LevelPoller) are stopped cleanly by their Close method. It should not be used in parallel tests,
+// as goroutines started by other tests would be reported.
+func CheckGoroutineLeaks(t testing.TB) {
+	t.Helper()
+
+	before := goroutineStacks()
+
+	t.Cleanup(func() {
+		var leaked []string
+
+		deadline := time.Now().Add(DefaultGoroutineLeakTimeout)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range goroutineStacks() {
+				if _, existed := before[id]; !existed {
+					leaked = append(leaked, stack)
+				}
+			}
+
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if len(leaked) > 0 {
+			t.Errorf("found %d leaked goroutine(s):\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// goroutineStacks returns the stack of every running goroutine, keyed by the goroutine's header line identifier
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	stacks := map[string]string{}
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		// each stack begins with a header like "goroutine 12 [running]:"
+		fields := strings.Fields(stack)
+		if len(fields) < 2 || fields[0] != "goroutine" {
+			continue
+		}
+		// goroutines running tests (e.g. parallel subtests) are not leaks
+		if strings.Contains(stack, "testing.tRunner") {
+			continue
+		}
+		stacks[fields[1]] = stack
+	}
+	return stacks
+}
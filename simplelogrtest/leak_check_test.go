@@ -0,0 +1,117 @@
+package simplelogrtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omaskery/simple-logr"
+)
+
+// fakeT records the failures and cleanups of a test, so that the failures of CheckGoroutineLeaks can be asserted on.
+// Only the methods used by CheckGoroutineLeaks are implemented
+type fakeT struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+// finish runs the cleanups in the same order as testing.T
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+// withLeakTimeout shortens DefaultGoroutineLeakTimeout for the duration of a test
+func withLeakTimeout(t *testing.T, timeout time.Duration) {
+	previous := DefaultGoroutineLeakTimeout
+	DefaultGoroutineLeakTimeout = timeout
+	t.Cleanup(func() {
+		DefaultGoroutineLeakTimeout = previous
+	})
+}
+
+func leakedGoroutine(stop <-chan struct{}) {
+	<-stop
+}
+
+func TestCheckGoroutineLeaksReportsLeaks(t *testing.T) {
+	withLeakTimeout(t, 50*time.Millisecond)
+
+	fake := &fakeT{}
+	CheckGoroutineLeaks(fake)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go leakedGoroutine(stop)
+
+	fake.finish()
+
+	if len(fake.errors) != 1 {
+		t.Fatalf("expected the leak to be reported once, got %v", fake.errors)
+	}
+	if !strings.Contains(fake.errors[0], "1 leaked goroutine") || !strings.Contains(fake.errors[0], "leakedGoroutine") {
+		t.Errorf("expected the report to include the leaked goroutine's stack, got %s", fake.errors[0])
+	}
+}
+
+func TestCheckGoroutineLeaksWaitsForGoroutinesToStop(t *testing.T) {
+	withLeakTimeout(t, time.Second)
+
+	fake := &fakeT{}
+	CheckGoroutineLeaks(fake)
+
+	stop := make(chan struct{})
+	go leakedGoroutine(stop)
+	time.AfterFunc(50*time.Millisecond, func() {
+		close(stop)
+	})
+
+	fake.finish()
+
+	if len(fake.errors) != 0 {
+		t.Errorf("expected no leaks to be reported, got %v", fake.errors)
+	}
+}
+
+func TestCheckGoroutineLeaksIgnoresExistingGoroutines(t *testing.T) {
+	withLeakTimeout(t, 50*time.Millisecond)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go leakedGoroutine(stop)
+
+	fake := &fakeT{}
+	CheckGoroutineLeaks(fake)
+	fake.finish()
+
+	if len(fake.errors) != 0 {
+		t.Errorf("expected goroutines started before the check not to be reported, got %v", fake.errors)
+	}
+}
+
+func TestCheckGoroutineLeaksClosedSink(t *testing.T) {
+	CheckGoroutineLeaks(t)
+
+	opts := simplelogr.AsyncSinkOptions{Sink: NewSink(t)}
+	opts.AssertDefaults()
+	sink := simplelogr.NewAsyncSink(opts)
+	sink.Start(context.Background())
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+}
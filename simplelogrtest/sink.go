@@ -0,0 +1,310 @@
+package simplelogrtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/omaskery/simple-logr"
+)
+
+var (
+	// DefaultScopeKey is the key used to tag entries with the name of the test scope they were logged within, see
+	// Sink.Scope
+	DefaultScopeKey = "test_scope"
+)
+
+// Sink records every Entry it is given in memory, so that tests can make assertions about what was logged. It is also
+// a SamplingObserver, recording the decisions made by a SamplingSink so that tests can assert that entries are not
+// sampled away. It is safe for concurrent use.
+type Sink struct {
+	lock      sync.Mutex
+	entries   []simplelogr.Entry
+	decisions []SampledEntry
+	// name is the name of the test this sink is scoped to, if created using Scope
+	name string
+	// root is the sink that scoped sinks are derived from, and holds the registry of active scopes
+	root   *Sink
+	scopes map[string]*Sink
+}
+
+// NewSink creates a new Sink. If t is not nil, the recorded entries are written to the test output when the test
+// fails, to help with diagnosing the failure
+func NewSink(t testing.TB) *Sink {
+	sink := &Sink{
+		scopes: map[string]*Sink{},
+	}
+	sink.root = sink
+
+	if t != nil {
+		t.Cleanup(func() {
+			if t.Failed() {
+				t.Logf("captured log entries:\n%s", sink.Dump())
+			}
+		})
+	}
+
+	return sink
+}
+
+// Log implements LogSink, recording the Entry. If the Entry is tagged with a test scope (see Scope), it is also
+// recorded by that scope and the scopes of any parent tests
+func (s *Sink) Log(e simplelogr.Entry) error {
+	s.record(e)
+
+	scope, ok := testScopeOf(e)
+	if !ok {
+		return nil
+	}
+
+	for _, scoped := range s.root.scopesFor(scope) {
+		if scoped != s {
+			scoped.record(e)
+		}
+	}
+
+	return nil
+}
+
+// SampledEntry is an Entry along with the decision a SamplingSink made about it
+type SampledEntry struct {
+	Entry    simplelogr.Entry
+	Decision simplelogr.SamplingDecision
+}
+
+// ObserveSampling implements SamplingObserver, recording the decision. As with Log, the decision is also recorded by
+// the test scope the Entry is tagged with, if any
+func (s *Sink) ObserveSampling(e simplelogr.Entry, decision simplelogr.SamplingDecision) {
+	s.observe(SampledEntry{Entry: e, Decision: decision})
+
+	scope, ok := testScopeOf(e)
+	if !ok {
+		return
+	}
+
+	for _, scoped := range s.root.scopesFor(scope) {
+		if scoped != s {
+			scoped.observe(SampledEntry{Entry: e, Decision: decision})
+		}
+	}
+}
+
+// Scope derives a Sink recording only the entries logged during the given (sub)test, even when the logger is shared
+// between parallel subtests. Entries are attributed to the scope by tagging them with DefaultScopeKey, so the code
+// under test must use a logger derived from Logger. The scope ends when the test finishes.
+func (s *Sink) Scope(t testing.TB) *Sink {
+	scoped := NewSink(t)
+	scoped.name = t.Name()
+	scoped.root = s.root
+
+	s.root.lock.Lock()
+	s.root.scopes[scoped.name] = scoped
+	s.root.lock.Unlock()
+
+	t.Cleanup(func() {
+		s.root.lock.Lock()
+		defer s.root.lock.Unlock()
+		delete(s.root.scopes, scoped.name)
+	})
+
+	return scoped
+}
+
+// Logger tags the given logger so that entries it logs are attributed to this scope, see Scope. If this sink is not
+// a scope the logger is returned unchanged
+func (s *Sink) Logger(logger logr.Logger) logr.Logger {
+	if s.name == "" {
+		return logger
+	}
+	return logger.WithValues(DefaultScopeKey, s.name)
+}
+
+func (s *Sink) record(e simplelogr.Entry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+func (s *Sink) observe(sampled SampledEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decisions = append(s.decisions, sampled)
+}
+
+// scopesFor finds the active scopes for the named test and its parent tests
+func (s *Sink) scopesFor(name string) []*Sink {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var scopes []*Sink
+	for scopeName, scoped := range s.scopes {
+		if name == scopeName || strings.HasPrefix(name, scopeName+"/") {
+			scopes = append(scopes, scoped)
+		}
+	}
+	return scopes
+}
+
+func testScopeOf(e simplelogr.Entry) (string, bool) {
+	for i := 0; i+1 < len(e.KVs); i += 2 {
+		if k, ok := e.KVs[i].(string); ok && k == DefaultScopeKey {
+			name, ok := e.KVs[i+1].(string)
+			return name, ok
+		}
+	}
+	return "", false
+}
+
+// Entries returns a copy of all entries recorded so far, in the order they were logged
+func (s *Sink) Entries() []simplelogr.Entry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entries := make([]simplelogr.Entry, len(s.entries))
+	copy(entries, s.entries)
+	return entries
+}
+
+// LastEntry returns the most recently recorded Entry, and false if nothing has been recorded yet
+func (s *Sink) LastEntry() (simplelogr.Entry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.entries) == 0 {
+		return simplelogr.Entry{}, false
+	}
+	return s.entries[len(s.entries)-1], true
+}
+
+// SamplingDecisions returns a copy of all sampling decisions recorded so far, in the order they were made
+func (s *Sink) SamplingDecisions() []SampledEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	decisions := make([]SampledEntry, len(s.decisions))
+	copy(decisions, s.decisions)
+	return decisions
+}
+
+// Suppressed returns the entries a SamplingSink has suppressed so far, along with the reasons why
+func (s *Sink) Suppressed() []SampledEntry {
+	var suppressed []SampledEntry
+	for _, sampled := range s.SamplingDecisions() {
+		if !sampled.Decision.Emitted {
+			suppressed = append(suppressed, sampled)
+		}
+	}
+	return suppressed
+}
+
+// Reset discards all entries and sampling decisions recorded so far
+func (s *Sink) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = nil
+	s.decisions = nil
+}
+
+// HasMessage reports whether any recorded Entry has the given message
+func (s *Sink) HasMessage(msg string) bool {
+	return s.find(func(e simplelogr.Entry) bool {
+		return e.Message == msg
+	})
+}
+
+// HasKV reports whether any recorded Entry has the given key-value pair, values are compared using reflect.DeepEqual
+func (s *Sink) HasKV(key string, value interface{}) bool {
+	return s.find(func(e simplelogr.Entry) bool {
+		for i := 0; i+1 < len(e.KVs); i += 2 {
+			if k, ok := e.KVs[i].(string); ok && k == key && reflect.DeepEqual(e.KVs[i+1], value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AssertMessage fails the test if no recorded Entry has the given message
+func (s *Sink) AssertMessage(t testing.TB, msg string) {
+	t.Helper()
+	if !s.HasMessage(msg) {
+		t.Errorf("expected a log entry with message %q, captured log entries:\n%s", msg, s.Dump())
+	}
+}
+
+// AssertKV fails the test if no recorded Entry has the given key-value pair
+func (s *Sink) AssertKV(t testing.TB, key string, value interface{}) {
+	t.Helper()
+	if !s.HasKV(key, value) {
+		t.Errorf("expected a log entry with %s=%v, captured log entries:\n%s", key, value, s.Dump())
+	}
+}
+
+// AssertNotSuppressed fails the test if a SamplingSink suppressed any Entry with the given message
+func (s *Sink) AssertNotSuppressed(t testing.TB, msg string) {
+	t.Helper()
+	for _, sampled := range s.Suppressed() {
+		if sampled.Entry.Message == msg {
+			t.Errorf("expected log entries with message %q not to be suppressed, but one was (%s after %d with key %q), captured log entries:\n%s",
+				msg, sampled.Decision.Reason, sampled.Decision.Count, sampled.Decision.Key, s.Dump())
+			return
+		}
+	}
+}
+
+// AssertSuppressed fails the test if no Entry with the given message has been suppressed by a SamplingSink
+func (s *Sink) AssertSuppressed(t testing.TB, msg string) {
+	t.Helper()
+	for _, sampled := range s.Suppressed() {
+		if sampled.Entry.Message == msg {
+			return
+		}
+	}
+	t.Errorf("expected a log entry with message %q to be suppressed, captured log entries:\n%s", msg, s.Dump())
+}
+
+// Dump renders all recorded entries as human-readable text, one per line, followed by any suppressed entries
+func (s *Sink) Dump() string {
+	builder := strings.Builder{}
+	for i, e := range s.Entries() {
+		_, _ = fmt.Fprintf(&builder, "  [%d] ", i)
+		dumpEntry(&builder, e)
+	}
+
+	suppressed := s.Suppressed()
+	if len(suppressed) > 0 {
+		builder.WriteString("suppressed log entries:\n")
+	}
+	for i, sampled := range suppressed {
+		_, _ = fmt.Fprintf(&builder, "  [%d] reason=%s count=%d ", i, sampled.Decision.Reason, sampled.Decision.Count)
+		dumpEntry(&builder, sampled.Entry)
+	}
+
+	return builder.String()
+}
+
+func dumpEntry(builder *strings.Builder, e simplelogr.Entry) {
+	_, _ = fmt.Fprintf(builder, "level=%d names=%v msg=%q", e.Level, e.Names, e.Message)
+	if e.Error != nil {
+		_, _ = fmt.Fprintf(builder, " error=%q", e.Error.Error())
+	}
+	for j := 0; j+1 < len(e.KVs); j += 2 {
+		_, _ = fmt.Fprintf(builder, " %v=%v", e.KVs[j], e.KVs[j+1])
+	}
+	builder.WriteString("\n")
+}
+
+func (s *Sink) find(predicate func(e simplelogr.Entry) bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, e := range s.entries {
+		if predicate(e) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ simplelogr.LogSink = (*Sink)(nil)
+var _ simplelogr.SamplingObserver = (*Sink)(nil)
@@ -0,0 +1,69 @@
+package simplelogr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SinkError describes a LogSink failing to log an Entry. The Logger reports one to its ErrorHandler for each sink that
+// fails, which can be retrieved using errors.As to find out which entry was lost and where, e.g. to save it elsewhere:
+//
+//	ErrorHandler: func(err error) {
+//	    var sinkErr *simplelogr.SinkError
+//	    if errors.As(err, &sinkErr) {
+//	        spool(sinkErr.Entry)
+//	    }
+//	}
+type SinkError struct {
+	// Sink is the sink that failed. MultiSink identifies which of its sinks failed, other wrappers are identified
+	// themselves unless the sinks they wrap report a SinkError of their own
+	Sink LogSink
+	// Entry is the entry the sink failed to log, which must not be modified
+	Entry Entry
+	// Attempts is the number of times the sink tried to log the entry, more than one if it was retried by a RetrySink
+	Attempts int
+	// Err is the error the sink returned
+	Err error
+}
+
+// Error implements error, describing the failure along with the sink and the message of the entry
+func (s *SinkError) Error() string {
+	if s.Attempts > 1 {
+		return fmt.Sprintf("failed to log entry %q to %T after %d attempts: %v", s.Entry.Message, s.Sink, s.Attempts, s.Err)
+	}
+	return fmt.Sprintf("failed to log entry %q to %T: %v", s.Entry.Message, s.Sink, s.Err)
+}
+
+// Unwrap returns the error the sink returned
+func (s *SinkError) Unwrap() error {
+	return s.Err
+}
+
+// newSinkError attributes an error returned by the given sink to it, unless the error already identifies the sinks
+// that failed
+func newSinkError(sink LogSink, e Entry, err error) error {
+	var sinkErr *SinkError
+	if _, ok := err.(multiError); ok || errors.As(err, &sinkErr) {
+		return err
+	}
+
+	return &SinkError{
+		Sink:     sink,
+		Entry:    e,
+		Attempts: 1,
+		Err:      err,
+	}
+}
+
+// reportSinkErrors reports an error returned by the given sink to the handler, separately for each of the sinks that
+// failed, as a SinkError
+func reportSinkErrors(sink LogSink, e Entry, err error, handler func(err error)) {
+	if errs, ok := err.(multiError); ok {
+		for _, err := range errs {
+			reportSinkErrors(sink, e, err, handler)
+		}
+		return
+	}
+
+	handler(newSinkError(sink, e, err))
+}
@@ -0,0 +1,131 @@
+package simplelogr
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/go-logr/logr"
+)
+
+// FlushSink is implemented by LogSink objects that buffer entries, e.g. batching, asynchronous or network sinks,
+// allowing any buffered entries to be emitted on demand
+type FlushSink interface {
+	LogSink
+	// Flush emits any buffered entries, returning once they have been emitted (or have failed to be)
+	Flush() error
+}
+
+// CloserSink is implemented by LogSink objects holding resources that must be released, e.g. connections and
+// background goroutines, and which emit any buffered entries before doing so
+type CloserSink interface {
+	LogSink
+	// Close emits any buffered entries, then releases the sink's resources
+	Close() error
+}
+
+// WrapperSink is implemented by LogSink objects that pass entries on to other LogSink objects, allowing the chain of
+// sinks behind a Logger to be walked, see WalkSinks
+type WrapperSink interface {
+	LogSink
+	// Unwrap returns the sinks that entries are passed on to
+	Unwrap() []LogSink
+}
+
+// Flush emits any entries buffered by the sinks behind the given logr.Logger, if it is backed by a Logger, typically
+// called before the process exits so that the last entries are not lost
+func Flush(logger logr.Logger) error {
+	if l, ok := logger.GetSink().(*Logger); ok {
+		return FlushSinks(l.options.Sink)
+	}
+	return nil
+}
+
+// Close emits any buffered entries and releases the resources of the sinks behind the given logr.Logger, if it is
+// backed by a Logger. The logger must not be used afterwards.
+func Close(logger logr.Logger) error {
+	if l, ok := logger.GetSink().(*Logger); ok {
+		return CloseSinks(l.options.Sink)
+	}
+	return nil
+}
+
+// FlushSinks calls Flush on the given sink and every sink it wraps that implements FlushSink, outermost first so that
+// entries flushed by wrappers are then flushed by the sinks they wrap. All sinks are flushed even if some fail.
+func FlushSinks(sink LogSink) error {
+	var errs multiError
+	WalkSinks(sink, func(s LogSink) {
+		if flusher, ok := s.(FlushSink); ok {
+			if err := flusher.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// CloseSinks calls Close on the given sink and every sink it wraps that implements CloserSink, or Flush for those
+// that only implement FlushSink, outermost first. All sinks are closed even if some fail.
+func CloseSinks(sink LogSink) error {
+	var errs multiError
+	WalkSinks(sink, func(s LogSink) {
+		var err error
+		switch s := s.(type) {
+		case CloserSink:
+			err = s.Close()
+		case FlushSink:
+			err = s.Flush()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// WalkSinks calls fn with the given sink and then, depth first, every sink it wraps as reported by WrapperSink. Sinks
+// that are reachable more than once are only visited the first time.
+func WalkSinks(sink LogSink, fn func(sink LogSink)) {
+	var visited []LogSink
+	var walk func(s LogSink)
+	walk = func(s LogSink) {
+		if s == nil {
+			return
+		}
+
+		if reflect.TypeOf(s).Comparable() {
+			for _, v := range visited {
+				if reflect.TypeOf(v) == reflect.TypeOf(s) && v == s {
+					return
+				}
+			}
+			visited = append(visited, s)
+		}
+
+		fn(s)
+
+		if wrapper, ok := s.(WrapperSink); ok {
+			for _, wrapped := range wrapper.Unwrap() {
+				walk(wrapped)
+			}
+		}
+	}
+	walk(sink)
+}
+
+// flushWriter flushes the given io.Writer if it buffers writes, e.g. a bufio.Writer
+func flushWriter(w io.Writer) error {
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
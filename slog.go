@@ -0,0 +1,98 @@
+//go:build go1.21
+// +build go1.21
+
+package simplelogr
+
+import (
+	"log/slog"
+)
+
+// resolveSlogValue converts values originating from log/slog into plain Go values, so that they are encoded
+// consistently regardless of whether they were created using the slog or logr APIs:
+// - slog.LogValuer implementations are resolved to the value they represent
+// - slog.Value objects are converted to the Go value they hold, with groups becoming a map of their attributes
+// - slog.Attr objects become a single entry map of their key to their (converted) value
+func resolveSlogValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case slog.Attr:
+		return slogAttrsToMap([]slog.Attr{value})
+	case slog.Value:
+		return slogValueToInterface(value)
+	case slog.LogValuer:
+		return slogValueToInterface(slog.AnyValue(value))
+	default:
+		return v
+	}
+}
+
+func slogValueToInterface(v slog.Value) interface{} {
+	v = v.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	case slog.KindGroup:
+		return slogAttrsToMap(v.Group())
+	default:
+		return v.Any()
+	}
+}
+
+func slogAttrsToMap(attrs []slog.Attr) map[string]interface{} {
+	obj := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		value := slogValueToInterface(attr.Value)
+
+		// slog inlines the attributes of groups with empty keys into their parent
+		if group, ok := value.(map[string]interface{}); ok && attr.Key == "" {
+			for k, v := range group {
+				obj[k] = v
+			}
+			continue
+		}
+
+		obj[attr.Key] = value
+	}
+	return obj
+}
+
+// isLazySlogAny reports whether a value originating from log/slog is resolved when it is logged, i.e. it is or contains
+// a slog.LogValuer
+func isLazySlogAny(v interface{}) bool {
+	switch value := v.(type) {
+	case slog.LogValuer:
+		return true
+	case slog.Value:
+		return isLazySlogValue(value)
+	case slog.Attr:
+		return isLazySlogValue(value.Value)
+	default:
+		return false
+	}
+}
+
+func isLazySlogValue(v slog.Value) bool {
+	switch v.Kind() {
+	case slog.KindLogValuer:
+		return true
+	case slog.KindGroup:
+		for _, attr := range v.Group() {
+			if isLazySlogValue(attr.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
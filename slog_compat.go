@@ -0,0 +1,14 @@
+//go:build !go1.21
+// +build !go1.21
+
+package simplelogr
+
+// resolveSlogValue is a no-op prior to go1.21, as log/slog values cannot exist
+func resolveSlogValue(v interface{}) interface{} {
+	return v
+}
+
+// isLazySlogAny always reports false prior to go1.21, as log/slog values cannot exist
+func isLazySlogAny(v interface{}) bool {
+	return false
+}
@@ -0,0 +1,444 @@
+package simplelogr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultHECBatchSize is the number of entries a SplunkHECLogSink batches before sending them
+	DefaultHECBatchSize = 100
+	// DefaultHECFlushInterval is how often a started SplunkHECLogSink sends incomplete batches
+	DefaultHECFlushInterval = 5 * time.Second
+	// DefaultHECMaxRetries is how many times a SplunkHECLogSink retries sending a batch before giving up
+	DefaultHECMaxRetries = 3
+	// DefaultHECRetryBackoff is how long a SplunkHECLogSink waits before its first retry, doubling for each retry
+	DefaultHECRetryBackoff = 500 * time.Millisecond
+	// DefaultHECTimeout bounds how long each request of a SplunkHECLogSink may take when no Client is specified
+	DefaultHECTimeout = 10 * time.Second
+)
+
+// SplunkHECLogSink batches log Entry objects and sends them to a Splunk HTTP Event Collector (HEC), so that services
+// can log to Splunk without a forwarder. Full batches are sent in the background, so that logging never waits for the
+// HTTP Event Collector, as are incomplete batches every FlushInterval once Start has been called. Flush and Close send
+// any batched entries synchronously, although Close does not retry them, so that shutting down is not held up by an
+// unavailable HTTP Event Collector.
+//
+// The first full batch starts the sink if Start has not been called, so Close should be called once the sink is no
+// longer needed. Failures to send in the background are reported to the ErrorHandler. Entries accumulate while the
+// HTTP Event Collector is slow or unavailable, so consider limiting their Memory.
+type SplunkHECLogSink struct {
+	options SplunkHECLogSinkOptions
+	event   *JSONLogSink
+
+	lock  sync.Mutex
+	batch []hecBatchEvent
+	// full wakes the background sender when a batch is full
+	full chan struct{}
+
+	// sendLock ensures batches are sent in the order they were produced
+	sendLock sync.Mutex
+
+	lifecycleLock sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+	closed        bool
+	// closing is cancelled by Close, so that batches are no longer retried
+	closing context.Context
+	stop    context.CancelFunc
+}
+
+// NewSplunkHECLogSink creates a new SplunkHECLogSink with the provided options
+func NewSplunkHECLogSink(opts SplunkHECLogSinkOptions) *SplunkHECLogSink {
+	closing, stop := context.WithCancel(context.Background())
+	return &SplunkHECLogSink{
+		options: opts,
+		event:   NewJSONLogSink(opts.Event),
+		full:    make(chan struct{}, 1),
+		closing: closing,
+		stop:    stop,
+	}
+}
+
+// hecEvent is the JSON structure of an event sent to the HTTP Event Collector
+type hecEvent struct {
+	Time       float64         `json:"time"`
+	Host       string          `json:"host,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	SourceType string          `json:"sourcetype,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// hecBatchEvent is an encoded event waiting to be sent, along with the sequence number of the Entry it encodes
+type hecBatchEvent struct {
+	sequence uint64
+	data     []byte
+	// size is the memory reserved for the event, see SplunkHECLogSinkOptions.Memory
+	size int64
+}
+
+// Log implements LogSink, adding the Entry to the current batch, and waking the background sender if it is full
+func (s *SplunkHECLogSink) Log(e Entry) error {
+	payload := bytes.Buffer{}
+	if err := s.event.Encode(&payload, e); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(hecEvent{
+		Time:       float64(e.Timestamp.UnixNano()) / float64(time.Second),
+		Host:       s.options.Host,
+		Source:     s.options.Source,
+		SourceType: s.options.SourceType,
+		Index:      s.options.Index,
+		Event:      bytes.TrimSpace(payload.Bytes()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode HEC event: %w", err)
+	}
+
+	var size int64
+	if s.options.Memory.Budget != nil {
+		size = int64(cap(b))
+	}
+
+	s.lock.Lock()
+	if !s.options.Memory.reserve(size, s.evictLocked) {
+		s.lock.Unlock()
+		return fmt.Errorf("HEC batch memory budget exceeded")
+	}
+	s.batch = append(s.batch, hecBatchEvent{sequence: e.Sequence, data: b, size: size})
+	full := len(s.batch) >= s.options.BatchSize
+	s.lock.Unlock()
+
+	if full {
+		s.sendInBackground()
+	}
+
+	return nil
+}
+
+// sendInBackground wakes the background sender to send a full batch, starting it first if necessary, unless the sink
+// has been closed
+func (s *SplunkHECLogSink) sendInBackground() {
+	s.lifecycleLock.Lock()
+	if s.done == nil && !s.closed {
+		s.startLocked(context.Background())
+	}
+	s.lifecycleLock.Unlock()
+
+	select {
+	case s.full <- struct{}{}:
+	default:
+	}
+}
+
+// Flush sends the entries batched so far to the HTTP Event Collector, in batches of up to BatchSize, retrying each
+// with backoff on failure until the sink is closed
+func (s *SplunkHECLogSink) Flush() error {
+	return s.flush(context.Background())
+}
+
+// flush sends the entries batched so far, abandoning any requests and retries once ctx is done
+func (s *SplunkHECLogSink) flush(ctx context.Context) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	s.lock.Lock()
+	pending := len(s.batch)
+	s.lock.Unlock()
+
+	var errs multiError
+	for pending > 0 {
+		s.lock.Lock()
+		n := len(s.batch)
+		if n > s.options.BatchSize {
+			n = s.options.BatchSize
+		}
+		batch := s.batch[:n:n]
+		if n < len(s.batch) {
+			s.batch = append([]hecBatchEvent(nil), s.batch[n:]...)
+		} else {
+			s.batch = nil
+		}
+		s.lock.Unlock()
+
+		if n == 0 {
+			break
+		}
+		pending -= n
+
+		if err := s.send(ctx, batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// send sends a batch of entries to the HTTP Event Collector, retrying with backoff on failure until ctx is done or
+// the sink is closed
+func (s *SplunkHECLogSink) send(ctx context.Context, batch []hecBatchEvent) error {
+	defer func() {
+		for _, event := range batch {
+			s.options.Memory.release(event.size)
+		}
+	}()
+
+	if s.options.Reorder {
+		sort.SliceStable(batch, func(i, j int) bool {
+			return batch[i].sequence < batch[j].sequence
+		})
+	}
+
+	var body []byte
+	for _, event := range batch {
+		body = append(body, event.data...)
+	}
+
+	contentEncoding := ""
+	if s.options.Compression != "" {
+		codec, err := LookupCompressionCodec(s.options.Compression)
+		if err != nil {
+			return fmt.Errorf("failed to send %d entries to HEC: %w", len(batch), err)
+		}
+		if codec.ContentEncoding == "" {
+			return fmt.Errorf("failed to send %d entries to HEC: compression codec %q has no HTTP content encoding",
+				len(batch), codec.Name)
+		}
+		if body, err = codec.compress(body); err != nil {
+			return fmt.Errorf("failed to compress %d entries for HEC: %w", len(batch), err)
+		}
+		contentEncoding = codec.ContentEncoding
+	}
+
+	retries := s.options.MaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+
+	backoff := s.options.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			if !s.wait(ctx, backoff) {
+				break
+			}
+			backoff *= 2
+		}
+
+		var retryable bool
+		if retryable, err = s.post(ctx, body, contentEncoding); err == nil || !retryable {
+			break
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to send %d entries to HEC: %w", len(batch), err)
+	}
+
+	return nil
+}
+
+// wait waits for the given backoff before a retry, returning false if ctx is done or the sink is closed first
+func (s *SplunkHECLogSink) wait(ctx context.Context, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.closing.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// evictLocked discards the oldest batched event to make room for a new one, see MemoryPolicyShrink
+func (s *SplunkHECLogSink) evictLocked() (int64, bool) {
+	if len(s.batch) == 0 {
+		return 0, false
+	}
+
+	oldest := s.batch[0]
+	s.batch[0] = hecBatchEvent{}
+	s.batch = s.batch[1:]
+
+	return oldest.size, true
+}
+
+// Start begins sending full batches, and incomplete batches every FlushInterval, in the background, until the context
+// is cancelled or Close is called. Calling Start on a sink that is already started has no effect.
+func (s *SplunkHECLogSink) Start(ctx context.Context) {
+	s.lifecycleLock.Lock()
+	defer s.lifecycleLock.Unlock()
+
+	if s.done != nil {
+		return
+	}
+	s.startLocked(ctx)
+}
+
+// startLocked starts the background sender
+func (s *SplunkHECLogSink) startLocked(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func(done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(s.options.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.flush(ctx); err != nil {
+					s.options.ErrorHandler(err)
+				}
+			case <-s.full:
+				if err := s.flush(ctx); err != nil {
+					s.options.ErrorHandler(err)
+				}
+			}
+		}
+	}(s.done)
+}
+
+// Close stops any background sending, abandoning batches being sent or retried in the background, and makes a single
+// attempt to send any remaining batched entries, without retrying
+func (s *SplunkHECLogSink) Close() error {
+	s.stop()
+
+	s.lifecycleLock.Lock()
+	s.closed = true
+	if s.done != nil {
+		s.cancel()
+		<-s.done
+		s.done = nil
+	}
+	s.lifecycleLock.Unlock()
+
+	return s.Flush()
+}
+
+// post posts a batch of events, reporting whether any failure is worth retrying
+func (s *SplunkHECLogSink) post(ctx context.Context, body []byte, contentEncoding string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Splunk "+s.options.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	sent := time.Now()
+	resp, err := s.options.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if s.options.ClockSkew != nil {
+		s.options.ClockSkew.ObserveHTTP(resp, sent, time.Now())
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+var _ LogSink = (*SplunkHECLogSink)(nil)
+var _ CloserSink = (*SplunkHECLogSink)(nil)
+
+// SplunkHECLogSinkOptions configures the behaviour of a SplunkHECLogSink
+type SplunkHECLogSinkOptions struct {
+	// URL is the HTTP Event Collector endpoint, e.g. https://splunk.example.com:8088/services/collector/event
+	URL string
+	// Token is the HEC token used to authenticate
+	Token string
+	// Host, Source, SourceType and Index are optional metadata attached to every event
+	Host       string
+	Source     string
+	SourceType string
+	Index      string
+	// Client is used to make requests to the HTTP Event Collector, by default one with a Timeout of DefaultHECTimeout
+	Client *http.Client
+	// BatchSize is the number of entries batched before they are sent
+	BatchSize int
+	// Reorder sorts each batch by Entry.Sequence before sending it, so that entries from an ordered MultiSink which
+	// arrive out of order (e.g. when logged concurrently) are sent in the order they were logged
+	Reorder bool
+	// FlushInterval is how often incomplete batches are sent once the sink has been started
+	FlushInterval time.Duration
+	// Compression, if specified, is the name of the CompressionCodec used to compress each batch, e.g. "gzip", which
+	// must have a CompressionCodec.ContentEncoding
+	Compression string
+	// MaxRetries is how many times sending a batch is retried before giving up, a negative value disables retries.
+	// Batches are not retried once the sink is closed
+	MaxRetries int
+	// RetryBackoff is how long to wait before the first retry, doubling for each subsequent retry
+	RetryBackoff time.Duration
+	// Event configures how each Entry is encoded as the JSON event payload
+	Event JSONLogSinkOptions
+	// ClockSkew, if specified, is used to monitor the clock of the remote end using the Date of its responses
+	ClockSkew *ClockSkewMonitor
+	// ErrorHandler is called with any errors encountered while flushing in the background
+	ErrorHandler func(err error)
+	// Memory optionally limits the memory used by batched events, including those being sent, shared with other
+	// buffering components
+	Memory MemoryLimit
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (s *SplunkHECLogSinkOptions) AssertDefaults() {
+	if s.Client == nil {
+		s.Client = &http.Client{Timeout: DefaultHECTimeout}
+	}
+
+	if s.BatchSize <= 0 {
+		s.BatchSize = DefaultHECBatchSize
+	}
+
+	if s.FlushInterval <= 0 {
+		s.FlushInterval = DefaultHECFlushInterval
+	}
+
+	if s.MaxRetries == 0 {
+		s.MaxRetries = DefaultHECMaxRetries
+	}
+
+	if s.RetryBackoff <= 0 {
+		s.RetryBackoff = DefaultHECRetryBackoff
+	}
+
+	s.Event.AssertDefaults()
+
+	if s.ErrorHandler == nil {
+		s.ErrorHandler = DefaultErrorHandler
+	}
+
+	s.Memory.assertDefaults("splunk_hec")
+}
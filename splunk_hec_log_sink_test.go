@@ -0,0 +1,141 @@
+package simplelogr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplunkHECLogSinkCloseInterruptsRetries(t *testing.T) {
+	var lock sync.Mutex
+	requests := 0
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var errs []error
+	opts := SplunkHECLogSinkOptions{
+		URL:          server.URL,
+		BatchSize:    1,
+		MaxRetries:   5,
+		RetryBackoff: time.Minute,
+		ErrorHandler: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+	opts.AssertDefaults()
+	sink := NewSplunkHECLogSink(opts)
+	sink.Start(context.Background())
+
+	// the full batch is sent in the background, which then waits to retry it
+	if err := sink.Log(Entry{Message: "first"}); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	<-received
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- sink.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close to interrupt the retries of the background sender")
+	}
+
+	if len(errs) != 1 {
+		t.Errorf("expected the abandoned batch to be reported, got %v", errs)
+	}
+
+	// remaining entries are sent once by Close, and not retried
+	lock.Lock()
+	requests = 0
+	lock.Unlock()
+	if err := sink.Log(Entry{Message: "second"}); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- sink.Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the failure to send the remaining entries to be returned")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close not to retry sending the remaining entries")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if requests != 1 {
+		t.Errorf("expected a single attempt to send the remaining entries, got %d", requests)
+	}
+}
+
+func TestSplunkHECLogSinkContentEncoding(t *testing.T) {
+	gzipCodec, err := LookupCompressionCodec("gzip")
+	if err != nil {
+		t.Fatalf("expected gzip to be registered: %v", err)
+	}
+	custom := gzipCodec
+	custom.Name = "tuned-gzip"
+	if err := RegisterCompressionCodec(custom); err != nil {
+		t.Fatalf("failed to register codec: %v", err)
+	}
+	opaque := gzipCodec
+	opaque.Name = "opaque"
+	opaque.ContentEncoding = ""
+	if err := RegisterCompressionCodec(opaque); err != nil {
+		t.Fatalf("failed to register codec: %v", err)
+	}
+	t.Cleanup(func() {
+		compressionCodecsLock.Lock()
+		defer compressionCodecsLock.Unlock()
+		delete(compressionCodecs, custom.Name)
+		delete(compressionCodecs, opaque.Name)
+	})
+
+	encodings := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings <- r.Header.Get("Content-Encoding")
+	}))
+	defer server.Close()
+
+	for _, compression := range []string{"tuned-gzip", "opaque"} {
+		opts := SplunkHECLogSinkOptions{URL: server.URL, Compression: compression}
+		opts.AssertDefaults()
+		sink := NewSplunkHECLogSink(opts)
+		_ = sink.Log(Entry{Message: "hello"})
+		err := sink.Flush()
+
+		switch compression {
+		case "tuned-gzip":
+			if err != nil {
+				t.Fatalf("failed to flush: %v", err)
+			}
+			if encoding := <-encodings; encoding != "gzip" {
+				t.Errorf("expected the codec's content encoding to be sent, got %q", encoding)
+			}
+		case "opaque":
+			if err == nil {
+				t.Error("expected a codec without a content encoding to be rejected")
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package simplelogr
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	// DefaultRuntimePrefixes are the function name prefixes of frames omitted by StackTraceOptions.OmitRuntime
+	DefaultRuntimePrefixes = []string{"runtime.", "testing."}
+	// DefaultLoggingPrefixes are the function name prefixes of frames omitted by StackTraceOptions.OmitLogging
+	DefaultLoggingPrefixes = []string{"github.com/omaskery/simple-logr.", "github.com/go-logr/logr."}
+)
+
+// StackTraceOptions trims stack traces down to the frames most likely to be useful, as those built into errors by
+// packages such as github.com/pkg/errors include every frame down to the runtime. Frames are filtered before the
+// remainder is limited to MaxFrames.
+type StackTraceOptions struct {
+	// MaxFrames limits the number of frames kept, innermost first. Zero means there is no limit
+	MaxFrames int
+	// OmitRuntime omits frames from the Go runtime and testing packages, see DefaultRuntimePrefixes
+	OmitRuntime bool
+	// OmitLogging omits frames from this package and logr, see DefaultLoggingPrefixes
+	OmitLogging bool
+	// OmitPrefixes omits frames whose fully qualified function name starts with any of the given prefixes, e.g.
+	// "net/http." or "github.com/example/middleware"
+	OmitPrefixes []string
+}
+
+// omit reports whether a frame for the named function should be omitted
+func (o StackTraceOptions) omit(function string) bool {
+	if o.OmitRuntime && hasAnyPrefix(function, DefaultRuntimePrefixes) {
+		return true
+	}
+	if o.OmitLogging && hasAnyPrefix(function, DefaultLoggingPrefixes) {
+		return true
+	}
+	return hasAnyPrefix(function, o.OmitPrefixes)
+}
+
+// trim filters the frames and limits their number, returning them unchanged if nothing would be removed
+func (o StackTraceOptions) trim(frames []StackFrame) []StackFrame {
+	if !o.OmitRuntime && !o.OmitLogging && len(o.OmitPrefixes) == 0 {
+		if o.MaxFrames > 0 && len(frames) > o.MaxFrames {
+			return frames[:o.MaxFrames]
+		}
+		return frames
+	}
+
+	trimmed := make([]StackFrame, 0, len(frames))
+	for _, frame := range frames {
+		if o.MaxFrames > 0 && len(trimmed) == o.MaxFrames {
+			break
+		}
+		if !o.omit(frame.Function) {
+			trimmed = append(trimmed, frame)
+		}
+	}
+	return trimmed
+}
+
+// trimError trims the frames and stack trace of the EncodedError, and those of its causes and constituent errors.
+// Stack traces that are not formatted in the style of github.com/pkg/errors are left unchanged.
+func (o StackTraceOptions) trimError(encoded EncodedError) EncodedError {
+	if len(encoded.Frames) > 0 {
+		encoded.Frames = o.trim(encoded.Frames)
+	}
+	if encoded.StackTrace != "" {
+		if frames, ok := parseStackFrames(encoded.StackTrace); ok {
+			encoded.StackTrace = formatStackFrames(o.trim(frames))
+		}
+	}
+
+	if len(encoded.Causes) > 0 {
+		causes := make([]EncodedError, len(encoded.Causes))
+		for i, cause := range encoded.Causes {
+			causes[i] = o.trimError(cause)
+		}
+		encoded.Causes = causes
+	}
+	if len(encoded.Errors) > 0 {
+		errs := make([]EncodedError, len(encoded.Errors))
+		for i, constituent := range encoded.Errors {
+			errs[i] = o.trimError(constituent)
+		}
+		encoded.Errors = errs
+	}
+
+	return encoded
+}
+
+// TrimmedErrorEncoder wraps an error encoder, such as DefaultErrorEncoder or StructuredErrorEncoder, trimming the
+// stack traces it extracts according to the options. Both frames and stack trace strings are trimmed, the latter when
+// formatted in the style of github.com/pkg/errors. Use Options.StackTrace to trim the stacks captured by the Logger.
+func TrimmedErrorEncoder(encoder func(err error) EncodedError, opts StackTraceOptions) func(err error) EncodedError {
+	return func(err error) EncodedError {
+		return opts.trimError(encoder(err))
+	}
+}
+
+// formatStackFrames formats frames in the style of github.com/pkg/errors, with each frame's function on one line and
+// its file and line number on the next, indented by a tab
+func formatStackFrames(frames []StackFrame) string {
+	buf := make([]byte, 0, 128*len(frames))
+	for _, frame := range frames {
+		buf = append(buf, '\n')
+		buf = append(buf, frame.Function...)
+		buf = append(buf, "\n\t"...)
+		buf = append(buf, frame.File...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+	}
+	return string(buf)
+}
+
+// parseStackFrames parses a stack trace formatted by formatStackFrames (or github.com/pkg/errors), returning false if
+// it is formatted differently
+func parseStackFrames(stackTrace string) ([]StackFrame, bool) {
+	lines := strings.Split(strings.TrimPrefix(stackTrace, "\n"), "\n")
+	if len(lines)%2 != 0 {
+		return nil, false
+	}
+
+	frames := make([]StackFrame, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		location := lines[i+1]
+		colon := strings.LastIndex(location, ":")
+		if !strings.HasPrefix(location, "\t") || colon < 0 {
+			return nil, false
+		}
+		line, err := strconv.Atoi(location[colon+1:])
+		if err != nil {
+			return nil, false
+		}
+
+		frames = append(frames, StackFrame{
+			Function: lines[i],
+			File:     location[1:colon],
+			Line:     line,
+		})
+	}
+	return frames, true
+}
+
+// hasAnyPrefix reports whether s starts with any of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package simplelogr
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+var (
+	// DefaultHostnameKey is the key HostnameFields uses for the hostname
+	DefaultHostnameKey = "host"
+	// DefaultPIDKey is the key PIDFields uses for the process ID
+	DefaultPIDKey = "pid"
+	// DefaultServiceKey is the key ServiceFields uses for the service name
+	DefaultServiceKey = "service"
+	// DefaultVersionKey is the key ServiceFields uses for the service version
+	DefaultVersionKey = "version"
+	// DefaultEnvironmentKey is the key ServiceFields uses for the deployment environment
+	DefaultEnvironmentKey = "env"
+)
+
+// FieldProvider produces key-value pairs describing the process, which New adds to every entry logged by the Logger
+// and all loggers derived from it. Providers are evaluated once, when the Logger is created. See Options.Fields
+type FieldProvider func() []interface{}
+
+// StaticFields creates a FieldProvider which adds the given key-value pairs
+func StaticFields(keysAndValues ...interface{}) FieldProvider {
+	return func() []interface{} {
+		return keysAndValues
+	}
+}
+
+// HostnameFields creates a FieldProvider which adds the hostname reported by the kernel using DefaultHostnameKey,
+// adding nothing if it cannot be determined
+func HostnameFields() FieldProvider {
+	return func() []interface{} {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			return nil
+		}
+		return []interface{}{DefaultHostnameKey, hostname}
+	}
+}
+
+// PIDFields creates a FieldProvider which adds the process ID using DefaultPIDKey
+func PIDFields() FieldProvider {
+	return func() []interface{} {
+		return []interface{}{DefaultPIDKey, os.Getpid()}
+	}
+}
+
+// ServiceFields creates a FieldProvider which adds the name, version and deployment environment of the service using
+// DefaultServiceKey, DefaultVersionKey and DefaultEnvironmentKey, omitting any that are empty
+func ServiceFields(service, version, environment string) FieldProvider {
+	return func() []interface{} {
+		var kvs []interface{}
+		if service != "" {
+			kvs = append(kvs, DefaultServiceKey, service)
+		}
+		if version != "" {
+			kvs = append(kvs, DefaultVersionKey, version)
+		}
+		if environment != "" {
+			kvs = append(kvs, DefaultEnvironmentKey, environment)
+		}
+		return kvs
+	}
+}
+
+// EnvFields creates a FieldProvider which adds the values of environment variables, mapping each key to the name of
+// the environment variable holding its value, e.g. {"version": "APP_VERSION"}. Variables that are unset are omitted.
+func EnvFields(keysToVariables map[string]string) FieldProvider {
+	return func() []interface{} {
+		keys := make([]string, 0, len(keysToVariables))
+		for key := range keysToVariables {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var kvs []interface{}
+		for _, key := range keys {
+			if value, ok := os.LookupEnv(keysToVariables[key]); ok {
+				kvs = append(kvs, key, value)
+			}
+		}
+		return kvs
+	}
+}
+
+// evaluateFieldProviders evaluates each FieldProvider in turn, concatenating the key-value pairs they produce. A
+// provider producing an odd number of arguments is reported to the error handler and its output discarded, rather than
+// causing every entry to be rejected.
+func evaluateFieldProviders(providers []FieldProvider, errorHandler func(err error)) []interface{} {
+	var kvs []interface{}
+	for i, provider := range providers {
+		fields := provider()
+		if len(fields)%2 != 0 {
+			errorHandler(fmt.Errorf("field provider %d produced an odd number of arguments for key-value pairs", i))
+			continue
+		}
+		kvs = append(kvs, fields...)
+	}
+	return kvs
+}
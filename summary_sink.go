@@ -0,0 +1,162 @@
+package simplelogr
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultSummaryMessage is the message of the summary entry emitted by SummarySink.LogSummary
+	DefaultSummaryMessage = "log summary"
+)
+
+// DropCounter is implemented by LogSink objects that may discard entries rather than emitting them, e.g. because they
+// are rate limited or their queues are full
+type DropCounter interface {
+	// Dropped returns the total number of entries discarded so far
+	Dropped() uint64
+}
+
+// Summary describes the entries that have passed through a SummarySink
+type Summary struct {
+	// Started is the time the SummarySink was created
+	Started time.Time
+	// Uptime is how long the SummarySink has existed
+	Uptime time.Duration
+	// Counts is the number of entries seen, by severity name
+	Counts map[string]uint64
+	// FirstError is the first Entry seen containing an Entry.Error, if any
+	FirstError *Entry
+	// LastError is the most recent Entry seen containing an Entry.Error, if any
+	LastError *Entry
+	// Dropped is the total number of entries discarded by the wrapped sink and any additional DropCounter objects
+	Dropped uint64
+}
+
+// SummarySinkOptions configures the behaviour of a SummarySink
+type SummarySinkOptions struct {
+	// Sink is the LogSink that entries are passed on to, and that the summary entry is emitted to
+	Sink LogSink
+	// SeverityEncoder identifies the severity name used to count entries
+	SeverityEncoder func(level int, err error) string
+	// DropCounters are additional sources of dropped entry counts, the wrapped Sink is always included if it
+	// implements DropCounter
+	DropCounters []DropCounter
+	// Message is the message of the summary entry
+	Message string
+}
+
+// AssertDefaults replaces all uninitialised options with reasonable defaults
+func (s *SummarySinkOptions) AssertDefaults() {
+	if s.SeverityEncoder == nil {
+		s.SeverityEncoder = DefaultSeverityEncoder(DefaultSeverity, DefaultErrorSeverity, DefaultSeverityThresholds)
+	}
+
+	if s.Message == "" {
+		s.Message = DefaultSummaryMessage
+	}
+}
+
+// SummarySink passes entries on to another LogSink while keeping track of what has been logged, so that a summary
+// entry can be emitted at shutdown - useful in batch jobs and CI to see at a glance whether a run logged problems
+type SummarySink struct {
+	options    SummarySinkOptions
+	lock       sync.Mutex
+	started    time.Time
+	counts     map[string]uint64
+	firstError *Entry
+	lastError  *Entry
+}
+
+// NewSummarySink creates a new SummarySink with the provided options
+func NewSummarySink(opts SummarySinkOptions) *SummarySink {
+	return &SummarySink{
+		options: opts,
+		started: time.Now().UTC(),
+		counts:  map[string]uint64{},
+	}
+}
+
+// Log implements LogSink, recording the Entry before passing it on to the wrapped sink
+func (s *SummarySink) Log(e Entry) error {
+	severity := e.severity(s.options.SeverityEncoder)
+
+	s.lock.Lock()
+	s.counts[severity]++
+	if e.Error != nil {
+		entry := e
+		if s.firstError == nil {
+			s.firstError = &entry
+		}
+		s.lastError = &entry
+	}
+	s.lock.Unlock()
+
+	return s.options.Sink.Log(e)
+}
+
+// Summary describes the entries logged so far
+func (s *SummarySink) Summary() Summary {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	summary := Summary{
+		Started:    s.started,
+		Uptime:     time.Since(s.started),
+		Counts:     make(map[string]uint64, len(s.counts)),
+		FirstError: s.firstError,
+		LastError:  s.lastError,
+	}
+	for severity, count := range s.counts {
+		summary.Counts[severity] = count
+	}
+
+	counters := s.options.DropCounters
+	if counter, ok := s.options.Sink.(DropCounter); ok {
+		counters = append([]DropCounter{counter}, counters...)
+	}
+	for _, counter := range counters {
+		summary.Dropped += counter.Dropped()
+	}
+
+	return summary
+}
+
+// LogSummary emits a summary entry to the wrapped sink, typically called at shutdown
+func (s *SummarySink) LogSummary() error {
+	summary := s.Summary()
+
+	kvs := []interface{}{
+		"uptime", summary.Uptime.String(),
+		"entries", summary.Counts,
+		"dropped", summary.Dropped,
+	}
+	if summary.FirstError != nil {
+		kvs = append(kvs, "first_error", summarizeErrorEntry(*summary.FirstError))
+	}
+	if summary.LastError != nil {
+		kvs = append(kvs, "last_error", summarizeErrorEntry(*summary.LastError))
+	}
+
+	return s.options.Sink.Log(Entry{
+		Timestamp: time.Now().UTC(),
+		Message:   s.options.Message,
+		KVs:       kvs,
+	})
+}
+
+func summarizeErrorEntry(e Entry) map[string]interface{} {
+	return map[string]interface{}{
+		DefaultTimestampKey: e.Timestamp,
+		DefaultMessageKey:   e.Message,
+		DefaultErrorKey:     e.Error.Error(),
+	}
+}
+
+// Unwrap implements WrapperSink, returning the wrapped sink
+func (s *SummarySink) Unwrap() []LogSink {
+	return []LogSink{s.options.Sink}
+}
+
+var _ LogSink = (*SummarySink)(nil)
+var _ WrapperSink = (*SummarySink)(nil)
@@ -26,4 +26,11 @@ func (s *SynchronizedWriter) Write(p []byte) (n int, err error) {
 	return s.Underlying.Write(p)
 }
 
+// Flush flushes the underlying io.Writer if it buffers writes, e.g. a bufio.Writer
+func (s *SynchronizedWriter) Flush() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return flushWriter(s.Underlying)
+}
+
 var _ io.Writer = (*SynchronizedWriter)(nil)
@@ -0,0 +1,177 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// processStart approximates the time the process started, for ElapsedTimestampEncoder
+var processStart = time.Now()
+
+// TimestampMode determines how a sink represents timestamps
+type TimestampMode int
+
+const (
+	// TimestampFormatted formats timestamps as strings using the sink's TimestampEncoder
+	TimestampFormatted TimestampMode = iota
+	// TimestampUnixSeconds represents timestamps as the number of seconds since the Unix epoch, with a fractional part
+	// (precise to around a microsecond)
+	TimestampUnixSeconds
+	// TimestampUnixMillis represents timestamps as the whole number of milliseconds since the Unix epoch
+	TimestampUnixMillis
+	// TimestampUnixNanos represents timestamps as the whole number of nanoseconds since the Unix epoch
+	TimestampUnixNanos
+)
+
+// epoch represents a timestamp as a number according to the mode, returning false for TimestampFormatted. Epoch
+// timestamps are unaffected by the location.
+func (m TimestampMode) epoch(t time.Time) (interface{}, bool) {
+	switch m {
+	case TimestampUnixSeconds:
+		return float64(t.UnixNano()) / float64(time.Second), true
+	case TimestampUnixMillis:
+		return t.UnixNano() / int64(time.Millisecond), true
+	case TimestampUnixNanos:
+		return t.UnixNano(), true
+	default:
+		return nil, false
+	}
+}
+
+// formatTimestamp formats a timestamp for TimestampFormatted, converting it to the given location (if any) and
+// formatting it using the encoder
+func formatTimestamp(t time.Time, location *time.Location, encoder func(t time.Time) string) string {
+	if location != nil {
+		t = t.In(location)
+	}
+	return encoder(t)
+}
+
+// text represents a timestamp according to the mode for text formats, formatting epoch timestamps in full rather than
+// in exponent form
+func (m TimestampMode) text(t time.Time, location *time.Location, encoder func(t time.Time) string) string {
+	epoch, _ := m.epoch(t)
+	switch value := epoch.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	default:
+		return formatTimestamp(t, location, encoder)
+	}
+}
+
+// ProcessStartTime returns the time the process started, as used by ElapsedTimestampEncoder. It is approximated by
+// the time this package was initialised.
+func ProcessStartTime() time.Time {
+	return processStart
+}
+
+// ElapsedTimestampEncoder creates a timestamp encoder formatting timestamps as the time elapsed since the given start
+// time (typically ProcessStartTime), e.g. "+1.250s", which can be easier to follow than wall clock times during local
+// development
+func ElapsedTimestampEncoder(start time.Time) func(t time.Time) string {
+	return func(t time.Time) string {
+		elapsed := t.Sub(start)
+		sign := "+"
+		if elapsed < 0 {
+			sign = "-"
+			elapsed = -elapsed
+		}
+		return fmt.Sprintf("%s%d.%03ds", sign, elapsed/time.Second, (elapsed%time.Second)/time.Millisecond)
+	}
+}
+
+// DeltaTimestampEncoder creates a timestamp encoder formatting timestamps as the time elapsed since the previous
+// entry's timestamp, e.g. "+12ms", which makes the latency between steps easy to see during local development. The
+// first entry is formatted as "+0s". Entries logged concurrently may be encoded out of order, in which case the delta
+// is measured from the latest timestamp seen so far and may be negative. It is safe for concurrent use, but should
+// not be shared between sinks.
+func DeltaTimestampEncoder() func(t time.Time) string {
+	var lock sync.Mutex
+	var previous time.Time
+
+	return func(t time.Time) string {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if previous.IsZero() {
+			previous = t
+		}
+		delta := t.Sub(previous)
+		if t.After(previous) {
+			previous = t
+		}
+		return formatDelta(delta)
+	}
+}
+
+// RelativeTimestampEncoder creates a timestamp encoder combining ElapsedTimestampEncoder and DeltaTimestampEncoder,
+// formatting timestamps as both the time elapsed since the given start time and since the previous entry, e.g.
+// "+1.250s (+12ms)"
+func RelativeTimestampEncoder(start time.Time) func(t time.Time) string {
+	elapsed := ElapsedTimestampEncoder(start)
+	delta := DeltaTimestampEncoder()
+	return func(t time.Time) string {
+		return elapsed(t) + " (" + delta(t) + ")"
+	}
+}
+
+// formatDelta formats a duration compactly with a sign, in the largest unit that keeps it readable, e.g. "+850µs",
+// "+12ms", "+1.250s" or "+2m3s"
+func formatDelta(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	switch {
+	case d == 0:
+		return sign + "0s"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%s%dµs", sign, d/time.Microsecond)
+	case d < time.Second:
+		return fmt.Sprintf("%s%dms", sign, d/time.Millisecond)
+	case d < time.Minute:
+		return fmt.Sprintf("%s%d.%03ds", sign, d/time.Second, (d%time.Second)/time.Millisecond)
+	default:
+		return sign + d.Round(time.Second).String()
+	}
+}
+
+// EpochTimestampDecoder creates a timestamp decoder for use with a JSONDecoder, parsing timestamps encoded as numbers
+// using the given TimestampMode, so that entries written with epoch timestamps can be read back
+func EpochTimestampDecoder(mode TimestampMode) func(v interface{}) (time.Time, error) {
+	return func(v interface{}) (time.Time, error) {
+		number, ok := v.(json.Number)
+		if !ok {
+			return time.Time{}, fmt.Errorf("expected timestamp number, got %T", v)
+		}
+
+		switch mode {
+		case TimestampUnixSeconds:
+			seconds, err := number.Float64()
+			if err != nil {
+				return time.Time{}, err
+			}
+			whole, fraction := math.Modf(seconds)
+			return time.Unix(int64(whole), int64(math.Round(fraction*float64(time.Second)))).UTC(), nil
+		case TimestampUnixMillis, TimestampUnixNanos:
+			n, err := number.Int64()
+			if err != nil {
+				return time.Time{}, err
+			}
+			if mode == TimestampUnixMillis {
+				n *= int64(time.Millisecond)
+			}
+			return time.Unix(0, n).UTC(), nil
+		default:
+			return time.Time{}, fmt.Errorf("timestamp mode %d is not an epoch mode", mode)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package simplelogr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Transform operations supported by TransformSpec
+const (
+	// TransformRename renames the key-value pair with the Key to the To key
+	TransformRename = "rename"
+	// TransformDrop removes the key-value pair with the Key
+	TransformDrop = "drop"
+	// TransformAdd adds a key-value pair with the Key and the static Value
+	TransformAdd = "add"
+	// TransformMapSeverity changes the severity of entries with the From severity to the To severity
+	TransformMapSeverity = "map_severity"
+)
+
+// TransformSpec declaratively describes a simple transformation of entries, so that the shape of logs can be adjusted
+// through configuration rather than code, e.g. as JSON:
+//
+//	[
+//	    {"op": "rename", "key": "uid", "to": "user_id"},
+//	    {"op": "drop", "key": "password"},
+//	    {"op": "add", "key": "env", "value": "production"},
+//	    {"op": "map_severity", "from": "DEBUG", "to": "INFO"}
+//	]
+//
+// See CompileTransforms
+type TransformSpec struct {
+	// Op is the operation to perform, one of TransformRename, TransformDrop, TransformAdd or TransformMapSeverity
+	Op string `json:"op"`
+	// Key is the key of the key-value pair being operated on
+	Key string `json:"key,omitempty"`
+	// To is the new key when renaming, or the new severity when mapping severities
+	To string `json:"to,omitempty"`
+	// From is the severity being mapped when mapping severities
+	From string `json:"from,omitempty"`
+	// Value is the value of the key-value pair being added
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ParseTransforms decodes a JSON array of TransformSpec
+func ParseTransforms(data []byte) ([]TransformSpec, error) {
+	var specs []TransformSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to decode transforms: %w", err)
+	}
+	return specs, nil
+}
+
+// CompileTransforms converts TransformSpec descriptions into Processor functions for use with a ProcessorSink,
+// validating them in the process. The severity encoder is used to determine the severity of entries when mapping
+// severities.
+func CompileTransforms(specs []TransformSpec, severityEncoder func(level int, err error) string) ([]Processor, error) {
+	processors := make([]Processor, 0, len(specs))
+
+	for i, spec := range specs {
+		switch spec.Op {
+		case TransformRename:
+			if spec.Key == "" || spec.To == "" {
+				return nil, fmt.Errorf("transform %d: %s requires key and to", i, spec.Op)
+			}
+			processors = append(processors, RenameKey(spec.Key, spec.To))
+		case TransformDrop:
+			if spec.Key == "" {
+				return nil, fmt.Errorf("transform %d: %s requires key", i, spec.Op)
+			}
+			processors = append(processors, DropKey(spec.Key))
+		case TransformAdd:
+			if spec.Key == "" {
+				return nil, fmt.Errorf("transform %d: %s requires key", i, spec.Op)
+			}
+			processors = append(processors, AddKV(spec.Key, spec.Value))
+		case TransformMapSeverity:
+			if spec.From == "" || spec.To == "" {
+				return nil, fmt.Errorf("transform %d: %s requires from and to", i, spec.Op)
+			}
+			processors = append(processors, MapSeverity(spec.From, spec.To, severityEncoder))
+		default:
+			return nil, fmt.Errorf("transform %d: unknown op %q", i, spec.Op)
+		}
+	}
+
+	return processors, nil
+}
+
+// RenameKey creates a Processor renaming key-value pairs with the given key
+func RenameKey(from string, to string) Processor {
+	return func(e Entry) (Entry, bool) {
+		kvs := make([]interface{}, len(e.KVs))
+		copy(kvs, e.KVs)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if k, ok := kvs[i].(string); ok && k == from {
+				kvs[i] = to
+			}
+		}
+		e.KVs = kvs
+		return e, true
+	}
+}
+
+// DropKey creates a Processor removing key-value pairs with the given key
+func DropKey(key string) Processor {
+	return func(e Entry) (Entry, bool) {
+		kvs := make([]interface{}, 0, len(e.KVs))
+		for i := 0; i+1 < len(e.KVs); i += 2 {
+			if k, ok := e.KVs[i].(string); ok && k == key {
+				continue
+			}
+			kvs = append(kvs, e.KVs[i], e.KVs[i+1])
+		}
+		e.KVs = kvs
+		return e, true
+	}
+}
+
+// AddKV creates a Processor adding a key-value pair with a static value to every Entry
+func AddKV(key string, value interface{}) Processor {
+	return func(e Entry) (Entry, bool) {
+		kvs := make([]interface{}, len(e.KVs), len(e.KVs)+2)
+		copy(kvs, e.KVs)
+		e.KVs = append(kvs, key, value)
+		return e, true
+	}
+}
+
+// MapSeverity creates a Processor changing the severity of entries with one severity to another, see Entry.Severity
+func MapSeverity(from string, to string, severityEncoder func(level int, err error) string) Processor {
+	return func(e Entry) (Entry, bool) {
+		if e.severity(severityEncoder) == from {
+			e.Severity = to
+		}
+		return e, true
+	}
+}
@@ -0,0 +1,40 @@
+package simplelogr
+
+import (
+	"unicode/utf8"
+)
+
+var (
+	// DefaultTruncatedKey is the key sinks use to mark entries in which values were truncated
+	DefaultTruncatedKey = "_truncated"
+	// DefaultTruncationSuffix is appended to truncated values and messages
+	DefaultTruncationSuffix = "…"
+)
+
+// truncateString shortens the string to at most max bytes, without splitting a UTF-8 encoded character, followed by
+// DefaultTruncationSuffix. It returns false if the string is not truncated, including when max is not positive.
+func truncateString(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + DefaultTruncationSuffix, true
+}
+
+// truncateEncoded shortens text that has been appended to the buffer since start to at most max bytes, in the same
+// way as truncateString, returning false if it is not truncated
+func truncateEncoded(buf []byte, start int, max int) ([]byte, bool) {
+	if max <= 0 || len(buf)-start <= max {
+		return buf, false
+	}
+
+	cut := start + max
+	for cut > start && !utf8.RuneStart(buf[cut]) {
+		cut--
+	}
+	return append(buf[:cut], DefaultTruncationSuffix...), true
+}
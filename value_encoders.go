@@ -0,0 +1,120 @@
+package simplelogr
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// valueEncoders is an immutable snapshot of the registered value encoders, replaced whenever one is registered, so
+// that the many values resolved when logging are looked up without locking
+type valueEncoders struct {
+	types      map[reflect.Type]func(v interface{}) interface{}
+	interfaces []registeredInterfaceEncoder
+	// implemented caches the encoder (or nil) of types that have been checked against the interfaces
+	implemented sync.Map
+}
+
+// registeredInterfaceEncoder is an encoder registered for the types implementing an interface
+type registeredInterfaceEncoder struct {
+	iface  reflect.Type
+	encode func(v interface{}) interface{}
+}
+
+var (
+	valueEncodersLock sync.Mutex
+	// registeredValueEncoders holds the current *valueEncoders, or nil if none are registered
+	registeredValueEncoders atomic.Value
+)
+
+// RegisterValueEncoder associates a type with a function converting values of that type into values that sinks can
+// encode, e.g. a string, without the type having to implement json.Marshaler. If the type is an interface, the encoder
+// applies to all types implementing it, with encoders registered for concrete types taking precedence, then those for
+// interfaces in the order they were registered. For example:
+//
+//	simplelogr.RegisterValueEncoder(reflect.TypeOf(net.IP{}), func(v interface{}) interface{} {
+//	    return v.(net.IP).String()
+//	})
+//	simplelogr.RegisterValueEncoder(reflect.TypeOf((*proto.Message)(nil)).Elem(), func(v interface{}) interface{} {
+//	    b, _ := protojson.Marshal(v.(proto.Message))
+//	    return json.RawMessage(b)
+//	})
+//
+// Encoders are shared by all sinks, and apply to the values of key-value pairs (after resolving Lazy and slog values),
+// but not to values nested within maps, slices or structs. Registering an encoder for a type replaces any previously
+// registered for it. Encoders should be registered before logging, typically in an init function.
+func RegisterValueEncoder(t reflect.Type, encode func(v interface{}) interface{}) error {
+	if t == nil || encode == nil {
+		return fmt.Errorf("value encoder for type %v must have a type and an encode function", t)
+	}
+
+	valueEncodersLock.Lock()
+	defer valueEncodersLock.Unlock()
+
+	updated := &valueEncoders{
+		types: map[reflect.Type]func(v interface{}) interface{}{},
+	}
+	if current, _ := registeredValueEncoders.Load().(*valueEncoders); current != nil {
+		for k, v := range current.types {
+			updated.types[k] = v
+		}
+		updated.interfaces = append(updated.interfaces, current.interfaces...)
+	}
+
+	if t.Kind() == reflect.Interface {
+		replaced := false
+		for i, registered := range updated.interfaces {
+			if registered.iface == t {
+				updated.interfaces[i].encode = encode
+				replaced = true
+			}
+		}
+		if !replaced {
+			updated.interfaces = append(updated.interfaces, registeredInterfaceEncoder{iface: t, encode: encode})
+		}
+	} else {
+		updated.types[t] = encode
+	}
+
+	registeredValueEncoders.Store(updated)
+	return nil
+}
+
+// encodeRegisteredValue converts the value using the encoder registered for its type, if any, see
+// RegisterValueEncoder
+func encodeRegisteredValue(v interface{}) interface{} {
+	encoders, _ := registeredValueEncoders.Load().(*valueEncoders)
+	if encoders == nil || v == nil {
+		return v
+	}
+
+	t := reflect.TypeOf(v)
+	if encode, ok := encoders.types[t]; ok {
+		return encode(v)
+	}
+	if len(encoders.interfaces) == 0 {
+		return v
+	}
+
+	if cached, ok := encoders.implemented.Load(t); ok {
+		if encode := cached.(func(v interface{}) interface{}); encode != nil {
+			return encode(v)
+		}
+		return v
+	}
+
+	var encode func(v interface{}) interface{}
+	for _, registered := range encoders.interfaces {
+		if t.Implements(registered.iface) {
+			encode = registered.encode
+			break
+		}
+	}
+	encoders.implemented.Store(t, encode)
+
+	if encode != nil {
+		return encode(v)
+	}
+	return v
+}
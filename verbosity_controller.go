@@ -0,0 +1,177 @@
+package simplelogr
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VerbosityController allows the verbosity of Logger objects to be changed while the program is running, see
+// Options.Controller. As well as the overall verbosity, it supports overriding the verbosity for loggers with a given
+// name prefix, and temporarily boosting verbosity (e.g. for a live debugging session) which automatically reverts
+// after a given duration. It is safe for concurrent use.
+type VerbosityController struct {
+	lock      sync.RWMutex
+	verbosity int
+	names     map[string]int
+	boosts    []*verbosityBoost
+	now       func() time.Time
+}
+
+type verbosityBoost struct {
+	prefix  string
+	key     string
+	value   interface{}
+	byValue bool
+	level   int
+	expires time.Time
+}
+
+// NewVerbosityController creates a new VerbosityController with the given initial overall verbosity
+func NewVerbosityController(verbosity int) *VerbosityController {
+	return &VerbosityController{
+		verbosity: verbosity,
+		names:     map[string]int{},
+		now:       time.Now,
+	}
+}
+
+// Verbosity returns the overall verbosity
+func (c *VerbosityController) Verbosity() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.verbosity
+}
+
+// SetVerbosity changes the overall verbosity
+func (c *VerbosityController) SetVerbosity(verbosity int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.verbosity = verbosity
+}
+
+// SetNameVerbosity overrides the verbosity of loggers whose name starts with the given prefix (see Logger.WithName),
+// names are matched whole segments at a time, joined using DefaultNameSeparator. When several prefixes match, the
+// longest takes precedence.
+func (c *VerbosityController) SetNameVerbosity(prefix string, verbosity int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.names[prefix] = verbosity
+}
+
+// SetNameVerbosities replaces all verbosity overrides previously added with SetNameVerbosity
+func (c *VerbosityController) SetNameVerbosities(names map[string]int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.names = make(map[string]int, len(names))
+	for prefix, verbosity := range names {
+		c.names[prefix] = verbosity
+	}
+}
+
+// ClearNameVerbosity removes an override previously added with SetNameVerbosity
+func (c *VerbosityController) ClearNameVerbosity(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.names, prefix)
+}
+
+// Boost temporarily raises the verbosity of loggers whose name starts with the given prefix to at least the given
+// level, for the given duration. The returned function cancels the boost early.
+func (c *VerbosityController) Boost(prefix string, verbosity int, duration time.Duration) (cancel func()) {
+	return c.addBoost(&verbosityBoost{
+		prefix: prefix,
+		level:  verbosity,
+	}, duration)
+}
+
+// BoostValue temporarily raises the verbosity of loggers carrying the given key-value pair (see Logger.WithValues),
+// e.g. a correlation ID, to at least the given level, for the given duration. The returned function cancels the boost
+// early.
+func (c *VerbosityController) BoostValue(key string, value interface{}, verbosity int, duration time.Duration) (cancel func()) {
+	return c.addBoost(&verbosityBoost{
+		key:     key,
+		value:   value,
+		byValue: true,
+		level:   verbosity,
+	}, duration)
+}
+
+// Enabled determines whether a logger with the given names and accumulated key-value pairs should emit messages at
+// the given verbosity level
+func (c *VerbosityController) Enabled(names []string, values []interface{}, level int) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	verbosity := c.verbosity
+	if len(c.names) > 0 {
+		longest := -1
+		joined := strings.Join(names, DefaultNameSeparator)
+		for prefix, v := range c.names {
+			if len(prefix) > longest && nameHasPrefix(joined, prefix) {
+				longest = len(prefix)
+				verbosity = v
+			}
+		}
+	}
+
+	if len(c.boosts) > 0 {
+		now := c.now()
+		joined := strings.Join(names, DefaultNameSeparator)
+		for _, b := range c.boosts {
+			if b.level <= verbosity || !now.Before(b.expires) {
+				continue
+			}
+			if b.byValue && !valuesHaveKV(values, b.key, b.value) {
+				continue
+			}
+			if !b.byValue && !nameHasPrefix(joined, b.prefix) {
+				continue
+			}
+			verbosity = b.level
+		}
+	}
+
+	return verbosity >= level
+}
+
+func (c *VerbosityController) addBoost(b *verbosityBoost, duration time.Duration) func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	b.expires = now.Add(duration)
+
+	active := c.boosts[:0]
+	for _, existing := range c.boosts {
+		if now.Before(existing.expires) {
+			active = append(active, existing)
+		}
+	}
+	c.boosts = append(active, b)
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		for i, existing := range c.boosts {
+			if existing == b {
+				c.boosts = append(c.boosts[:i], c.boosts[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func nameHasPrefix(name string, prefix string) bool {
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+DefaultNameSeparator)
+}
+
+func valuesHaveKV(values []interface{}, key string, value interface{}) bool {
+	for i := 0; i+1 < len(values); i += 2 {
+		if k, ok := values[i].(string); ok && k == key && reflect.DeepEqual(values[i+1], value) {
+			return true
+		}
+	}
+	return false
+}